@@ -0,0 +1,86 @@
+package csrf
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type failure_counter struct {
+	window_start time.Time
+	count        int
+	alerted      bool
+}
+
+type failure_alert struct {
+	mu         sync.Mutex
+	counters   map[string]*failure_counter
+	last_prune time.Time
+	threshold  int
+	window     time.Duration
+	on_alert   func(ip string, count int)
+}
+
+func client_ip(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func (fa *failure_alert) record(now time.Time, ip string) {
+
+	fa.mu.Lock()
+	if now.Sub(fa.last_prune) >= fa.window {
+		for key, counter := range fa.counters {
+			if now.Sub(counter.window_start) >= fa.window {
+				delete(fa.counters, key)
+			}
+		}
+		fa.last_prune = now
+	}
+
+	counter, found := fa.counters[ip]
+	if !found || now.Sub(counter.window_start) >= fa.window {
+		counter = &failure_counter{window_start: now}
+		fa.counters[ip] = counter
+	}
+
+	counter.count++
+	alert := counter.count > fa.threshold && !counter.alerted
+	if alert {
+		counter.alerted = true
+	}
+	count := counter.count
+	fa.mu.Unlock()
+
+	if alert {
+		fa.on_alert(ip, count)
+	}
+}
+
+// WithFailureAlert count validation failures per client ip and call `on_alert` once per `window`
+// when the count of a client exceed `threshold` within the window. the ip is taken by `ip`,
+// default to the host of [http.Request.RemoteAddr], see [TrustedProxyClientIP] behind reverse proxy.
+// the window is measured by the clock set by [WithClock]
+func WithFailureAlert(threshold int, window time.Duration, ip func(*http.Request) string, on_alert func(ip string, count int)) Option {
+	if ip == nil {
+		ip = client_ip
+	}
+
+	fa := &failure_alert{
+		counters:  make(map[string]*failure_counter),
+		threshold: threshold,
+		window:    window,
+		on_alert:  on_alert,
+	}
+
+	return func(c *CSRF) {
+		c.on_failure = append(c.on_failure, func(f Failure) {
+			fa.record(c.clock.Now(), ip(f.Request))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package csrf_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestWithFailureAlert(t *testing.T) {
+	const window = time.Minute
+
+	type alert struct {
+		ip    string
+		count int
+	}
+	var alerts []alert
+	clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+	c := csrf.New(new_provider(t, csrf.Config{}),
+		csrf.WithFailureAlert(3, window, nil, func(ip string, count int) {
+			alerts = append(alerts, alert{ip, count})
+		}),
+		csrf.WithClock(clock),
+	)
+
+	fail := func(ip string, n int) {
+		for range n {
+			r := post("/", "unknown")
+			r.RemoteAddr = ip + ":1234"
+			if err := c.Validate(r, csrf.HeaderTokenSource); err == nil {
+				t.Fatal("Validate of unknown token passed")
+			}
+		}
+	}
+
+	fail("192.0.2.1", 3)
+	fail("192.0.2.2", 3)
+	if len(alerts) != 0 {
+		t.Fatalf("alerts at threshold: %v", alerts)
+	}
+
+	fail("192.0.2.1", 5)
+	if want := []alert{{"192.0.2.1", 4}}; fmt.Sprint(alerts) != fmt.Sprint(want) {
+		t.Fatalf("alerts = %v, want %v", alerts, want)
+	}
+
+	clock.Advance(window - time.Second)
+	fail("192.0.2.2", 1)
+	if want := []alert{{"192.0.2.1", 4}, {"192.0.2.2", 4}}; fmt.Sprint(alerts) != fmt.Sprint(want) {
+		t.Fatalf("alerts within the window = %v, want %v", alerts, want)
+	}
+
+	clock.Advance(time.Second)
+	fail("192.0.2.1", 4)
+	if want := []alert{{"192.0.2.1", 4}, {"192.0.2.2", 4}, {"192.0.2.1", 4}}; fmt.Sprint(alerts) != fmt.Sprint(want) {
+		t.Fatalf("alerts in the next window = %v, want %v", alerts, want)
+	}
+}
+
+func TestWithFailureAlertIP(t *testing.T) {
+	var got []string
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithFailureAlert(0, time.Minute,
+		func(r *http.Request) string { return r.Header.Get("X-Real-Ip") },
+		func(ip string, count int) { got = append(got, ip) },
+	))
+
+	r := post("/", "unknown")
+	r.Header.Set("X-Real-Ip", "198.51.100.7")
+	if err := c.Validate(r, csrf.HeaderTokenSource); err == nil {
+		t.Fatal("Validate of unknown token passed")
+	}
+
+	if len(got) != 1 || got[0] != "198.51.100.7" {
+		t.Errorf("alerted ips = %v, want [198.51.100.7]", got)
+	}
+}
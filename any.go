@@ -0,0 +1,26 @@
+package csrf
+
+import "net/http"
+
+// AnySource return the token of the first of `sources` which supply one, e.g. header or form field
+// whichever is present. unlike passing `sources` to [CSRF.Validate] the other sources are not compared
+func AnySource(sources ...TokenSourceFunc) TokenSourceFunc {
+	return func(r *http.Request) string {
+		for _, source := range sources {
+			if token := source(r); token != "" {
+				return token
+			}
+		}
+
+		return ""
+	}
+}
+
+// ValidateAny is [CSRF.Validate] accepting the token of the first source which supply one, see [AnySource]
+func (c *CSRF) ValidateAny(r *http.Request, sources ...TokenSourceFunc) error {
+	if len(sources) == 0 {
+		return c.Validate(r)
+	}
+
+	return c.Validate(r, AnySource(sources...))
+}
@@ -0,0 +1,47 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestValidateAny(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithTokenMode(csrf.MultiUse))
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []csrf.TokenSourceFunc{csrf.HeaderTokenSource, csrf.QueryTokenSource("csrf")}
+
+	tests := []struct {
+		name    string
+		r       *http.Request
+		sources []csrf.TokenSourceFunc
+		want    error
+	}{
+		{"first source", post("/", token), sources, nil},
+		{"second source", post("/?csrf="+token, ""), sources, nil},
+		{"first source win", post("/?csrf=unknown", token), sources, nil},
+		{"first source invalid", post("/?csrf="+token, "unknown"), sources, csrf.ErrTokenNotFound},
+		{"none", post("/", ""), sources, csrf.ErrTokenMissing},
+		{"no sources", post("/", token), nil, csrf.ErrNoSources},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := c.ValidateAny(tt.r, tt.sources...); !errors.Is(err, tt.want) {
+				t.Errorf("ValidateAny = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// unlike Validate which compare every source
+	if err := c.Validate(post("/?csrf=unknown", token), sources...); !errors.Is(err, csrf.ErrInconsistentTokenBetweenSources) {
+		t.Errorf("Validate = %v, want %v", err, csrf.ErrInconsistentTokenBetweenSources)
+	}
+}
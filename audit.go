@@ -0,0 +1,178 @@
+package csrf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AuditRecord is the audit log entry of failed validation, see [WithAudit]
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	RemoteIP  string    `json:"remote_ip"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	// Source is [Failure.Source]
+	Source string `json:"source,omitempty"`
+	// TokenHash is [Failure.TokenHash]
+	TokenHash string `json:"token_hash,omitempty"`
+	// Reason is [FailureReason] of the error
+	Reason   string `json:"reason"`
+	FailOpen bool   `json:"fail_open,omitempty"`
+}
+
+// AuditSink receive [AuditRecord], it is called synchronously on the request path
+type AuditSink interface {
+	Audit(AuditRecord)
+}
+
+// AuditSinkFunc adapt function to [AuditSink]
+type AuditSinkFunc func(AuditRecord)
+
+func (fn AuditSinkFunc) Audit(record AuditRecord) { fn(record) }
+
+// WithAudit record every failed validation to `sink`, the remote ip is taken by `ip`,
+// default to the host of [http.Request.RemoteAddr], see [TrustedProxyClientIP] behind reverse proxy
+func WithAudit(sink AuditSink, ip func(*http.Request) string) Option {
+	if ip == nil {
+		ip = client_ip
+	}
+
+	return OnFailure(func(f Failure) {
+		sink.Audit(AuditRecord{
+			Time:      time.Now(),
+			Method:    f.Request.Method,
+			Path:      f.Request.URL.Path,
+			RemoteIP:  ip(f.Request),
+			UserAgent: f.Request.UserAgent(),
+			Source:    f.Source,
+			TokenHash: f.TokenHash,
+			Reason:    f.Reason,
+			FailOpen:  f.FailOpen,
+		})
+	})
+}
+
+type writer_sink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// WriterAuditSink write every record to `w` as json line, write error is ignored
+func WriterAuditSink(w io.Writer) AuditSink {
+	return &writer_sink{enc: json.NewEncoder(w)}
+}
+
+func (ws *writer_sink) Audit(record AuditRecord) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	_ = ws.enc.Encode(record)
+}
+
+// SlogAuditSink log every record at warn level, unlike [WithLogger] the token hash and remote ip are included
+func SlogAuditSink(logger *slog.Logger) AuditSink {
+	return AuditSinkFunc(func(record AuditRecord) {
+		logger.LogAttrs(context.Background(), slog.LevelWarn, "csrf: audit",
+			slog.String("method", record.Method),
+			slog.String("path", record.Path),
+			slog.String("remote_ip", record.RemoteIP),
+			slog.String("user_agent", record.UserAgent),
+			slog.String("source", record.Source),
+			slog.String("token_hash", record.TokenHash),
+			slog.String("reason", record.Reason),
+			slog.Bool("fail_open", record.FailOpen),
+		)
+	})
+}
+
+// WebhookAuditSink POST every record as json to url in the background, so slow endpoint does not delay the request.
+// records are dropped once `buffer` records are pending
+type WebhookAuditSink struct {
+	url     string
+	client  *http.Client
+	records chan AuditRecord
+	done    chan struct{}
+	dropped atomic.Uint64
+}
+
+var _ AuditSink = (*WebhookAuditSink)(nil)
+
+// NewWebhookAuditSink return [WebhookAuditSink] posting to `url` with `client`, default to [http.DefaultClient].
+// the background goroutine stops when `ctx` is done
+func NewWebhookAuditSink(ctx context.Context, url string, client *http.Client, buffer int) *WebhookAuditSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	if buffer <= 0 {
+		buffer = 1024
+	}
+
+	ws := &WebhookAuditSink{
+		url:     url,
+		client:  client,
+		records: make(chan AuditRecord, buffer),
+		done:    make(chan struct{}),
+	}
+
+	go ws.run(ctx)
+	return ws
+}
+
+func (ws *WebhookAuditSink) Audit(record AuditRecord) {
+	select {
+	case ws.records <- record:
+	default:
+		ws.dropped.Add(1)
+	}
+}
+
+// Dropped return the number of records dropped because the buffer was full
+func (ws *WebhookAuditSink) Dropped() uint64 { return ws.dropped.Load() }
+
+// Done is closed once the background goroutine exited
+func (ws *WebhookAuditSink) Done() <-chan struct{} { return ws.done }
+
+func (ws *WebhookAuditSink) run(ctx context.Context) {
+	defer close(ws.done)
+
+	ctx_done := ctx.Done()
+	for {
+		select {
+		case <-ctx_done:
+			return
+		case record := <-ws.records:
+			// error is ignored, the audit log is best effort
+			_ = ws.post(ctx, record)
+		}
+	}
+}
+
+func (ws *WebhookAuditSink) post(ctx context.Context, record AuditRecord) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ws.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
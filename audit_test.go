@@ -0,0 +1,91 @@
+package csrf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestWithAudit(t *testing.T) {
+	var buf bytes.Buffer
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithAudit(csrf.WriterAuditSink(&buf), nil))
+
+	r := post("/transfer", "unknown")
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("User-Agent", "test")
+	if err := c.Validate(r, csrf.HeaderTokenSource); err == nil {
+		t.Fatal("Validate of unknown token passed")
+	}
+
+	var record csrf.AuditRecord
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("audit log %q: %v", buf.String(), err)
+	}
+
+	want := csrf.AuditRecord{
+		Method:    http.MethodPost,
+		Path:      "/transfer",
+		RemoteIP:  "192.0.2.1",
+		UserAgent: "test",
+		Source:    record.Source,
+		TokenHash: hash("unknown"),
+		Reason:    csrf.FailureReason(csrf.ErrTokenNotFound),
+	}
+	if record.Time.IsZero() || record.Source == "" {
+		t.Errorf("record = %+v, want time and source", record)
+	}
+
+	record.Time = time.Time{}
+	if record != want {
+		t.Errorf("record = %+v, want %+v", record, want)
+	}
+
+	// passed validation is not audited
+	buf.Reset()
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Validate(post("/", token), csrf.HeaderTokenSource); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("audited valid request: %s", buf.String())
+	}
+}
+
+func TestWebhookAuditSink(t *testing.T) {
+	records := make(chan csrf.AuditRecord, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var record csrf.AuditRecord
+		if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+			t.Error(err)
+		}
+		records <- record
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sink := csrf.NewWebhookAuditSink(ctx, srv.URL, srv.Client(), 1)
+	sink.Audit(csrf.AuditRecord{Path: "/transfer", Reason: "token_not_found"})
+
+	select {
+	case record := <-records:
+		if record.Path != "/transfer" || record.Reason != "token_not_found" {
+			t.Errorf("posted record = %+v", record)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("record was not posted")
+	}
+
+	cancel()
+	<-sink.Done()
+}
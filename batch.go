@@ -0,0 +1,210 @@
+package csrf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// BatchChecker is optional interface for [TokenProvider] which check many tokens in one round trip,
+// the returned slice has the error of each token at the same index, like Check it must not delete the tokens
+type BatchChecker interface {
+	CheckBatch(ctx context.Context, tokens []string) []error
+}
+
+var (
+	_ BatchChecker = (*DefaultTokenProvider)(nil)
+	_ BatchChecker = (*HashedTokenProvider)(nil)
+	_ BatchChecker = (*ShardedTokenProvider)(nil)
+	_ BatchChecker = (*SQLTokenProvider)(nil)
+)
+
+func (dtp *DefaultTokenProvider) CheckBatch(ctx context.Context, tokens []string) []error {
+	errs := make([]error, len(tokens))
+
+	defer dtp.lock_check()()
+
+	for i, token := range tokens {
+		errs[i] = dtp.check(ctx, token)
+	}
+
+	return errs
+}
+
+func (htp *HashedTokenProvider) CheckBatch(ctx context.Context, tokens []string) []error {
+	hashed := make([]string, len(tokens))
+	for i, token := range tokens {
+		hashed[i] = hash_token(token)
+	}
+
+	errs := htp.dtp.CheckBatch(ctx, hashed)
+	for i, token := range tokens {
+		if token == "" {
+			errs[i] = ErrTokenMissing
+		}
+	}
+
+	return errs
+}
+
+// CheckBatch lock each shard once for all of its tokens
+func (stp *ShardedTokenProvider) CheckBatch(ctx context.Context, tokens []string) []error {
+	errs := make([]error, len(tokens))
+	by_shard := make(map[*DefaultTokenProvider][]int)
+	for i, token := range tokens {
+		shard := stp.shard(token)
+		by_shard[shard] = append(by_shard[shard], i)
+	}
+
+	for shard, indexes := range by_shard {
+		unlock := shard.lock_check()
+		for _, i := range indexes {
+			errs[i] = shard.check(ctx, tokens[i])
+		}
+		unlock()
+	}
+
+	return errs
+}
+
+// sql_batch_size is the max number of tokens of single CheckBatch query, below the bound parameter limit
+// of every common database (e.g. 999 of old sqlite)
+const sql_batch_size = 500
+
+// CheckBatch check the tokens with one query per sql_batch_size tokens, error of the query is returned for every token of the query
+func (stp *SQLTokenProvider) CheckBatch(ctx context.Context, tokens []string) []error {
+	errs := make([]error, len(tokens))
+	for start := 0; start < len(tokens); start += sql_batch_size {
+		end := min(start+sql_batch_size, len(tokens))
+		stp.check_batch(ctx, tokens[start:end], errs[start:end])
+	}
+
+	return errs
+}
+
+func (stp *SQLTokenProvider) check_batch(ctx context.Context, tokens []string, errs []error) {
+	args := []any{sql_subject.Replace(SessionIDFromContext(ctx)), stp.valid_after()}
+	placeholders := make([]string, len(tokens))
+	for i, token := range tokens {
+		args = append(args, token)
+		placeholders[i] = stp.placeholder(len(args))
+	}
+
+	query := fmt.Sprintf("SELECT token FROM %s WHERE subject = %s AND expire_at > %s AND token IN (%s)",
+		stp.table, stp.placeholder(1), stp.placeholder(2), strings.Join(placeholders, ", "))
+	rows, err := stp.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		fill(errs, err)
+		return
+	}
+	defer rows.Close()
+
+	found := make(map[string]bool, len(tokens))
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			fill(errs, err)
+			return
+		}
+		found[token] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		fill(errs, err)
+		return
+	}
+
+	for i, token := range tokens {
+		if !found[token] {
+			errs[i] = ErrTokenNotFound
+		}
+	}
+}
+
+func fill(errs []error, err error) []error {
+	for i := range errs {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// CheckBatch validate every token of bulk submission `r` (e.g. per item token) like [CSRF.Validate]:
+// the validators run once for the request, then each token is checked for format and [WithIssuedAfter] cutoff,
+// bound to the subject, bindings and scope of `r`, consumed in [SingleUse] mode and reported to the failure hooks.
+// the returned slice has the error of each token at the same index. the provider is called once for the batch
+// if it implements [BatchChecker] and the tokens are not consumed
+func (c *CSRF) CheckBatch(r *http.Request, tokens []string) (errs []error) {
+	ctx, span := c.tracer.Start(c.with_scope(c.with_subject(r.Context(), r), r), "csrf.CheckBatch")
+	defer func() { end_span(span, errors.Join(errs...)) }()
+
+	errs = make([]error, len(tokens))
+	if err := c.run_validators(r); err != nil {
+		return c.report_batch(ctx, r, tokens, fill(errs, err))
+	}
+
+	indexes := make([]int, 0, len(tokens))
+	checked := make([]string, 0, len(tokens))
+	for i, value := range tokens {
+		if !c.well_formed(value) {
+			errs[i] = ErrTokenMalformed
+			continue
+		}
+
+		if c.masking {
+			value = UnmaskToken(value)
+		}
+
+		if value == "" {
+			errs[i] = ErrTokenMissing
+			continue
+		}
+
+		if errs[i] = c.precheck(ctx, value); errs[i] == nil {
+			indexes = append(indexes, i)
+			checked = append(checked, value)
+		}
+	}
+
+	for j, err := range c.check_batch(ctx, checked) {
+		errs[indexes[j]] = err
+	}
+
+	return c.report_batch(ctx, r, tokens, errs)
+}
+
+func (c *CSRF) check_batch(ctx context.Context, tokens []string) []error {
+	if bc, ok := c.TokenProvider.(BatchChecker); ok && len(tokens) > 0 && !c.consumes() {
+		defer func(start time.Time) { c.metrics.ProviderLatency("check", time.Since(start)) }(time.Now())
+
+		errs, _ := provider_span(ctx, c, "CheckBatch", func(ctx context.Context) ([]error, error) {
+			return bc.CheckBatch(ctx, tokens), nil
+		})
+		return errs
+	}
+
+	errs := make([]error, len(tokens))
+	for i, token := range tokens {
+		errs[i] = c.check(ctx, token)
+	}
+
+	return errs
+}
+
+// report_batch apply [FailurePolicy] and report every result like [CSRF.Validate], along with the hash of its token
+func (c *CSRF) report_batch(ctx context.Context, r *http.Request, tokens []string, errs []error) []error {
+	for i, err := range errs {
+		supplied := supplied_token{value: tokens[i]}
+		if c.failure_policy == FailOpen && is_backend_error(ctx, err) {
+			c.fail_open(r, supplied, err)
+			err = nil
+		}
+
+		errs[i] = c.report(r, supplied, err)
+	}
+
+	return errs
+}
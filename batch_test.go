@@ -0,0 +1,152 @@
+package csrf_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestCheckBatch(t *testing.T) {
+	tests := []struct {
+		name string
+		mode csrf.TokenMode
+		// replay is the error of checking the same tokens again
+		replay error
+	}{
+		{"single use", csrf.SingleUse, csrf.ErrTokenNotFound},
+		{"multi use", csrf.MultiUse, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := csrf.New(new_provider(t, csrf.Config{}),
+				csrf.WithTokenMode(tt.mode),
+				csrf.WithMasking(),
+				csrf.WithSubject(func(r *http.Request) string { return r.Header.Get("User") }),
+			)
+
+			issue := func(user string) string {
+				token, err := c.GetToken(csrf.WithSessionID(context.Background(), user))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			}
+
+			alice, bob := issue("alice"), issue("bob")
+			tokens := []string{alice, bob, "", "not\x00printable"}
+			want := []error{nil, csrf.ErrTokenNotFound, csrf.ErrTokenMissing, csrf.ErrTokenMalformed}
+
+			r := post("/", "")
+			r.Header.Set("User", "alice")
+			for i, err := range c.CheckBatch(r, tokens) {
+				if !errors.Is(err, want[i]) {
+					t.Errorf("CheckBatch[%d] = %v, want %v", i, err, want[i])
+				}
+			}
+
+			if err := c.CheckBatch(r, tokens[:1])[0]; !errors.Is(err, tt.replay) {
+				t.Errorf("replayed CheckBatch = %v, want %v", err, tt.replay)
+			}
+		})
+	}
+}
+
+func TestCheckBatchValidators(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithAllowedContentTypes("application/json"))
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, err := range c.CheckBatch(post("/", ""), []string{token, token}) {
+		if !errors.Is(err, csrf.ErrContentTypeNotAllowed) {
+			t.Errorf("CheckBatch[%d] = %v, want %v", i, err, csrf.ErrContentTypeNotAllowed)
+		}
+	}
+}
+
+func TestSQLCheckBatchChunked(t *testing.T) {
+	d := &batch_driver{stored: make(map[string]bool)}
+	stp := csrf.NewSQLTokenProvider(context.Background(), sql.OpenDB(d), csrf.SQLConfig{})
+	defer stp.Close()
+
+	tokens := make([]string, 1234)
+	for i := range tokens {
+		tokens[i] = fmt.Sprint("token-", i)
+		if i%2 == 0 {
+			d.stored[tokens[i]] = true
+		}
+	}
+
+	for i, err := range stp.CheckBatch(context.Background(), tokens) {
+		if want := d.stored[tokens[i]]; (err == nil) != want {
+			t.Fatalf("CheckBatch[%d] = %v, stored %v", i, err, want)
+		}
+	}
+
+	if d.queries != 3 || d.max_args > 502 {
+		t.Errorf("got %d queries with up to %d args, want 3 queries of at most 502 args", d.queries, d.max_args)
+	}
+}
+
+// batch_driver answer the CheckBatch query of [csrf.SQLTokenProvider] from `stored`, every argument
+// after the subject and expiry is a token
+type batch_driver struct {
+	stored   map[string]bool
+	queries  int
+	max_args int
+}
+
+func (d *batch_driver) Connect(context.Context) (driver.Conn, error) { return batch_conn{d}, nil }
+func (d *batch_driver) Driver() driver.Driver                        { return nil }
+
+type batch_conn struct{ d *batch_driver }
+
+func (c batch_conn) Prepare(string) (driver.Stmt, error) { return batch_stmt(c), nil }
+func (batch_conn) Close() error                          { return nil }
+func (batch_conn) Begin() (driver.Tx, error)             { return nil, errors.New("not supported") }
+
+type batch_stmt struct{ d *batch_driver }
+
+func (batch_stmt) Close() error  { return nil }
+func (batch_stmt) NumInput() int { return -1 }
+
+func (batch_stmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s batch_stmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.queries++
+	s.d.max_args = max(s.d.max_args, len(args))
+
+	rows := &batch_rows{}
+	for _, arg := range args[2:] {
+		if token := arg.(string); s.d.stored[token] {
+			rows.tokens = append(rows.tokens, token)
+		}
+	}
+
+	return rows, nil
+}
+
+type batch_rows struct{ tokens []string }
+
+func (*batch_rows) Columns() []string { return []string{"token"} }
+func (*batch_rows) Close() error      { return nil }
+
+func (r *batch_rows) Next(dest []driver.Value) error {
+	if len(r.tokens) == 0 {
+		return io.EOF
+	}
+
+	dest[0], r.tokens = r.tokens[0], r.tokens[1:]
+	return nil
+}
@@ -0,0 +1,132 @@
+package csrf_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+)
+
+// issue return `n` tokens issued by `tp`
+func issue(b *testing.B, tp csrf.TokenProvider, ctx context.Context, n int) []string {
+	b.Helper()
+
+	tokens := make([]string, n)
+	for i := range tokens {
+		token, err := tp.Get(ctx)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		tokens[i] = token
+	}
+
+	return tokens
+}
+
+func BenchmarkDefaultTokenProvider(b *testing.B) {
+	ctx := context.Background()
+
+	b.Run("Get", func(b *testing.B) {
+		tp := new_provider(b, csrf.Config{})
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := tp.Get(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+
+	b.Run("Check", func(b *testing.B) {
+		tp := new_provider(b, csrf.Config{})
+		tokens := issue(b, tp, ctx, 1024)
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				if err := tp.Check(ctx, tokens[i%len(tokens)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+
+	b.Run("CheckAndConsume", func(b *testing.B) {
+		tp := new_provider(b, csrf.Config{})
+		tokens := issue(b, tp, ctx, b.N)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for _, token := range tokens {
+			if err := tp.CheckAndConsume(ctx, token); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkHMACTokenProvider(b *testing.B) {
+	ctx := csrf.WithSessionID(context.Background(), "session")
+	tp := new_hmac_provider(b, csrf.HMACConfig{Secret: hmac_secret, TTL: time.Hour})
+
+	b.Run("Get", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := tp.Get(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Check", func(b *testing.B) {
+		tokens := issue(b, tp, ctx, 1024)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := range b.N {
+			if err := tp.Check(ctx, tokens[i%len(tokens)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkEncryptedTokenProvider(b *testing.B) {
+	ctx := csrf.WithSessionID(context.Background(), "session")
+	tp, err := csrf.NewEncryptedTokenProvider(time.Hour, []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("Get", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := tp.Get(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Check", func(b *testing.B) {
+		tokens := issue(b, tp, ctx, 1024)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := range b.N {
+			if err := tp.Check(ctx, tokens[i%len(tokens)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkTokenBucketLimiter issue from new key every time, so the buckets stay above the prune threshold
+func BenchmarkTokenBucketLimiter(b *testing.B) {
+	tbl := csrf.NewTokenBucketLimiter(1, 1)
+	ctx := context.Background()
+
+	for i := range b.N {
+		tbl.Allow(ctx, fmt.Sprint(i))
+	}
+}
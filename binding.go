@@ -0,0 +1,114 @@
+package csrf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// WithClientIPBinding bind tokens to the client ip returned by `ip`, default to the host of [http.Request.RemoteAddr],
+// see [TrustedProxyClientIP] behind reverse proxy. token validated from different ip is rejected with [ErrTokenNotFound].
+// the ip is appended to the subject passed to the [TokenProvider], [CSRF.RevokeAll] of the bare subject still revoke the token
+func WithClientIPBinding(ip func(*http.Request) string) Option {
+	if ip == nil {
+		ip = client_ip
+	}
+
+	return func(c *CSRF) {
+		c.bindings = append(c.bindings, ip)
+	}
+}
+
+// WithFingerprintBinding bind tokens to the hash of the client fingerprint returned by `fp`,
+// e.g. JA3 of the TLS client hello captured in `tls.Config.GetConfigForClient` and stored in the connection context.
+// token validated from client with different fingerprint is rejected with [ErrTokenNotFound], see [WithClientIPBinding]
+func WithFingerprintBinding(fp func(*http.Request) string) Option {
+	return func(c *CSRF) {
+		c.bindings = append(c.bindings, func(r *http.Request) string {
+			sum := sha256.Sum256([]byte(fp(r)))
+			return hex.EncodeToString(sum[:16])
+		})
+	}
+}
+
+// WithUserAgentBinding is [WithFingerprintBinding] of the User-Agent header,
+// it is weak signal since the header is chosen by the client but it is free
+func WithUserAgentBinding() Option {
+	return WithFingerprintBinding((*http.Request).UserAgent)
+}
+
+// WithNamespace bind tokens to `namespace`, so applications sharing the same [TokenProvider] backend
+// can not validate the tokens of each other, see [WithTenant]
+func WithNamespace(namespace string) Option {
+	return WithTenant(func(*http.Request) string { return namespace })
+}
+
+// WithTenant bind tokens to the tenant returned by `tenant`, e.g. from the host or the request context,
+// token issued for one tenant is rejected with [ErrTokenNotFound] for the others. like the other bindings
+// it only apply to tokens issued by the middlewares and [CSRF.TokenHandler], see [WithClientIPBinding]
+func WithTenant(tenant func(*http.Request) string) Option {
+	return func(c *CSRF) {
+		c.bindings = append(c.bindings, tenant)
+	}
+}
+
+// bind append the request bindings to `subject`
+func (c *CSRF) bind(subject string, r *http.Request) string {
+	if len(c.bindings) == 0 {
+		return subject
+	}
+
+	var b strings.Builder
+	b.WriteString(subject)
+	for _, binding := range c.bindings {
+		b.WriteByte(0)
+		b.WriteString(binding(r))
+	}
+
+	return b.String()
+}
+
+// TrustedProxyClientIP return the client ip from `X-Forwarded-For` if the request came through `trusted` proxies,
+// the header is walked from the right and the first address not in `trusted` is returned,
+// so spoofed entries prepended by the client are ignored
+func TrustedProxyClientIP(trusted ...netip.Prefix) func(*http.Request) string {
+	is_trusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return func(r *http.Request) string {
+		remote := client_ip(r)
+		addr, err := netip.ParseAddr(remote)
+		if err != nil || !is_trusted(addr) {
+			return remote
+		}
+
+		hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if host, _, err := net.SplitHostPort(hop); err == nil {
+				hop = host
+			}
+
+			addr, err := netip.ParseAddr(hop)
+			if err != nil {
+				break
+			}
+
+			if !is_trusted(addr) {
+				return addr.Unmap().String()
+			}
+		}
+
+		return remote
+	}
+}
@@ -0,0 +1,103 @@
+package csrf_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestTrustedProxyClientIP(t *testing.T) {
+	client_ip := csrf.TrustedProxyClientIP(netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8"))
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		want   string
+	}{
+		{"untrusted remote", "203.0.113.9:1234", []string{"198.51.100.1"}, "203.0.113.9"},
+		{"no header", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"single hop", "10.0.0.1:1234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed", "10.0.0.1:1234", []string{"192.0.2.66, 198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"multiple headers", "10.0.0.1:1234", []string{"192.0.2.66", "198.51.100.1"}, "198.51.100.1"},
+		{"all trusted", "10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.1"},
+		{"invalid hop", "10.0.0.1:1234", []string{"garbage, 10.0.0.2"}, "10.0.0.1"},
+		{"hop with port", "10.0.0.1:1234", []string{"198.51.100.1:5555"}, "198.51.100.1"},
+		{"mapped hop", "10.0.0.1:1234", []string{"::ffff:198.51.100.1"}, "198.51.100.1"},
+		{"ipv6 proxy", "[fd00::1]:1234", []string{"2001:db8::7"}, "2001:db8::7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, xff := range tt.xff {
+				r.Header.Add("X-Forwarded-For", xff)
+			}
+
+			if got := client_ip(r); got != tt.want {
+				t.Errorf("client ip = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// handler_token return token issued by [csrf.CSRF.TokenHandler] for `r`
+func handler_token(t *testing.T, c *csrf.CSRF, r *http.Request) string {
+	t.Helper()
+
+	var resp struct{ Token string }
+	rec := httptest.NewRecorder()
+	c.TokenHandler(0).ServeHTTP(rec, r)
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Token == "" {
+		t.Fatalf("TokenHandler responded %d %s", rec.Code, rec.Body)
+	}
+
+	return resp.Token
+}
+
+func TestWithNamespace(t *testing.T) {
+	tp := new_provider(t, csrf.Config{})
+	first := csrf.New(tp, csrf.WithTokenMode(csrf.MultiUse), csrf.WithNamespace("first"))
+	second := csrf.New(tp, csrf.WithTokenMode(csrf.MultiUse), csrf.WithNamespace("second"))
+
+	token := handler_token(t, first, httptest.NewRequest(http.MethodGet, "/", nil))
+	if err := second.Validate(post("/", token), csrf.HeaderTokenSource); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("Validate in other namespace = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+
+	if err := first.Validate(post("/", token), csrf.HeaderTokenSource); err != nil {
+		t.Errorf("Validate in the same namespace = %v", err)
+	}
+}
+
+func TestWithTenant(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}),
+		csrf.WithTokenMode(csrf.MultiUse),
+		csrf.WithTenant(func(r *http.Request) string { return r.Host }),
+	)
+
+	token := handler_token(t, c, httptest.NewRequest(http.MethodGet, "http://a.example/", nil))
+
+	tests := []struct {
+		host string
+		want error
+	}{
+		{"a.example", nil},
+		{"b.example", csrf.ErrTokenNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			r := post("http://"+tt.host+"/", token)
+			if err := c.Validate(r, csrf.HeaderTokenSource); !errors.Is(err, tt.want) {
+				t.Errorf("Validate = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
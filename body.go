@@ -0,0 +1,180 @@
+package csrf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// max bytes of request body scanned by body based token sources, same as [http.Request.ParseForm]
+const max_body_scan = 10 << 20
+
+type restored_body struct {
+	io.Reader
+	io.Closer
+}
+
+// restore_body put back the already consumed bytes in front of the unread remainder of the body
+func restore_body(r *http.Request, consumed []byte) {
+	r.Body = restored_body{
+		Reader: io.MultiReader(bytes.NewReader(consumed), r.Body),
+		Closer: r.Body,
+	}
+}
+
+// StreamFormTokenSource return token from `application/x-www-form-urlencoded` body or empty string.
+// unlike [FormTokenSource] it does not call [http.Request.ParseForm], the body is only scanned until
+// the field is found and then restored so the handler can still read the full body
+func StreamFormTokenSource(field string) TokenSourceFunc {
+	return func(r *http.Request) string {
+		if r.Body == nil || r.Body == http.NoBody {
+			return ""
+		}
+
+		media_type, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if media_type != "application/x-www-form-urlencoded" {
+			return ""
+		}
+
+		var consumed bytes.Buffer
+		br := bufio.NewReader(io.LimitReader(io.TeeReader(r.Body, &consumed), max_body_scan))
+		defer func() { restore_body(r, consumed.Bytes()) }()
+
+		for {
+			pair, err := br.ReadString('&')
+			key, value, _ := strings.Cut(strings.TrimSuffix(pair, "&"), "=")
+			if key, kerr := url.QueryUnescape(key); kerr == nil && key == field {
+				value, verr := url.QueryUnescape(value)
+				if verr != nil {
+					return ""
+				}
+
+				return value
+			}
+
+			if err != nil {
+				return ""
+			}
+		}
+	}
+}
+
+// JSONTokenSource return string value of top level `field` from json request body or empty string,
+// the body is buffered and restored so the handler can still decode it
+func JSONTokenSource(field string) TokenSourceFunc {
+	return func(r *http.Request) string {
+		if r.Body == nil || r.Body == http.NoBody {
+			return ""
+		}
+
+		media_type, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if !(media_type == "application/json" || strings.HasSuffix(media_type, "+json")) {
+			return ""
+		}
+
+		consumed, err := io.ReadAll(io.LimitReader(r.Body, max_body_scan))
+		restore_body(r, consumed)
+		if err != nil {
+			return ""
+		}
+
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(consumed, &body); err != nil {
+			return ""
+		}
+
+		var token string
+		if err := json.Unmarshal(body[field], &token); err != nil {
+			return ""
+		}
+
+		return token
+	}
+}
+
+// MultipartTokenSource return token from `multipart/form-data` body or empty string.
+// unlike [FormTokenSource] the form is not parsed into [http.Request.MultipartForm], at most `max_memory` bytes
+// of the body are buffered while looking for the field and then restored so the handler can still read the full body.
+// the field must appear within the first `max_memory` bytes, if the form was already parsed the value is read from it
+func MultipartTokenSource(field string, max_memory int64) TokenSourceFunc {
+	return func(r *http.Request) string {
+		if r.MultipartForm != nil {
+			if values := r.MultipartForm.Value[field]; len(values) > 0 {
+				return values[0]
+			}
+
+			return ""
+		}
+
+		if r.Body == nil || r.Body == http.NoBody {
+			return ""
+		}
+
+		media_type, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if media_type != "multipart/form-data" || params["boundary"] == "" {
+			return ""
+		}
+
+		var consumed bytes.Buffer
+		mr := multipart.NewReader(io.LimitReader(io.TeeReader(r.Body, &consumed), max_memory), params["boundary"])
+		defer func() { restore_body(r, consumed.Bytes()) }()
+
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				return ""
+			}
+
+			if part.FormName() == field && part.FileName() == "" {
+				value, err := io.ReadAll(part)
+				if err != nil {
+					return ""
+				}
+
+				return string(value)
+			}
+		}
+	}
+}
+
+// max memory of multipart form parsed by [AutoTokenSource], same as [http.Request.FormValue]
+const auto_multipart_memory = 32 << 20
+
+// AutoTokenSource pick the source by the request content type: [StreamFormTokenSource] for urlencoded form,
+// [MultipartTokenSource] for multipart form and [JSONTokenSource] for json, reading `field`.
+// the header `header` is used for the other requests and when the body does not carry the token
+func AutoTokenSource(header, field string) TokenSourceFunc {
+	form := StreamFormTokenSource(field)
+	multipart_form := MultipartTokenSource(field, auto_multipart_memory)
+	json_body := JSONTokenSource(field)
+	return func(r *http.Request) string {
+		var token string
+		media_type, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch {
+		case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		case media_type == "application/x-www-form-urlencoded":
+			token = form(r)
+		case media_type == "multipart/form-data":
+			token = multipart_form(r)
+		case media_type == "application/json", strings.HasSuffix(media_type, "+json"):
+			token = json_body(r)
+		}
+
+		if token == "" {
+			token = r.Header.Get(header)
+		}
+
+		return token
+	}
+}
+
+// AutoSource is [AutoTokenSource] of the configured header and form field
+func (c *CSRF) AutoSource() TokenSourceFunc {
+	return AutoTokenSource(c.header_name, c.form_field)
+}
@@ -0,0 +1,106 @@
+package csrf_test
+
+import (
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+// counting_reader count the bytes read from the body
+type counting_reader struct {
+	io.Reader
+	n int
+}
+
+func (cr *counting_reader) Read(p []byte) (int, error) {
+	n, err := cr.Reader.Read(p)
+	cr.n += n
+	return n, err
+}
+
+func TestStreamFormTokenSource(t *testing.T) {
+	large := "data=" + strings.Repeat("x", 1<<20)
+
+	tests := []struct {
+		name         string
+		content_type string
+		body         string
+		want         string
+		// max_read is the most bytes the source may read, zero means unchecked
+		max_read int
+	}{
+		{"early in large body", "application/x-www-form-urlencoded", "csrf_token=abc&" + large, "abc", 64 << 10},
+		{"late", "application/x-www-form-urlencoded", "a=1&b=2&csrf_token=abc", "abc", 0},
+		{"escaped", "application/x-www-form-urlencoded", "csrf%5Ftoken=a%2Bb+c", "a+b c", 0},
+		{"missing", "application/x-www-form-urlencoded", "a=1&" + large, "", 0},
+		{"bad escape", "application/x-www-form-urlencoded", "csrf_token=%zz", "", 0},
+		{"content type params", "application/x-www-form-urlencoded; charset=utf-8", "csrf_token=abc", "abc", 0},
+		{"other content type", "application/json", `{"csrf_token":"abc"}`, "", 0},
+	}
+
+	source := csrf.StreamFormTokenSource("csrf_token")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &counting_reader{Reader: strings.NewReader(tt.body)}
+			r := httptest.NewRequest(http.MethodPost, "/", body)
+			r.Header.Set("Content-Type", tt.content_type)
+
+			if got := source(r); got != tt.want {
+				t.Errorf("source = %q, want %q", got, tt.want)
+			}
+
+			if tt.max_read > 0 && body.n > tt.max_read {
+				t.Errorf("source read %d bytes, want at most %d", body.n, tt.max_read)
+			}
+
+			if r.Form != nil {
+				t.Error("the form was parsed")
+			}
+
+			if rest, err := io.ReadAll(r.Body); err != nil || string(rest) != tt.body {
+				t.Errorf("handler read %d bytes (%v), want the full body of %d bytes", len(rest), err, len(tt.body))
+			}
+		})
+	}
+}
+
+func TestAutoTokenSource(t *testing.T) {
+	var multipart_body strings.Builder
+	mw := multipart.NewWriter(&multipart_body)
+	mw.WriteField("token", "multipart")
+	mw.Close()
+
+	header := func(r *http.Request) *http.Request {
+		r.Header.Set("X-Token", "header")
+		return r
+	}
+
+	tests := []struct {
+		name string
+		r    *http.Request
+		want string
+	}{
+		{"form", body_request("application/x-www-form-urlencoded", []byte("token=form")), "form"},
+		{"multipart", body_request(mw.FormDataContentType(), []byte(multipart_body.String())), "multipart"},
+		{"json", body_request("application/json; charset=utf-8", []byte(`{"token":"json"}`)), "json"},
+		{"json suffix", body_request("application/vnd.api+json", []byte(`{"token":"json"}`)), "json"},
+		{"body without token", header(body_request("application/json", []byte(`{}`))), "header"},
+		{"other content type", header(body_request("text/plain", []byte("token=form"))), "header"},
+		{"body ignored on GET", header(httptest.NewRequest(http.MethodGet, "/?token=query", nil)), "header"},
+		{"none", body_request("application/json", []byte(`{}`)), ""},
+	}
+
+	source := csrf.AutoTokenSource("X-Token", "token")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := source(tt.r); got != tt.want {
+				t.Errorf("AutoTokenSource = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
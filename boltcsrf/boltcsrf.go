@@ -0,0 +1,358 @@
+// Package boltcsrf implements [csrf.TokenProvider] backed by embedded bbolt database
+package boltcsrf
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/binary"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Config for [New]
+type Config struct {
+	// Bucket name, default to `csrf_tokens`
+	Bucket string
+	// TTL of issued token, default to [csrf.DefaultTTL]
+	TTL time.Duration
+	// CleanupInterval is the interval of expired token removal, default to [csrf.DefaultGCInterval].
+	// bbolt reuse the freed pages but never shrink the file, see [TokenProvider.Compact]
+	CleanupInterval time.Duration
+	// GCBatchSize is the number of keys visited by the cleanup and RevokeAll in single write transaction,
+	// so Get and CheckAndConsume are not blocked for long. default to [csrf.DefaultGCBatchSize]
+	GCBatchSize int
+	// Generator of new token, default to [csrf.UUIDTokenGenerator]
+	Generator csrf.GenerateTokenFunc
+	// Clock default to [csrf.SystemClock]
+	Clock csrf.Clock
+}
+
+// TokenProvider store tokens in bbolt bucket, the value is `expire_at || issued_at || subject`
+type TokenProvider struct {
+	db        *bolt.DB
+	bucket    []byte
+	token_ttl time.Duration
+	generate  csrf.GenerateTokenFunc
+	gc_batch  int
+
+	stop_gc context.CancelFunc
+	gc_done chan struct{}
+	clock   csrf.Clock
+}
+
+var (
+	_ csrf.TokenProvider    = (*TokenProvider)(nil)
+	_ csrf.Consumer         = (*TokenProvider)(nil)
+	_ csrf.IssuedAtProvider = (*TokenProvider)(nil)
+	_ csrf.TTLProvider      = (*TokenProvider)(nil)
+	_ csrf.HealthChecker    = (*TokenProvider)(nil)
+	_ csrf.Revoker          = (*TokenProvider)(nil)
+	_ csrf.Lister           = (*TokenProvider)(nil)
+	_ csrf.IDRevoker        = (*TokenProvider)(nil)
+)
+
+// New return [TokenProvider] storing tokens in `db`, the bucket is created if not exists.
+// the cleanup goroutine stops when `ctx` is done or on Close, `db` is owned by the caller
+func New(ctx context.Context, db *bolt.DB, cfg Config) (*TokenProvider, error) {
+	if cfg.Bucket == "" {
+		cfg.Bucket = "csrf_tokens"
+	}
+
+	if cfg.TTL <= 0 {
+		cfg.TTL = csrf.DefaultTTL
+	}
+
+	if cfg.CleanupInterval <= 0 {
+		cfg.CleanupInterval = csrf.DefaultGCInterval
+	}
+
+	if cfg.GCBatchSize <= 0 {
+		cfg.GCBatchSize = csrf.DefaultGCBatchSize
+	}
+
+	if cfg.Generator == nil {
+		cfg.Generator = csrf.UUIDTokenGenerator
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = csrf.SystemClock{}
+	}
+
+	btp := &TokenProvider{
+		db:        db,
+		bucket:    []byte(cfg.Bucket),
+		token_ttl: cfg.TTL,
+		generate:  cfg.Generator,
+		gc_batch:  cfg.GCBatchSize,
+		gc_done:   make(chan struct{}),
+		clock:     cfg.Clock,
+	}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(btp.bucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, btp.stop_gc = context.WithCancel(ctx)
+	go btp.gc(ctx, cfg.CleanupInterval)
+	return btp, nil
+}
+
+type entry struct {
+	expire_at int64
+	issued_at int64
+	subject   []byte
+}
+
+func encode(e entry) []byte {
+	buf := make([]byte, 16, 16+len(e.subject))
+	binary.BigEndian.PutUint64(buf, uint64(e.expire_at))
+	binary.BigEndian.PutUint64(buf[8:], uint64(e.issued_at))
+	return append(buf, e.subject...)
+}
+
+func decode(buf []byte) (entry, bool) {
+	if len(buf) < 16 {
+		return entry{}, false
+	}
+
+	return entry{
+		expire_at: int64(binary.BigEndian.Uint64(buf)),
+		issued_at: int64(binary.BigEndian.Uint64(buf[8:])),
+		subject:   buf[16:],
+	}, true
+}
+
+func (btp *TokenProvider) gc(ctx context.Context, interval time.Duration) {
+	defer close(btp.gc_done)
+
+	timer := btp.clock.NewTimer(interval)
+	defer timer.Stop()
+
+	ctx_done := ctx.Done()
+	for {
+		select {
+		case <-ctx_done:
+			return
+		case <-timer.C():
+		}
+
+		now := btp.clock.Now().Unix()
+		// error is ignored, the tokens will be removed on the next run
+		_ = btp.delete_where(ctx, func(e entry, ok bool) bool { return !ok || e.expire_at <= now })
+		timer.Reset(interval)
+	}
+}
+
+// delete_where delete every token for which `match` return true, `ok` is false for malformed value.
+// the bucket is walked in write transactions of at most [Config.GCBatchSize] keys and it stop between them once `ctx` is done
+func (btp *TokenProvider) delete_where(ctx context.Context, match func(e entry, ok bool) bool) error {
+	var from []byte
+	for more := true; more; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := btp.db.Update(func(tx *bolt.Tx) error {
+			c := tx.Bucket(btp.bucket).Cursor()
+			k, v := c.First()
+			if from != nil {
+				k, v = c.Seek(from)
+			}
+
+			more = false
+			for visited := 0; k != nil; visited++ {
+				if visited == btp.gc_batch {
+					// the key is only valid during the transaction
+					from, more = bytes.Clone(k), true
+					return nil
+				}
+
+				if match(decode(v)) {
+					if err := c.Delete(); err != nil {
+						return err
+					}
+					// Delete move the cursor to the next item
+					k, v = c.Seek(k)
+					continue
+				}
+
+				k, v = c.Next()
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get issue token bound to the session id from context, see [csrf.WithSessionID]
+func (btp *TokenProvider) Get(ctx context.Context) (string, error) {
+	token, err := csrf.GenerateToken(btp.generate)
+	if err != nil {
+		return "", err
+	}
+
+	now := btp.clock.Now()
+	value := encode(entry{
+		expire_at: now.Add(csrf.TTLFromContext(ctx, btp.token_ttl)).Unix(),
+		issued_at: now.UnixNano(),
+		subject:   []byte(csrf.SessionIDFromContext(ctx)),
+	})
+
+	err = btp.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(btp.bucket).Put([]byte(token), value)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (btp *TokenProvider) check(ctx context.Context, b *bolt.Bucket, token string) error {
+	e, ok := decode(b.Get([]byte(token)))
+	if !ok || subtle.ConstantTimeCompare(e.subject, []byte(csrf.SessionIDFromContext(ctx))) != 1 {
+		return csrf.ErrTokenNotFound
+	}
+
+	if !(btp.clock.Now().Unix() < e.expire_at) {
+		return csrf.ErrTokenExpired
+	}
+
+	return nil
+}
+
+func (btp *TokenProvider) Check(ctx context.Context, token string) error {
+	return btp.db.View(func(tx *bolt.Tx) error {
+		return btp.check(ctx, tx.Bucket(btp.bucket), token)
+	})
+}
+
+func (btp *TokenProvider) CheckAndConsume(ctx context.Context, token string) error {
+	return btp.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(btp.bucket)
+		if err := btp.check(ctx, b, token); err != nil {
+			return err
+		}
+
+		return b.Delete([]byte(token))
+	})
+}
+
+func (btp *TokenProvider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	var issued_at time.Time
+	err := btp.db.View(func(tx *bolt.Tx) error {
+		e, ok := decode(tx.Bucket(btp.bucket).Get([]byte(token)))
+		if !ok {
+			return csrf.ErrTokenNotFound
+		}
+
+		issued_at = time.Unix(0, e.issued_at)
+		return nil
+	})
+
+	return issued_at, err
+}
+
+func (btp *TokenProvider) TTL() time.Duration { return btp.token_ttl }
+
+// RevokeAll walk the bucket in batches like the cleanup, the tokens of already committed batches stay revoked
+// if `ctx` is done before the walk finish
+func (btp *TokenProvider) RevokeAll(ctx context.Context, subject string) error {
+	return btp.delete_where(ctx, func(e entry, ok bool) bool { return ok && csrf.MatchSubject(string(e.subject), subject) })
+}
+
+// RevokeID walk the bucket to find the token with [csrf.TokenID] `id`
+func (btp *TokenProvider) RevokeID(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return btp.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(btp.bucket).Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if csrf.TokenID(string(k)) == id {
+				return c.Delete()
+			}
+		}
+
+		return nil
+	})
+}
+
+// Compact write compacted copy of the database to `dst`, e.g. new file which then replace the original one
+// while no provider use it. bbolt never shrink the file, the space freed by the cleanup is only returned this way.
+// every bucket is copied in transactions of at most `tx_max_size` bytes, zero copy in single transaction
+func (btp *TokenProvider) Compact(dst *bolt.DB, tx_max_size int64) error {
+	return bolt.Compact(dst, btp.db, tx_max_size)
+}
+
+// Close stop the cleanup goroutine and wait for it to exit, the database is not closed
+func (btp *TokenProvider) Close() error {
+	btp.stop_gc()
+	<-btp.gc_done
+	return nil
+}
+
+// Ping return error if the database was closed
+func (btp *TokenProvider) Ping(context.Context) error {
+	return btp.db.View(func(*bolt.Tx) error { return nil })
+}
+
+// List walk the whole bucket, the keys are in token order so every page hash every token
+// and sort the ones after the cursor by [csrf.TokenID]
+func (btp *TokenProvider) List(ctx context.Context, cursor string, limit int) ([]csrf.TokenInfo, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if limit <= 0 {
+		return nil, "", nil
+	}
+
+	now := btp.clock.Now().Unix()
+	var tokens []csrf.TokenInfo
+	err := btp.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(btp.bucket).ForEach(func(k, v []byte) error {
+			e, ok := decode(v)
+			if !ok || e.expire_at <= now {
+				return nil
+			}
+
+			if id := csrf.TokenID(string(k)); id > cursor {
+				tokens = append(tokens, csrf.TokenInfo{
+					ID:       id,
+					Subject:  string(e.subject),
+					IssuedAt: time.Unix(0, e.issued_at),
+					ExpireAt: time.Unix(e.expire_at, 0),
+				})
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	slices.SortFunc(tokens, func(a, b csrf.TokenInfo) int { return strings.Compare(a.ID, b.ID) })
+	tokens = tokens[:min(limit, len(tokens))]
+	return tokens, csrf.NextCursor(tokens, limit), nil
+}
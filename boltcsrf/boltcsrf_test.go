@@ -0,0 +1,197 @@
+package boltcsrf_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/boltcsrf"
+	"github.com/bokunodev/csrf/csrftest"
+	bolt "go.etcd.io/bbolt"
+)
+
+func open_db(t *testing.T, name string) *bolt.DB {
+	t.Helper()
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), name), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func new_provider(t *testing.T, db *bolt.DB, cfg boltcsrf.Config) *boltcsrf.TokenProvider {
+	t.Helper()
+
+	btp, err := boltcsrf.New(context.Background(), db, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { btp.Close() })
+	return btp
+}
+
+// count return the number of keys in the token bucket, expired or not
+func count(t *testing.T, db *bolt.DB) int {
+	t.Helper()
+
+	var n int
+	err := db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket([]byte("csrf_tokens")).Stats().KeyN
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+func issue(t *testing.T, tp csrf.TokenProvider, ctx context.Context, n int) []string {
+	t.Helper()
+
+	tokens := make([]string, n)
+	for i := range tokens {
+		token, err := tp.Get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokens[i] = token
+	}
+
+	return tokens
+}
+
+func TestRevokeAll(t *testing.T) {
+	db := open_db(t, "tokens.db")
+	btp := new_provider(t, db, boltcsrf.Config{GCBatchSize: 2})
+	alice := csrf.WithSessionID(context.Background(), "alice")
+	bob := csrf.WithSessionID(context.Background(), "bob")
+
+	revoked := issue(t, btp, alice, 5)
+	revoked = append(revoked, issue(t, btp, csrf.WithSessionID(alice, "alice\x00192.0.2.1"), 2)...)
+	kept := issue(t, btp, bob, 3)
+
+	if err := btp.RevokeAll(alice, "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := count(t, db); n != len(kept) {
+		t.Errorf("tokens after RevokeAll = %d, want %d", n, len(kept))
+	}
+
+	for _, token := range kept {
+		if err := btp.Check(bob, token); err != nil {
+			t.Errorf("Check of other subject = %v", err)
+		}
+	}
+
+	canceled, cancel := context.WithCancel(bob)
+	cancel()
+	if err := btp.RevokeAll(canceled, "bob"); !errors.Is(err, context.Canceled) {
+		t.Errorf("RevokeAll = %v, want %v", err, context.Canceled)
+	}
+
+	if _, err := btp.IssuedAt(canceled, kept[0]); !errors.Is(err, context.Canceled) {
+		t.Errorf("IssuedAt = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestList(t *testing.T) {
+	btp := new_provider(t, open_db(t, "tokens.db"), boltcsrf.Config{})
+	ctx := csrf.WithSessionID(context.Background(), "alice")
+
+	tokens := issue(t, btp, ctx, 5)
+	want := make([]string, len(tokens))
+	for i, token := range tokens {
+		want[i] = csrf.TokenID(token)
+	}
+	slices.Sort(want)
+
+	var got []string
+	for cursor := ""; ; {
+		page, next, err := btp.List(ctx, cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, info := range page {
+			got = append(got, info.ID)
+		}
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("listed %v, want %v", got, want)
+	}
+
+	if err := btp.RevokeID(ctx, csrf.TokenID(tokens[0])); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := btp.Check(ctx, tokens[0]); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("Check of revoked token = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+
+	if err := btp.Check(ctx, tokens[1]); err != nil {
+		t.Errorf("Check of other token = %v", err)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	db := open_db(t, "tokens.db")
+	clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+	btp := new_provider(t, db, boltcsrf.Config{TTL: time.Minute, CleanupInterval: time.Second, GCBatchSize: 3, Clock: clock})
+	ctx := context.Background()
+
+	issue(t, btp, ctx, 10)
+	kept := issue(t, btp, csrf.WithTokenTTL(ctx, time.Hour), 2)
+	clock.Advance(time.Minute)
+
+	// the gc goroutine may start its timer after any given Advance, keep ticking until it ran
+	for deadline := time.Now().Add(5 * time.Second); count(t, db) != len(kept); {
+		if time.Now().After(deadline) {
+			t.Fatalf("tokens after cleanup = %d, want %d", count(t, db), len(kept))
+		}
+
+		clock.Advance(time.Second)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, token := range kept {
+		if err := btp.Check(ctx, token); err != nil {
+			t.Errorf("Check of unexpired = %v", err)
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	db := open_db(t, "tokens.db")
+	btp := new_provider(t, db, boltcsrf.Config{})
+	ctx := context.Background()
+	tokens := issue(t, btp, ctx, 100)
+
+	dst := open_db(t, "compacted.db")
+	if err := btp.Compact(dst, 1<<16); err != nil {
+		t.Fatal(err)
+	}
+
+	compacted := new_provider(t, dst, boltcsrf.Config{})
+	for _, token := range tokens {
+		if err := compacted.Check(ctx, token); err != nil {
+			t.Fatalf("Check in compacted database = %v", err)
+		}
+	}
+}
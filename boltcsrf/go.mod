@@ -0,0 +1,17 @@
+module github.com/bokunodev/csrf/boltcsrf
+
+go 1.22rc2
+
+require (
+	github.com/bokunodev/csrf v0.0.0
+	go.etcd.io/bbolt v1.3.11
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/otel v1.31.0 // indirect
+	go.opentelemetry.io/otel/trace v1.31.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+)
+
+replace github.com/bokunodev/csrf => ../
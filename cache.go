@@ -0,0 +1,130 @@
+package csrf
+
+import (
+	"context"
+	"crypto/subtle"
+	"sync"
+	"time"
+)
+
+type cache_entry struct {
+	subject   string
+	expire_at time.Time
+}
+
+// CachingProvider front remote [TokenProvider] with short lived local cache of successful Check,
+// intended for [MultiUse] tokens which are checked on every request. CheckAndConsume and RevokeAll
+// invalidate the local cache only, tokens consumed through other instance or expired stay valid here for up to the cache ttl
+type CachingProvider struct {
+	TokenProvider
+	cache_ttl time.Duration
+	size      int
+	clock     Clock
+
+	mu      sync.Mutex
+	entries map[string]cache_entry
+}
+
+var (
+	_ TokenProvider    = (*CachingProvider)(nil)
+	_ Consumer         = (*CachingProvider)(nil)
+	_ IssuedAtProvider = (*CachingProvider)(nil)
+	_ TTLProvider      = (*CachingProvider)(nil)
+	_ Revoker          = (*CachingProvider)(nil)
+)
+
+// NewCachingProvider cache successful Check of `tp` for `cache_ttl`, holding at most `size` tokens, default to 1024
+func NewCachingProvider(tp TokenProvider, cache_ttl time.Duration, size int) *CachingProvider {
+	if size <= 0 {
+		size = 1024
+	}
+
+	return &CachingProvider{
+		TokenProvider: tp,
+		cache_ttl:     cache_ttl,
+		size:          size,
+		clock:         SystemClock{},
+		entries:       make(map[string]cache_entry),
+	}
+}
+
+func (cp *CachingProvider) Check(ctx context.Context, token string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	subject := SessionIDFromContext(ctx)
+	now := cp.clock.Now()
+
+	cp.mu.Lock()
+	entry, ok := cp.entries[token]
+	cp.mu.Unlock()
+	if ok && subtle.ConstantTimeCompare([]byte(entry.subject), []byte(subject)) == 1 && now.Before(entry.expire_at) {
+		return nil
+	}
+
+	if err := cp.TokenProvider.Check(ctx, token); err != nil {
+		return err
+	}
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	if len(cp.entries) >= cp.size {
+		for k, v := range cp.entries {
+			if !now.Before(v.expire_at) {
+				delete(cp.entries, k)
+			}
+		}
+
+		// still full, drop everything rather than tracking recency
+		if len(cp.entries) >= cp.size {
+			clear(cp.entries)
+		}
+	}
+
+	cp.entries[token] = cache_entry{subject: subject, expire_at: now.Add(cp.cache_ttl)}
+	return nil
+}
+
+func (cp *CachingProvider) CheckAndConsume(ctx context.Context, token string) error {
+	cp.mu.Lock()
+	delete(cp.entries, token)
+	cp.mu.Unlock()
+
+	return consume(ctx, cp.TokenProvider, token)
+}
+
+func (cp *CachingProvider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	iap, ok := cp.TokenProvider.(IssuedAtProvider)
+	if !ok {
+		return time.Time{}, errIssuedAtNotTracked
+	}
+
+	return iap.IssuedAt(ctx, token)
+}
+
+func (cp *CachingProvider) TTL() time.Duration {
+	if tp, ok := cp.TokenProvider.(TTLProvider); ok {
+		return tp.TTL()
+	}
+
+	return 0
+}
+
+func (cp *CachingProvider) RevokeAll(ctx context.Context, subject string) error {
+	revoker, ok := cp.TokenProvider.(Revoker)
+	if !ok {
+		return ErrRevokeNotSupported
+	}
+
+	cp.mu.Lock()
+	for k, v := range cp.entries {
+		if MatchSubject(v.subject, subject) {
+			delete(cp.entries, k)
+		}
+	}
+	cp.mu.Unlock()
+
+	return revoker.RevokeAll(ctx, subject)
+}
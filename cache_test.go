@@ -0,0 +1,67 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestCachingProviderRevokeAll(t *testing.T) {
+	cp := csrf.NewCachingProvider(new_provider(t, csrf.Config{}), time.Hour, 0)
+	c := csrf.New(cp,
+		csrf.WithTokenMode(csrf.MultiUse),
+		csrf.WithSubject(func(r *http.Request) string { return r.Header.Get("X-User") }),
+		csrf.WithClientIPBinding(nil),
+	)
+
+	request := func(user, token string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("X-User", user)
+		r.Header.Set(csrf.DefaultHeaderName, token)
+		return r
+	}
+
+	alice, err := c.RequestToken(request("alice", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bob, err := c.RequestToken(request("bob", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fill the cache
+	for _, r := range []*http.Request{request("alice", alice), request("bob", bob)} {
+		if err := c.Validate(r, csrf.HeaderTokenSource); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := c.RevokeAll(context.Background(), "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		user  string
+		token string
+		err   error
+	}{
+		{"revoked", "alice", alice, csrf.ErrTokenNotFound},
+		{"other subject", "bob", bob, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := c.Validate(request(tt.user, tt.token), csrf.HeaderTokenSource); !errors.Is(err, tt.err) {
+				t.Fatalf("Validate = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
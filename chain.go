@@ -0,0 +1,98 @@
+package csrf
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// FallbackPolicy decide when [ChainProvider] consult the secondary provider on check
+type FallbackPolicy int
+
+const (
+	// FallbackOnError consult the secondary only if the primary failed with error other than [ErrInvalidToken],
+	// e.g. the backend is unreachable
+	FallbackOnError FallbackPolicy = iota
+	// FallbackAlways also consult the secondary if the primary rejected the token,
+	// e.g. token issued by the secondary during the primary outage
+	FallbackAlways
+)
+
+// ChainProvider issue tokens from the primary provider and fall back to the secondary when the primary fails,
+// e.g. redis primary with in memory fallback to keep working through brief outages
+type ChainProvider struct {
+	primary   TokenProvider
+	secondary TokenProvider
+	policy    FallbackPolicy
+}
+
+var (
+	_ TokenProvider    = (*ChainProvider)(nil)
+	_ Consumer         = (*ChainProvider)(nil)
+	_ IssuedAtProvider = (*ChainProvider)(nil)
+)
+
+func NewChainProvider(primary, secondary TokenProvider, policy FallbackPolicy) *ChainProvider {
+	return &ChainProvider{primary: primary, secondary: secondary, policy: policy}
+}
+
+func (cp *ChainProvider) fallback(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	return cp.policy == FallbackAlways || !errors.Is(err, ErrInvalidToken)
+}
+
+func (cp *ChainProvider) Get(ctx context.Context) (string, error) {
+	token, err := cp.primary.Get(ctx)
+	if err != nil && ctx.Err() == nil {
+		return cp.secondary.Get(ctx)
+	}
+
+	return token, err
+}
+
+func (cp *ChainProvider) Check(ctx context.Context, token string) error {
+	err := cp.primary.Check(ctx, token)
+	if cp.fallback(ctx, err) {
+		return cp.secondary.Check(ctx, token)
+	}
+
+	return err
+}
+
+func consume(ctx context.Context, tp TokenProvider, token string) error {
+	if c, ok := tp.(Consumer); ok {
+		return c.CheckAndConsume(ctx, token)
+	}
+
+	return tp.Check(ctx, token)
+}
+
+func (cp *ChainProvider) CheckAndConsume(ctx context.Context, token string) error {
+	err := consume(ctx, cp.primary, token)
+	if cp.fallback(ctx, err) {
+		return consume(ctx, cp.secondary, token)
+	}
+
+	return err
+}
+
+func (cp *ChainProvider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	err := error(errIssuedAtNotTracked)
+	for _, tp := range [...]TokenProvider{cp.primary, cp.secondary} {
+		iap, ok := tp.(IssuedAtProvider)
+		if !ok {
+			err = errIssuedAtNotTracked
+			continue
+		}
+
+		var issued_at time.Time
+		if issued_at, err = iap.IssuedAt(ctx, token); err == nil {
+			return issued_at, nil
+		}
+	}
+
+	return time.Time{}, err
+}
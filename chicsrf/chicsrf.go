@@ -0,0 +1,65 @@
+// Package chicsrf adapt [csrf.CSRF] to chi router.
+//
+//	r := chi.NewRouter()
+//	r.Use(c.TokenMiddleware(csrf.ExposeHeader))
+//	r.Group(func(r chi.Router) {
+//		r.Use(chicsrf.Except(chicsrf.Middleware(c), "/webhooks/*"))
+//		r.Post("/profile", update_profile)
+//		r.Post("/webhooks/*", handle_webhook)
+//	})
+package chicsrf
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/bokunodev/csrf"
+	"github.com/go-chi/chi/v5"
+)
+
+// Middleware is [csrf.CSRF.Middleware], usable with chi `Use`, `With` and `Group`
+func Middleware(c *csrf.CSRF, sources ...csrf.TokenSourceFunc) func(next http.Handler) http.Handler {
+	return c.Middleware(sources...)
+}
+
+// Except skip `mw` for request whose chi route pattern match any of `patterns` (see [path.Match]),
+// e.g. `/webhooks/*`. the route pattern is only known after chi matched the route,
+// so Except must be registered on the route or on the sub router, not on the root router
+func Except(mw func(next http.Handler) http.Handler, patterns ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		protected := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip(r, patterns) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			protected.ServeHTTP(w, r)
+		})
+	}
+}
+
+func skip(r *http.Request, patterns []string) bool {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return false
+	}
+
+	route := rctx.RoutePattern()
+	for _, pattern := range patterns {
+		if pattern == route {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern, route); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Token return the token stored in the request context by [csrf.CSRF.TokenMiddleware]
+func Token(r *http.Request) string {
+	return csrf.TokenFromContext(r.Context())
+}
@@ -0,0 +1,105 @@
+package chicsrf_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/chicsrf"
+	"github.com/go-chi/chi/v5"
+)
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, chicsrf.Token(r))
+}
+
+func new_router(t *testing.T) (*chi.Mux, *csrf.CSRF) {
+	t.Helper()
+
+	tp := csrf.NewDefaultTokenProviderConfig(context.Background(), csrf.Config{})
+	t.Cleanup(func() { tp.Close() })
+	c := csrf.New(tp)
+
+	r := chi.NewRouter()
+	r.Use(c.TokenMiddleware(csrf.ExposeHeader))
+	r.Get("/form", ok)
+	r.With(chicsrf.Middleware(c)).Post("/with", ok)
+	r.Group(func(r chi.Router) {
+		r.Use(chicsrf.Except(chicsrf.Middleware(c), "/webhooks/*"))
+		r.Post("/profile", ok)
+		r.Post("/webhooks/*", ok)
+	})
+	r.Route("/api", func(r chi.Router) {
+		r.Use(chicsrf.Middleware(c))
+		r.Post("/items", ok)
+	})
+
+	return r, c
+}
+
+func TestMiddleware(t *testing.T) {
+	router, c := new_router(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		token  string
+		status int
+	}{
+		{"get", http.MethodGet, "/form", "", http.StatusOK},
+		{"with without token", http.MethodPost, "/with", "", http.StatusForbidden},
+		{"with", http.MethodPost, "/with", "issued", http.StatusOK},
+		{"group without token", http.MethodPost, "/profile", "", http.StatusForbidden},
+		{"group invalid token", http.MethodPost, "/profile", "invalid", http.StatusForbidden},
+		{"group", http.MethodPost, "/profile", "issued", http.StatusOK},
+		{"group except", http.MethodPost, "/webhooks/github", "", http.StatusOK},
+		{"sub router without token", http.MethodPost, "/api/items", "", http.StatusForbidden},
+		{"sub router", http.MethodPost, "/api/items", "issued", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, nil)
+			switch tt.token {
+			case "":
+			case "issued":
+				// single use token, every case need its own
+				token, err := c.GetToken(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				r.Header.Set(csrf.DefaultHeaderName, token)
+			default:
+				r.Header.Set(csrf.DefaultHeaderName, tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, r)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if tt.status == http.StatusOK && rec.Body.String() != rec.Header().Get(csrf.DefaultHeaderName) {
+				t.Errorf("Token = %q, want the issued token %q", rec.Body.String(), rec.Header().Get(csrf.DefaultHeaderName))
+			}
+		})
+	}
+}
+
+func TestExceptOutsideRoute(t *testing.T) {
+	tp := csrf.NewDefaultTokenProviderConfig(context.Background(), csrf.Config{})
+	t.Cleanup(func() { tp.Close() })
+
+	// without chi route context nothing is skipped
+	h := chicsrf.Except(chicsrf.Middleware(csrf.New(tp)), "/*")(http.HandlerFunc(ok))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhooks/github", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,14 @@
+module github.com/bokunodev/csrf/chicsrf
+
+go 1.22rc2
+
+require github.com/bokunodev/csrf v0.0.0
+
+require (
+	github.com/go-chi/chi/v5 v5.1.0
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/otel v1.31.0 // indirect
+	go.opentelemetry.io/otel/trace v1.31.0 // indirect
+)
+
+replace github.com/bokunodev/csrf => ../
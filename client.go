@@ -0,0 +1,202 @@
+package csrf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Transport is [http.RoundTripper] attaching token to unsafe request, for tests and service to service callers.
+// the token is learned from the response header and cookie, or fetched from [Transport.TokenURL]
+// which respond like [CSRF.TokenHandler]. unsafe request rejected with 403 Forbidden is retried once with fresh token
+// if its body can be rewound. the token is only attached to request of the origin it was learned from,
+// and fetched only for request of the origin of TokenURL. use it with [http.Client.Jar] so the session cookie
+// reach the token endpoint
+type Transport struct {
+	// Base default to [http.DefaultTransport]
+	Base http.RoundTripper
+	// TokenURL is fetched with the cookies of the request when there is no token
+	TokenURL string
+	// HeaderName default to [DefaultHeaderName]
+	HeaderName string
+	// CookieName is the cookie the token is learned from, empty disable it
+	CookieName string
+	// SingleUse forget the token after each unsafe request, for [SingleUse] token mode
+	SingleUse bool
+
+	mu    sync.Mutex
+	token string
+	// origin the token was learned from
+	origin string
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+
+	return http.DefaultTransport
+}
+
+func (t *Transport) header_name() string {
+	if t.HeaderName != "" {
+		return t.HeaderName
+	}
+
+	return DefaultHeaderName
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if slices.Contains(DefaultSafeMethods, req.Method) {
+		resp, err := t.base().RoundTrip(req)
+		if err == nil {
+			t.learn(req.URL, resp)
+		}
+
+		return resp, err
+	}
+
+	resp, sent, err := t.round_trip(req, false)
+	if err != nil || resp.StatusCode != http.StatusForbidden || (req.Body != nil && req.GetBody == nil) {
+		return resp, err
+	}
+
+	// retry with the token learned from the 403 response, or fetch fresh one if there is none
+	refresh := t.token_for(req.URL) == sent
+	if refresh && !t.can_fetch(req.URL) {
+		return resp, nil
+	}
+
+	body := req.Body
+	if req.GetBody != nil {
+		if body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+
+	resp.Body.Close()
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	resp, _, err = t.round_trip(retry, refresh)
+	return resp, err
+}
+
+// round_trip send `req` with the token of its origin, fetched from [Transport.TokenURL] if there is none or `refresh`.
+// it return the token which was sent
+func (t *Transport) round_trip(req *http.Request, refresh bool) (*http.Response, string, error) {
+	token := t.token_for(req.URL)
+	if (token == "" || refresh) && t.can_fetch(req.URL) {
+		var err error
+		if token, err = t.fetch(req); err != nil {
+			return nil, "", err
+		}
+	}
+
+	req = req.Clone(req.Context())
+	if token != "" {
+		req.Header.Set(t.header_name(), token)
+	}
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !t.learn(req.URL, resp) && t.SingleUse {
+		t.set(token, "")
+	}
+
+	return resp, token, nil
+}
+
+// origin return the scheme and host of `u`, the token is only sent to the origin it was learned from
+func origin(u *url.URL) string {
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// token_for return the token if it was learned from the origin of `u`
+func (t *Transport) token_for(u *url.URL) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.origin != origin(u) {
+		return ""
+	}
+
+	return t.token
+}
+
+// can_fetch report whether token for `u` can be fetched from [Transport.TokenURL], which must be of the same origin
+func (t *Transport) can_fetch(u *url.URL) bool {
+	if t.TokenURL == "" {
+		return false
+	}
+
+	token_url, err := url.Parse(t.TokenURL)
+	return err == nil && origin(token_url) == origin(u)
+}
+
+// set replace the token if it is still `old`
+func (t *Transport) set(old, token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token == old {
+		t.token = token
+	}
+}
+
+// learn store the token of `resp` to request of `u`, it return false if there is none
+func (t *Transport) learn(u *url.URL, resp *http.Response) bool {
+	token := resp.Header.Get(t.header_name())
+	if token == "" && t.CookieName != "" {
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == t.CookieName {
+				token = cookie.Value
+			}
+		}
+	}
+
+	if token == "" {
+		return false
+	}
+
+	t.mu.Lock()
+	t.token, t.origin = token, origin(u)
+	t.mu.Unlock()
+	return true
+}
+
+func (t *Transport) fetch(orig *http.Request) (string, error) {
+	req, err := http.NewRequestWithContext(orig.Context(), http.MethodGet, t.TokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header["Cookie"] = orig.Header["Cookie"]
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("csrf: token endpoint responded with %s", resp.Status)
+	}
+
+	var body token_response
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	t.token, t.origin = body.Token, origin(req.URL)
+	t.mu.Unlock()
+	return body.Token, nil
+}
@@ -0,0 +1,142 @@
+package csrf_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+// token_server accept unsafe request carrying the current token, otherwise respond 403 Forbidden.
+// GET / expose the current token in the header, GET /token respond like [csrf.CSRF.TokenHandler]
+type token_server struct {
+	mu     sync.Mutex
+	token  string
+	rotate bool
+	bodies []string
+	sent   []string
+}
+
+func (ts *token_server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/token":
+		json.NewEncoder(w).Encode(map[string]string{"token": ts.token, "header": csrf.DefaultHeaderName})
+	case r.Method == http.MethodGet:
+		w.Header().Set(csrf.DefaultHeaderName, ts.token)
+	default:
+		body, _ := io.ReadAll(r.Body)
+		ts.bodies = append(ts.bodies, string(body))
+		ts.sent = append(ts.sent, r.Header.Get(csrf.DefaultHeaderName))
+		if r.Header.Get(csrf.DefaultHeaderName) != ts.token {
+			if ts.rotate {
+				w.Header().Set(csrf.DefaultHeaderName, ts.token)
+			}
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}
+}
+
+func (ts *token_server) set(token string) {
+	ts.mu.Lock()
+	ts.token = token
+	ts.mu.Unlock()
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		token_url bool
+		rotate    bool
+		// the server token change after the client learned it
+		stale  bool
+		status int
+		sent   []string
+	}{
+		{"learned", false, false, false, http.StatusOK, []string{"one"}},
+		{"retry with token of 403", false, true, true, http.StatusOK, []string{"one", "two"}},
+		{"retry with fetched token", true, false, true, http.StatusOK, []string{"one", "two"}},
+		{"no retry without new token", false, false, true, http.StatusForbidden, []string{"one"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := &token_server{token: "one", rotate: tt.rotate}
+			srv := httptest.NewServer(ts)
+			defer srv.Close()
+
+			transport := &csrf.Transport{}
+			if tt.token_url {
+				transport.TokenURL = srv.URL + "/token"
+			}
+			client := &http.Client{Transport: transport}
+
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if tt.stale {
+				ts.set("two")
+			}
+
+			// strings.Reader body can be rewound through GetBody
+			resp, err = client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+
+			if strings.Join(ts.sent, ",") != strings.Join(tt.sent, ",") {
+				t.Errorf("sent tokens = %v, want %v", ts.sent, tt.sent)
+			}
+
+			for _, body := range ts.bodies {
+				if body != "payload" {
+					t.Errorf("body = %q, want %q", body, "payload")
+				}
+			}
+		})
+	}
+}
+
+func TestTransportOtherOrigin(t *testing.T) {
+	ts := &token_server{token: "one"}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+
+	other := &token_server{token: "other"}
+	other_srv := httptest.NewServer(other)
+	defer other_srv.Close()
+
+	client := &http.Client{Transport: &csrf.Transport{TokenURL: srv.URL + "/token"}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp, err = client.Post(other_srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	for _, sent := range other.sent {
+		if sent != "" {
+			t.Errorf("token %q sent to other origin", sent)
+		}
+	}
+}
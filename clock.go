@@ -0,0 +1,39 @@
+package csrf
+
+import "time"
+
+// Clock is the source of time of the providers, so tests can advance time deterministically
+// instead of sleeping through real ttl and gc interval
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// WithClock set the [Clock] used by [CSRF] for the issue time and expiry of [Token] and the window of
+// [WithFailureAlert], default to [SystemClock].
+// it should be the clock of the [TokenProvider] so the reported expiry match the provider
+func WithClock(clock Clock) Option {
+	return func(c *CSRF) {
+		c.clock = clock
+	}
+}
+
+// Timer is the subset of [time.Timer] used by the providers
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// SystemClock is [Clock] backed by package time
+type SystemClock struct{}
+
+var _ Clock = SystemClock{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+func (SystemClock) NewTimer(d time.Duration) Timer { return system_timer{time.NewTimer(d)} }
+
+type system_timer struct{ *time.Timer }
+
+func (t system_timer) C() <-chan time.Time { return t.Timer.C }
@@ -0,0 +1,47 @@
+package csrf
+
+import "io"
+
+var (
+	_ io.Closer = (*DefaultTokenProvider)(nil)
+	_ io.Closer = (*HashedTokenProvider)(nil)
+	_ io.Closer = (*ShardedTokenProvider)(nil)
+	_ io.Closer = (*SQLTokenProvider)(nil)
+	_ io.Closer = (*PooledProvider)(nil)
+)
+
+// Close stop the gc goroutine and wait for it to exit, the tokens are deleted if [Config.FlushOnClose] is set.
+// the provider is still usable after Close but expired tokens are no longer removed
+func (dtp *DefaultTokenProvider) Close() error {
+	dtp.stop_gc()
+	<-dtp.gc_done
+
+	if dtp.flush {
+		dtp.mu.Lock()
+		clear(dtp.tokens)
+		clear(dtp.by_subject)
+		dtp.expiry = nil
+		dtp.mu.Unlock()
+	}
+
+	return nil
+}
+
+func (htp *HashedTokenProvider) Close() error {
+	return htp.dtp.Close()
+}
+
+func (stp *ShardedTokenProvider) Close() error {
+	for _, shard := range stp.shards {
+		shard.Close()
+	}
+
+	return nil
+}
+
+// Close stop the cleanup goroutine and wait for it to exit, the database is not closed
+func (stp *SQLTokenProvider) Close() error {
+	stp.stop_gc()
+	<-stp.gc_done
+	return nil
+}
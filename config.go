@@ -0,0 +1,118 @@
+package csrf
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+)
+
+const (
+	DefaultTTL         = 15 * time.Minute
+	DefaultGCInterval  = time.Minute
+	DefaultGCBatchSize = 1024
+)
+
+// Config for [NewDefaultTokenProviderConfig], zero value of each field fallback to sensible default
+type Config struct {
+	// TTL of issued token, default to [DefaultTTL]
+	TTL time.Duration
+	// GCInterval is the interval of expired token removal, default to [DefaultGCInterval]
+	GCInterval time.Duration
+	// Generator of new token, default to [UUIDTokenGenerator]
+	Generator GenerateTokenFunc
+	// MaxTokens limit the number of stored token, Get return [ErrTooManyTokens] once reached.
+	// zero means unlimited
+	MaxTokens int
+	// MaxTokensPerSubject limit the number of valid tokens bound to the same subject (see [WithSessionID]), the tokens
+	// of the subject with different bindings or scope count together (see [MatchSubject]).
+	// the oldest token of the subject is evicted once exceeded. it is enforced per shard by [NewShardedTokenProvider].
+	// zero means unlimited
+	MaxTokensPerSubject int
+	// EvictOldest evict the token closest to expiry instead of returning [ErrTooManyTokens] once MaxTokens is reached
+	EvictOldest bool
+	// Logger default to discard all logs
+	Logger *slog.Logger
+	// SlidingExpiration refresh the token ttl on every successful Check instead of keeping the original expiry
+	SlidingExpiration bool
+	// GracePeriod keep accepting token for this long after it expired, for form submitted right at the ttl boundary.
+	// such validation is counted in [Stats.Grace]
+	GracePeriod time.Duration
+	// Hooks are called on token issuance, consumption and expiry
+	Hooks Hooks
+	// FlushOnClose delete every token on Close
+	FlushOnClose bool
+	// Clock default to [SystemClock]
+	Clock Clock
+	// Shards is the number of shards of [NewShardedTokenProvider], ignored by the other constructors
+	Shards int
+	// GCJitter add random delay of up to GCJitter to every gc interval, so replicas started together do not sweep in lockstep
+	GCJitter time.Duration
+	// GCBatchSize is the number of expired entries processed while holding the lock, the lock is released
+	// between batches so Get and Check are not blocked for long. default to [DefaultGCBatchSize]
+	GCBatchSize int
+	// GCMaxPerRun cap the number of expired entries processed by single gc run, the rest are left to the next run.
+	// zero means unlimited
+	GCMaxPerRun int
+	// IdleBackoff is the max gc interval when there is no token to collect, the interval is doubled
+	// on every idle run up to IdleBackoff and reset to GCInterval once a token is issued.
+	// zero or value less than GCInterval disable the backoff
+	IdleBackoff time.Duration
+}
+
+func (cfg Config) with_defaults() Config {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+
+	if cfg.GCInterval <= 0 {
+		cfg.GCInterval = DefaultGCInterval
+	}
+
+	if cfg.GCBatchSize <= 0 {
+		cfg.GCBatchSize = DefaultGCBatchSize
+	}
+
+	if cfg.Generator == nil {
+		cfg.Generator = UUIDTokenGenerator
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock{}
+	}
+
+	if cfg.Logger == nil {
+		cfg.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	return cfg
+}
+
+// NewDefaultTokenProviderConfig return [DefaultTokenProvider] configured by `cfg`,
+// the gc goroutine stops when `ctx` is done
+func NewDefaultTokenProviderConfig(ctx context.Context, cfg Config) *DefaultTokenProvider {
+	cfg = cfg.with_defaults()
+	dtp := &DefaultTokenProvider{
+		tokens:          make(map[string]token_entry),
+		token_ttl:       cfg.TTL,
+		generate:        cfg.Generator,
+		max_tokens:      cfg.MaxTokens,
+		max_per_subject: cfg.MaxTokensPerSubject,
+		evict:           cfg.EvictOldest,
+		sliding:         cfg.SlidingExpiration,
+		grace:           cfg.GracePeriod,
+		logger:          cfg.Logger,
+		hooks:           cfg.Hooks,
+		wake:            make(chan struct{}, 1),
+		flush:           cfg.FlushOnClose,
+		gc_done:         make(chan struct{}),
+		clock:           cfg.Clock,
+		gc_jitter:       cfg.GCJitter,
+		gc_batch:        cfg.GCBatchSize,
+		gc_max_per_run:  cfg.GCMaxPerRun,
+	}
+
+	ctx, dtp.stop_gc = context.WithCancel(ctx)
+	go dtp.gc(ctx, cfg.GCInterval, cfg.IdleBackoff)
+	return dtp
+}
@@ -0,0 +1,167 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func must_get(t *testing.T, tp csrf.TokenProvider, ctx context.Context) string {
+	t.Helper()
+
+	token, err := tp.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func TestConfigDefaults(t *testing.T) {
+	tp := new_provider(t, csrf.Config{})
+	if tp.TTL() != csrf.DefaultTTL {
+		t.Errorf("TTL = %v, want %v", tp.TTL(), csrf.DefaultTTL)
+	}
+
+	token := must_get(t, tp, context.Background())
+	if !csrf.UUIDFormat(token) {
+		t.Errorf("token %q is not uuid", token)
+	}
+
+	if err := tp.Check(context.Background(), token); err != nil {
+		t.Errorf("Check = %v", err)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	ctx := context.Background()
+	alice := csrf.WithSessionID(ctx, "alice")
+
+	tests := []struct {
+		name string
+		cfg  csrf.Config
+		run  func(t *testing.T, tp *csrf.DefaultTokenProvider, clock *csrftest.Clock)
+	}{
+		{"TTL", csrf.Config{TTL: time.Minute}, func(t *testing.T, tp *csrf.DefaultTokenProvider, clock *csrftest.Clock) {
+			token := must_get(t, tp, ctx)
+			clock.Advance(59 * time.Second)
+			if err := tp.Check(ctx, token); err != nil {
+				t.Errorf("Check before ttl = %v", err)
+			}
+
+			clock.Advance(time.Second)
+			if err := tp.Check(ctx, token); !errors.Is(err, csrf.ErrTokenExpired) {
+				t.Errorf("Check after ttl = %v, want %v", err, csrf.ErrTokenExpired)
+			}
+		}},
+		{"Generator", csrf.Config{Generator: sequence()}, func(t *testing.T, tp *csrf.DefaultTokenProvider, _ *csrftest.Clock) {
+			for i := range 3 {
+				if token, want := must_get(t, tp, ctx), fmt.Sprint("seq-", i); token != want {
+					t.Errorf("token = %q, want %q", token, want)
+				}
+			}
+		}},
+		{"MaxTokens", csrf.Config{MaxTokens: 1}, func(t *testing.T, tp *csrf.DefaultTokenProvider, _ *csrftest.Clock) {
+			must_get(t, tp, ctx)
+			if _, err := tp.Get(ctx); !errors.Is(err, csrf.ErrTooManyTokens) {
+				t.Errorf("Get = %v, want %v", err, csrf.ErrTooManyTokens)
+			}
+		}},
+		{"EvictOldest", csrf.Config{MaxTokens: 1, EvictOldest: true}, func(t *testing.T, tp *csrf.DefaultTokenProvider, clock *csrftest.Clock) {
+			first := must_get(t, tp, ctx)
+			clock.Advance(time.Second)
+			second := must_get(t, tp, ctx)
+			if err := tp.Check(ctx, first); !errors.Is(err, csrf.ErrTokenNotFound) {
+				t.Errorf("Check of evicted = %v, want %v", err, csrf.ErrTokenNotFound)
+			}
+
+			if err := tp.Check(ctx, second); err != nil {
+				t.Errorf("Check = %v", err)
+			}
+		}},
+		{"MaxTokensPerSubject", csrf.Config{MaxTokensPerSubject: 1}, func(t *testing.T, tp *csrf.DefaultTokenProvider, _ *csrftest.Clock) {
+			first := must_get(t, tp, alice)
+			other := must_get(t, tp, ctx)
+			second := must_get(t, tp, alice)
+			if err := tp.Check(alice, first); !errors.Is(err, csrf.ErrTokenNotFound) {
+				t.Errorf("Check of evicted = %v, want %v", err, csrf.ErrTokenNotFound)
+			}
+
+			if err := tp.Check(alice, second); err != nil {
+				t.Errorf("Check of newest = %v", err)
+			}
+
+			if err := tp.Check(ctx, other); err != nil {
+				t.Errorf("Check of other subject = %v", err)
+			}
+		}},
+		{"SlidingExpiration", csrf.Config{TTL: time.Minute, SlidingExpiration: true}, func(t *testing.T, tp *csrf.DefaultTokenProvider, clock *csrftest.Clock) {
+			token := must_get(t, tp, ctx)
+			for range 3 {
+				clock.Advance(50 * time.Second)
+				if err := tp.Check(ctx, token); err != nil {
+					t.Fatalf("Check = %v", err)
+				}
+			}
+		}},
+		{"GracePeriod", csrf.Config{TTL: time.Minute, GracePeriod: 30 * time.Second}, func(t *testing.T, tp *csrf.DefaultTokenProvider, clock *csrftest.Clock) {
+			token := must_get(t, tp, ctx)
+			clock.Advance(80 * time.Second)
+			if err := tp.Check(ctx, token); err != nil {
+				t.Errorf("Check within grace = %v", err)
+			}
+
+			clock.Advance(10 * time.Second)
+			if err := tp.Check(ctx, token); !errors.Is(err, csrf.ErrTokenExpired) {
+				t.Errorf("Check after grace = %v, want %v", err, csrf.ErrTokenExpired)
+			}
+		}},
+		{"FlushOnClose", csrf.Config{FlushOnClose: true}, func(t *testing.T, tp *csrf.DefaultTokenProvider, _ *csrftest.Clock) {
+			token := must_get(t, tp, ctx)
+			tp.Close()
+			if err := tp.Check(ctx, token); !errors.Is(err, csrf.ErrTokenNotFound) {
+				t.Errorf("Check after Close = %v, want %v", err, csrf.ErrTokenNotFound)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+			tt.cfg.Clock = clock
+			tt.run(t, new_provider(t, tt.cfg), clock)
+		})
+	}
+}
+
+func TestConfigHooks(t *testing.T) {
+	var issued, consumed []string
+	tp := new_provider(t, csrf.Config{Hooks: csrf.Hooks{
+		OnIssue:   func(e csrf.TokenEvent) { issued = append(issued, e.Token) },
+		OnConsume: func(e csrf.TokenEvent) { consumed = append(consumed, e.Token) },
+	}})
+
+	token := must_get(t, tp, context.Background())
+	if err := tp.CheckAndConsume(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issued) != 1 || issued[0] != token || len(consumed) != 1 || consumed[0] != token {
+		t.Errorf("issued %v, consumed %v, want [%s]", issued, consumed, token)
+	}
+}
+
+// sequence return generator of `seq-0`, `seq-1`, ...
+func sequence() csrf.GenerateTokenFunc {
+	n := 0
+	return func() string {
+		token := fmt.Sprint("seq-", n)
+		n++
+		return token
+	}
+}
@@ -0,0 +1,54 @@
+package csrf
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+var ErrContentTypeNotAllowed = errors.New("content type not allowed")
+
+// ContentTypeCheck return [ErrContentTypeNotAllowed] for unsafe request whose media type is not one of `allowed`
+// (e.g. `application/json`), including request without Content-Type.
+// content types which can be sent by cross origin html form without CORS preflight are rejected unless explicitly allowed
+func ContentTypeCheck(allowed ...string) ValidatorFunc {
+	allowed = slices.Clone(allowed)
+	for i := range allowed {
+		allowed[i] = strings.ToLower(allowed[i])
+	}
+
+	return func(r *http.Request) error {
+		if is_safe_method(r) {
+			return nil
+		}
+
+		media_type, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || !slices.Contains(allowed, media_type) {
+			return ErrContentTypeNotAllowed
+		}
+
+		return nil
+	}
+}
+
+// WithAllowedContentTypes reject unsafe request whose media type is not one of `allowed` with [ErrContentTypeNotAllowed]
+// before the token is checked, e.g. `application/json` only for JSON API so `text/plain` simple request which skip
+// CORS preflight never reach the handler. it is [WithValidators] of [ContentTypeCheck]
+func WithAllowedContentTypes(allowed ...string) Option {
+	return WithValidators(ContentTypeCheck(allowed...))
+}
+
+// AllOf return the first error of `validators`, in order
+func AllOf(validators ...ValidatorFunc) ValidatorFunc {
+	return func(r *http.Request) error {
+		for _, validate := range validators {
+			if err := validate(r); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package csrf_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestContentTypeCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		allowed      []string
+		method       string
+		content_type string
+		want         error
+	}{
+		{"allowed", []string{"application/json"}, http.MethodPost, "application/json", nil},
+		{"params and case", []string{"Application/JSON"}, http.MethodPost, "application/json; charset=utf-8", nil},
+		{"form rejected", []string{"application/json"}, http.MethodPost, "application/x-www-form-urlencoded", csrf.ErrContentTypeNotAllowed},
+		{"form allowed", []string{"application/json", "application/x-www-form-urlencoded"}, http.MethodPost, "application/x-www-form-urlencoded", nil},
+		{"text plain", []string{"application/json"}, http.MethodPut, "text/plain", csrf.ErrContentTypeNotAllowed},
+		{"missing", []string{"application/json"}, http.MethodPost, "", csrf.ErrContentTypeNotAllowed},
+		{"malformed", []string{"application/json"}, http.MethodPost, "application/json;;", csrf.ErrContentTypeNotAllowed},
+		{"safe method", []string{"application/json"}, http.MethodGet, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			if tt.content_type != "" {
+				r.Header.Set("Content-Type", tt.content_type)
+			}
+
+			if err := csrf.ContentTypeCheck(tt.allowed...)(r); !errors.Is(err, tt.want) {
+				t.Errorf("ContentTypeCheck = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllOf(t *testing.T) {
+	first, second := errors.New("first"), errors.New("second")
+	pass := func(*http.Request) error { return nil }
+	fail := func(err error) csrf.ValidatorFunc { return func(*http.Request) error { return err } }
+
+	tests := []struct {
+		name       string
+		validators []csrf.ValidatorFunc
+		want       error
+	}{
+		{"none", nil, nil},
+		{"pass", []csrf.ValidatorFunc{pass, pass}, nil},
+		{"first error", []csrf.ValidatorFunc{pass, fail(first), fail(second)}, first},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := csrf.AllOf(tt.validators...)(post("/", "")); err != tt.want {
+				t.Errorf("AllOf = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithAllowedContentTypes(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithAllowedContentTypes("application/json"))
+	r := post("/", "token")
+	r.Header.Set("Content-Type", "text/plain")
+	if err := c.Validate(r, csrf.HeaderTokenSource); !errors.Is(err, csrf.ErrContentTypeNotAllowed) {
+		t.Errorf("Validate = %v, want %v", err, csrf.ErrContentTypeNotAllowed)
+	}
+}
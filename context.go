@@ -0,0 +1,111 @@
+package csrf
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+func with_token(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, csrf_token_context_key(0), token)
+}
+
+// TokenFromContext return token stored by [CSRF.TokenMiddleware] or empty string
+func TokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(csrf_token_context_key(0)).(string)
+	return token
+}
+
+// TokenExposure select where [CSRF.TokenMiddleware] write the issued token in addition to the request context
+type TokenExposure uint8
+
+const (
+	// ExposeHeader write the token to the response header named by [WithHeaderName]
+	ExposeHeader TokenExposure = 1 << iota
+	// ExposeCookie write the token to the cookie named by [WithCookieName]
+	ExposeCookie
+)
+
+// RequestToken issue token for `r` the same way the middlewares do, so [WithSubject], the bindings
+// and the issuance rate limit are honored, for adapters of other frameworks
+func (c *CSRF) RequestToken(r *http.Request) (string, error) {
+	return c.GetToken(c.request_context(r))
+}
+
+// TokenMiddleware store token in the request context, see [TokenFromContext] and [ContextTokenSource].
+// on safe request (see [WithSafeMethods]) the token the client carry back in the cookie or header named by
+// [WithCookieName] and [WithHeaderName] is reused while it is valid in the [TokenProvider], so static assets
+// and navigations do not issue new token. other requests may consume the token and always get new one
+func (c *CSRF) TokenMiddleware(expose TokenExposure) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := c.ReuseToken(r, c.client_token(r, expose))
+			if err != nil {
+				issue_error(w, err)
+				return
+			}
+
+			if expose&ExposeHeader != 0 {
+				w.Header().Set(c.header_name, token)
+			}
+
+			if expose&ExposeCookie != 0 {
+				http.SetCookie(w, token_cookie(r, c.cookie_name, token, false))
+			}
+
+			next.ServeHTTP(w, r.WithContext(with_token(r.Context(), token)))
+		})
+	}
+}
+
+// ReuseToken return `token` the client carried back if `r` is safe request (see [WithSafeMethods]) and the token
+// is still valid in the [TokenProvider], otherwise new token issued by [CSRF.RequestToken].
+// it is the reuse of [CSRF.TokenMiddleware] for adapters of other frameworks
+func (c *CSRF) ReuseToken(r *http.Request, token string) (string, error) {
+	if slices.Contains(c.safe_methods, r.Method) && c.valid_token(r, token) {
+		return token, nil
+	}
+
+	return c.RequestToken(r)
+}
+
+// client_token return the token previously exposed to the client, from the cookie if it is exposed there
+// or from the request header
+func (c *CSRF) client_token(r *http.Request, expose TokenExposure) string {
+	if expose&ExposeCookie != 0 {
+		if cookie, err := r.Cookie(c.cookie_name); err == nil && cookie.Value != "" {
+			return cookie.Value
+		}
+	}
+
+	return r.Header.Get(c.header_name)
+}
+
+// ResponseHeaderMiddleware write the request token to the response header `name` of every response,
+// default to the name set by [WithHeaderName], for SPA which read the header and echo it back on the next request.
+// the token is taken from [TokenFromContext] or issued if there is none, e.g. after single use token was consumed.
+// it should be placed after [CSRF.TokenMiddleware] and the validation middleware
+func (c *CSRF) ResponseHeaderMiddleware(name string) func(next http.Handler) http.Handler {
+	if name == "" {
+		name = c.header_name
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := TokenFromContext(r.Context())
+			if token == "" {
+				var err error
+				token, err = c.GetToken(c.request_context(r))
+				if err != nil {
+					issue_error(w, err)
+					return
+				}
+
+				r = r.WithContext(with_token(r.Context(), token))
+			}
+
+			w.Header().Set(name, token)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package csrf_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestTokenMiddlewareReuse(t *testing.T) {
+	tests := []struct {
+		name   string
+		expose csrf.TokenExposure
+		method string
+		carry  func(r *http.Request, token string)
+		reuse  bool
+	}{
+		{"cookie", csrf.ExposeCookie, http.MethodGet, func(r *http.Request, token string) {
+			r.AddCookie(&http.Cookie{Name: csrf.DefaultCookieName, Value: token})
+		}, true},
+		{"header", csrf.ExposeHeader, http.MethodGet, func(r *http.Request, token string) {
+			r.Header.Set(csrf.DefaultHeaderName, token)
+		}, true},
+		{"none", csrf.ExposeHeader, http.MethodGet, func(*http.Request, string) {}, false},
+		{"invalid", csrf.ExposeCookie, http.MethodGet, func(r *http.Request, _ string) {
+			r.AddCookie(&http.Cookie{Name: csrf.DefaultCookieName, Value: "invalid"})
+		}, false},
+		{"unsafe method", csrf.ExposeHeader, http.MethodPost, func(r *http.Request, token string) {
+			r.Header.Set(csrf.DefaultHeaderName, token)
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := new_provider(t, csrf.Config{})
+			c := csrf.New(tp)
+			var got string
+			handler := c.TokenMiddleware(tt.expose)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = csrf.TokenFromContext(r.Context())
+			}))
+
+			token, err := c.GetToken(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(tt.method, "/", nil)
+			tt.carry(r, token)
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if reused := got == token; reused != tt.reuse {
+				t.Errorf("reused = %v, want %v", reused, tt.reuse)
+			}
+
+			want := uint64(2)
+			if tt.reuse {
+				want = 1
+			}
+
+			if issued := tp.Stats().Issued; issued != want {
+				t.Errorf("issued = %d, want %d", issued, want)
+			}
+		})
+	}
+}
+
+func TestTokenMiddlewareConsumed(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}))
+	var got string
+	handler := c.TokenMiddleware(csrf.ExposeCookie)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = csrf.TokenFromContext(r.Context())
+	}))
+
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Validate(post("/", token), csrf.HeaderTokenSource); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: csrf.DefaultCookieName, Value: token})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	if got == token || got == "" {
+		t.Fatalf("token = %q, want new token", got)
+	}
+
+	if set := cookie(rec, csrf.DefaultCookieName); set == nil || set.Value != got {
+		t.Errorf("cookie = %v, want value %q", set, got)
+	}
+}
+
+func TestResponseHeaderMiddleware(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}))
+
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		context bool
+	}{
+		{"issued", "", csrf.DefaultHeaderName, false},
+		{"from context", "", csrf.DefaultHeaderName, true},
+		{"named", "X-Next-Token", "X-Next-Token", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token string
+			var h http.Handler = c.ResponseHeaderMiddleware(tt.header)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				token = csrf.TokenFromContext(r.Context())
+			}))
+
+			var upstream string
+			if tt.context {
+				next := h
+				h = c.TokenMiddleware(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					upstream = csrf.TokenFromContext(r.Context())
+					next.ServeHTTP(w, r)
+				}))
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if token == "" {
+				t.Fatal("no token in context")
+			}
+
+			if got := rec.Header().Get(tt.want); got != token {
+				t.Errorf("%s = %q, want %q", tt.want, got, token)
+			}
+
+			if tt.context && token != upstream {
+				t.Errorf("token = %s, want the one of TokenMiddleware %s", token, upstream)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package csrf
+
+import (
+	"net/http"
+	"time"
+)
+
+// CookieOptions for [SetTokenCookie]
+type CookieOptions struct {
+	// Name default to [DefaultCookieName]
+	Name string
+	// Path default to `/`
+	Path   string
+	Domain string
+	// MaxAge zero means session cookie
+	MaxAge   time.Duration
+	Secure   bool
+	HttpOnly bool
+	// SameSite default to [http.SameSiteLaxMode]
+	SameSite http.SameSite
+}
+
+// SetTokenCookie write the token as cookie configured by `opts`
+func SetTokenCookie(w http.ResponseWriter, token string, opts CookieOptions) {
+	if opts.Name == "" {
+		opts.Name = DefaultCookieName
+	}
+
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     opts.Name,
+		Value:    token,
+		Path:     opts.Path,
+		Domain:   opts.Domain,
+		MaxAge:   int(opts.MaxAge / time.Second),
+		Secure:   opts.Secure,
+		HttpOnly: opts.HttpOnly,
+		SameSite: opts.SameSite,
+	})
+}
+
+// CookieTokenSource return token from cookie or empty string
+func CookieTokenSource(name string) TokenSourceFunc {
+	return func(r *http.Request) string {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+
+		return cookie.Value
+	}
+}
+
+func token_cookie(r *http.Request, name, value string, http_only bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Secure:   r.TLS != nil,
+		HttpOnly: http_only,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
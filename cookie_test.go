@@ -0,0 +1,63 @@
+package csrf_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestSetTokenCookie(t *testing.T) {
+	tests := []struct {
+		name string
+		opts csrf.CookieOptions
+		want http.Cookie
+	}{
+		{
+			"defaults",
+			csrf.CookieOptions{},
+			http.Cookie{Name: csrf.DefaultCookieName, Path: "/", SameSite: http.SameSiteLaxMode},
+		},
+		{
+			"configured",
+			csrf.CookieOptions{
+				Name:     "token",
+				Path:     "/app",
+				Domain:   "example.com",
+				MaxAge:   time.Hour,
+				Secure:   true,
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			},
+			http.Cookie{
+				Name:     "token",
+				Path:     "/app",
+				Domain:   "example.com",
+				MaxAge:   3600,
+				Secure:   true,
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			csrf.SetTokenCookie(rec, "value", tt.opts)
+
+			got := cookie(rec, tt.want.Name)
+			if got == nil {
+				t.Fatalf("cookie %s not set", tt.want.Name)
+			}
+
+			if got.Value != "value" || got.Path != tt.want.Path || got.Domain != tt.want.Domain ||
+				got.MaxAge != tt.want.MaxAge || got.Secure != tt.want.Secure ||
+				got.HttpOnly != tt.want.HttpOnly || got.SameSite != tt.want.SameSite {
+				t.Errorf("cookie = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
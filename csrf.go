@@ -1,116 +1,406 @@
 package csrf
 
 import (
+	"container/heap"
 	"context"
+	"crypto/subtle"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
-var ErrInvalidToken = errors.New("invalid token")
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenMissing returned when no source supplied any token, it wraps [ErrInvalidToken]
+	ErrTokenMissing = fmt.Errorf("%w: token missing", ErrInvalidToken)
+	// ErrTokenExpired returned when the token was found but has expired, it wraps [ErrInvalidToken]
+	ErrTokenExpired = fmt.Errorf("%w: token expired", ErrInvalidToken)
+	// ErrTokenNotFound returned when the token is unknown or already consumed, it wraps [ErrInvalidToken]
+	ErrTokenNotFound = fmt.Errorf("%w: token not found", ErrInvalidToken)
+	// ErrTokenMalformed returned when the token is not printable ascii, exceed [WithMaxTokenLength] or is rejected
+	// by [WithTokenFormat], it wraps [ErrInvalidToken]
+	ErrTokenMalformed = fmt.Errorf("%w: token malformed", ErrInvalidToken)
+	// ErrInconsistentTokenBetweenSources returned when the sources supplied different tokens
+	ErrInconsistentTokenBetweenSources = errors.New("inconsistent token between sources")
+	// ErrNoSources returned by validation given no [TokenSourceFunc], it is misconfiguration
+	ErrNoSources = errors.New("no token sources")
+	// ErrTooManyTokens returned by [DefaultTokenProvider.Get] when [Config.MaxTokens] is reached
+	ErrTooManyTokens = errors.New("too many tokens")
+)
 
-// TokenProvider responsible for generating and storing unique token
+// TokenProvider responsible for generating and storing unique token.
+// every operation must return `ctx.Err()` promptly once the context is canceled or its deadline is exceeded
 type TokenProvider interface {
 	Get(ctx context.Context) (string, error)
-	// Check must return error [ErrInvalidToken] if token was not found or expired
-	// and must immediately delete the token if found
+	// Check must return error [ErrInvalidToken] if token was not found or expired,
+	// preferably the more specific [ErrTokenNotFound] or [ErrTokenExpired].
+	// it must not delete the token, see [Consumer]
 	Check(ctx context.Context, token string) error
 }
 
+// Consumer is optional interface for [TokenProvider] which support single use token
+type Consumer interface {
+	// CheckAndConsume is Check which also immediately delete the token if it is valid
+	CheckAndConsume(ctx context.Context, token string) error
+}
+
+type token_entry struct {
+	expire_at  int64
+	issued_at  int64
+	generation uint64
+	// subject is the session id the token is bound to, see [WithSessionID]
+	subject string
+	meta    map[string]string
+}
+
+// IssuedAtProvider is optional interface for [TokenProvider] which keep track the issuance time of its tokens
+type IssuedAtProvider interface {
+	// IssuedAt must return error [ErrInvalidToken] if token was not found
+	IssuedAt(ctx context.Context, token string) (time.Time, error)
+}
+
 type DefaultTokenProvider struct {
-	tokens    map[string]int64
-	mu        sync.Mutex
-	token_ttl time.Duration
+	tokens map[string]token_entry
+	expiry expiry_heap
+	// mu is held for reading by Check and friends unless [Config.SlidingExpiration] which refresh the entry
+	mu         sync.RWMutex
+	token_ttl  time.Duration
+	generation atomic.Uint64
+	generate   GenerateTokenFunc
+	max_tokens int
+	evict      bool
+	// by_subject index the tokens of every subject for RevokeAll and [Config.MaxTokensPerSubject], see subject_cap.go
+	max_per_subject int
+	by_subject      map[string]map[string]struct{}
+	sliding         bool
+	grace           time.Duration
+	logger          *slog.Logger
+	hooks           Hooks
+	// idle is set when gc is backing off, see [Config.IdleBackoff]
+	idle atomic.Bool
+	wake chan struct{}
+
+	flush          bool
+	stop_gc        context.CancelFunc
+	gc_done        chan struct{}
+	gc_jitter      time.Duration
+	gc_batch       int
+	gc_max_per_run int
+
+	stats provider_stats
+	clock Clock
 }
 
-var _ TokenProvider = (*DefaultTokenProvider)(nil)
+var (
+	_ TokenProvider         = (*DefaultTokenProvider)(nil)
+	_ Consumer              = (*DefaultTokenProvider)(nil)
+	_ Exchanger             = (*DefaultTokenProvider)(nil)
+	_ TokenProviderWithMeta = (*DefaultTokenProvider)(nil)
+	_ MetaConsumer          = (*DefaultTokenProvider)(nil)
+	_ IssuedAtProvider      = (*DefaultTokenProvider)(nil)
+)
+
+func (dtp *DefaultTokenProvider) gc(ctx context.Context, interval, max_interval time.Duration) {
+	defer close(dtp.gc_done)
+
+	current_interval := interval
+	timer := dtp.clock.NewTimer(dtp.jitter(current_interval))
+	defer timer.Stop()
 
-func (dtp *DefaultTokenProvider) gc(ctx context.Context, interval time.Duration) {
-	ticker := time.NewTicker(interval)
 	ctx_done := ctx.Done()
 	for {
 		select {
 		case <-ctx_done:
 			return
-		case <-ticker.C:
+		case <-dtp.wake:
+			// new token while backing off, go back to the normal interval
+			if !timer.Stop() {
+				select {
+				case <-timer.C():
+				default:
+				}
+			}
+			current_interval = interval
+			timer.Reset(dtp.jitter(current_interval))
+			continue
+		case <-timer.C():
 		}
 
-		current_time := time.Now()
-		dtp.mu.Lock()
-		for token, expire_at := range dtp.tokens {
-			if !(current_time.Unix() < expire_at) {
-				delete(dtp.tokens, token)
-			}
+		if remaining := dtp.sweep(ctx); remaining == 0 && max_interval > interval {
+			current_interval = min(current_interval*2, max_interval)
+			dtp.idle.Store(true)
+		} else {
+			current_interval = interval
 		}
-		dtp.mu.Unlock()
+
+		timer.Reset(dtp.jitter(current_interval))
 	}
 }
 
-func (dtp *DefaultTokenProvider) Get(_ context.Context) (string, error) {
-	uid, err := uuid.NewRandom()
+// Get issue token bound to the session id from context, see [WithSessionID]
+func (dtp *DefaultTokenProvider) Get(ctx context.Context) (string, error) {
+	token, err := GenerateToken(dtp.generate)
 	if err != nil {
-		panic(err)
+		return "", err
+	}
+
+	if err := dtp.store(ctx, token); err != nil {
+		return "", err
 	}
 
-	token := uid.String()
+	return token, nil
+}
 
+func (dtp *DefaultTokenProvider) store(ctx context.Context, token string) error {
 	dtp.mu.Lock()
-	defer dtp.mu.Unlock()
+	err := dtp.store_locked(ctx, token)
+	issued := dtp.tokens[token]
+	dtp.mu.Unlock()
 
-	dtp.tokens[token] = time.Now().Add(dtp.token_ttl).Unix()
+	if err != nil {
+		return err
+	}
 
-	return token, nil
+	call_hook(dtp.hooks.OnIssue, issued.event(token))
+	return nil
 }
 
-func (dtp *DefaultTokenProvider) Check(_ context.Context, token string) error {
-	dtp.mu.Lock()
-	defer dtp.mu.Unlock()
+// store_locked store token bound to the session id from context with the ttl from [WithTokenTTL] if any,
+// it must be called with dtp.mu held
+func (dtp *DefaultTokenProvider) store_locked(ctx context.Context, token string) error {
+	// the request may have been canceled while waiting for the lock
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if dtp.max_tokens > 0 && len(dtp.tokens) >= dtp.max_tokens {
+		if !dtp.evict {
+			dtp.logger.Warn("csrf: token limit reached", "max_tokens", dtp.max_tokens)
+			return ErrTooManyTokens
+		}
+
+		dtp.evict_oldest_locked()
+	}
 
-	expire_at, found := dtp.tokens[token]
-	if !(found && time.Now().Unix() < expire_at) {
-		return ErrInvalidToken
+	now := dtp.clock.Now()
+	subject := SessionIDFromContext(ctx)
+	expire_at := now.Add(TTLFromContext(ctx, dtp.token_ttl)).Unix()
+	heap.Push(&dtp.expiry, expiry_item{expire_at: expire_at, token: token})
+	dtp.tokens[token] = token_entry{
+		expire_at:  expire_at,
+		issued_at:  now.UnixNano(),
+		generation: dtp.generation.Load(),
+		subject:    subject,
+	}
+	dtp.stats.issued.Add(1)
+	dtp.index_locked(token, subject)
+
+	if dtp.idle.CompareAndSwap(true, false) {
+		select {
+		case dtp.wake <- struct{}{}:
+		default:
+		}
 	}
 
 	return nil
 }
 
+// Check return [ErrInvalidToken] if the token is not bound to the session id from context
+func (dtp *DefaultTokenProvider) Check(ctx context.Context, token string) error {
+	defer dtp.lock_check()()
+
+	return dtp.check(ctx, token)
+}
+
+// lock_check lock dtp.mu for check and return the unlock function, check only read the entry
+// so concurrent validation of multi use tokens share the read lock unless sliding expiration is enabled
+func (dtp *DefaultTokenProvider) lock_check() (unlock func()) {
+	if dtp.sliding {
+		dtp.mu.Lock()
+		return dtp.mu.Unlock
+	}
+
+	dtp.mu.RLock()
+	return dtp.mu.RUnlock
+}
+
+func (dtp *DefaultTokenProvider) CheckAndConsume(ctx context.Context, token string) error {
+	_, err := dtp.CheckAndConsumeWithMeta(ctx, token)
+	return err
+}
+
+// check must be called with dtp.mu held, see [DefaultTokenProvider.lock_check]
+func (dtp *DefaultTokenProvider) check(ctx context.Context, token string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entry, found := dtp.tokens[token]
+	if !(found && subtle.ConstantTimeCompare([]byte(entry.subject), []byte(SessionIDFromContext(ctx))) == 1) {
+		dtp.stats.rejected.Add(1)
+		return ErrTokenNotFound
+	}
+
+	now := dtp.clock.Now().Unix()
+	if !(now < entry.expire_at+dtp.grace_seconds() && entry.generation == dtp.generation.Load()) {
+		dtp.stats.rejected.Add(1)
+		return ErrTokenExpired
+	}
+
+	if now >= entry.expire_at {
+		dtp.stats.grace.Add(1)
+	}
+
+	dtp.stats.validated.Add(1)
+
+	if dtp.sliding {
+		entry.expire_at = dtp.clock.Now().Add(dtp.token_ttl).Unix()
+		dtp.tokens[token] = entry
+	}
+
+	return nil
+}
+
+func (dtp *DefaultTokenProvider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	dtp.mu.RLock()
+	defer dtp.mu.RUnlock()
+
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	entry, found := dtp.tokens[token]
+	if !found {
+		return time.Time{}, ErrTokenNotFound
+	}
+
+	return time.Unix(0, entry.issued_at), nil
+}
+
+// Rotate invalidate all tokens issued before the call in O(1),
+// the stale tokens are rejected by Check and removed by the gc once expired
+func (dtp *DefaultTokenProvider) Rotate() {
+	dtp.generation.Add(1)
+}
+
+// NewDefaultTokenProvider is [NewDefaultTokenProviderConfig] with only [Config.GCInterval] set
 func NewDefaultTokenProvider(ctx context.Context, gc_intrvl time.Duration) *DefaultTokenProvider {
-	dtp := &DefaultTokenProvider{tokens: make(map[string]int64)}
-	go dtp.gc(ctx, gc_intrvl)
-	return dtp
+	return NewDefaultTokenProviderConfig(ctx, Config{GCInterval: gc_intrvl})
 }
 
 type GenerateTokenFunc func() string
 
+// UUIDTokenGenerator generate random UUID v4 token, it panics if the system entropy source fails,
+// use [GenerateToken] to get the failure as error
+func UUIDTokenGenerator() string {
+	uid, err := uuid.NewRandom()
+	if err != nil {
+		panic(entropy_failure{err})
+	}
+
+	return uid.String()
+}
+
 type CSRF struct {
 	TokenProvider
+	tracer       trace.Tracer
+	issued_after atomic.Int64
+	on_failure   []func(Failure)
+	masking      bool
+
+	header_name    string
+	form_field     string
+	cookie_name    string
+	error_handler  ErrorHandlerFunc
+	safe_methods   []string
+	skippers       []func(*http.Request) bool
+	validators     []ValidatorFunc
+	token_format   func(string) bool
+	max_token_len  int
+	issue_limiter  RateLimiter
+	rate_key       func(*http.Request) string
+	subject        func(*http.Request) string
+	bindings       []func(*http.Request) string
+	token_mode     TokenMode
+	metrics        Collector
+	failure_policy FailurePolicy
+	report_only    bool
+	scope          func(*http.Request) string
+	clock          Clock
+	token_age      bool
 }
 
-func New(tp TokenProvider) *CSRF {
-	return &CSRF{TokenProvider: tp}
+type Option func(*CSRF)
+
+func New(tp TokenProvider, opts ...Option) *CSRF {
+	c := &CSRF{
+		TokenProvider: tp,
+		tracer:        noop.NewTracerProvider().Tracer(""),
+		header_name:   DefaultHeaderName,
+		form_field:    DefaultFormField,
+		cookie_name:   DefaultCookieName,
+		error_handler: DefaultErrorHandler,
+		safe_methods:  DefaultSafeMethods,
+		max_token_len: DefaultMaxTokenLength,
+		metrics:       nop_collector{},
+		clock:         SystemClock{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 // GetToken return new token and store it in the [TokenProvider]
-func (c *CSRF) GetToken(ctx context.Context) (string, error) {
-	return c.TokenProvider.Get(ctx)
+func (c *CSRF) GetToken(ctx context.Context) (token string, err error) {
+	ctx, span := c.tracer.Start(ctx, "csrf.GetToken")
+	defer func() { end_span(span, err) }()
+
+	if err := c.allow_issue(ctx); err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	token, err = provider_span(ctx, c, "Get", func(ctx context.Context) (string, error) {
+		return c.TokenProvider.Get(ctx)
+	})
+	c.metrics.ProviderLatency("get", time.Since(start))
+	if err == nil {
+		c.metrics.TokenIssued()
+	}
+
+	if err != nil || !c.masking {
+		return token, err
+	}
+
+	return MaskToken(token)
 }
 
 type (
 	TokenSourceFunc        func(*http.Request) string
+	ValidatorFunc          func(*http.Request) error
 	csrf_token_context_key int
 )
 
 // ContextTokenSource return token from request context or empty string
 func ContextTokenSource(r *http.Request) string {
-	return r.Context().Value(csrf_token_context_key(0)).(string)
+	return TokenFromContext(r.Context())
 }
 
 // HeaderTokenSource return token from `X-Csrf-Token` header or empty string
+//
+// Deprecated: the header is fixed regardless of [WithHeaderName], use [CSRF.HeaderSource]
 func HeaderTokenSource(r *http.Request) string {
-	return r.Header.Get("X-Csrf-Token")
+	return r.Header.Get(DefaultHeaderName)
 }
 
 // FormTokenSource return token from form value
@@ -120,39 +410,184 @@ func FormTokenSource(field string) TokenSourceFunc {
 	}
 }
 
-var errInconsistentTokenBetweenSources = errors.New("inconsistent token between sources")
+// QueryTokenSource return token from url query parameter only, the body is never read
+func QueryTokenSource(param string) TokenSourceFunc {
+	return func(r *http.Request) string {
+		return r.URL.Query().Get(param)
+	}
+}
 
 // Validate extract token from the specified sources
 // and [ErrInvalidToken] if token is not found or has been expired
 func (c *CSRF) Validate(r *http.Request, sources ...TokenSourceFunc) error {
+	return c.ValidateWithContext(r.Context(), r, sources...)
+}
+
+// request_context return the request context carrying the subject from [WithSubject]
+func (c *CSRF) request_context(r *http.Request) context.Context {
+	ctx := r.Context()
+	if c.issue_limiter != nil {
+		ctx = context.WithValue(ctx, csrf_rate_key_context_key(0), c.rate_key(r))
+	}
+
+	return c.with_subject(ctx, r)
+}
+
+func (c *CSRF) with_subject(ctx context.Context, r *http.Request) context.Context {
+	if c.subject == nil && len(c.bindings) == 0 {
+		return ctx
+	}
+
+	subject := SessionIDFromContext(ctx)
+	if c.subject != nil {
+		subject = c.subject(r)
+	}
+
+	return WithSessionID(ctx, c.bind(subject, r))
+}
+
+// ValidateWithContext is [CSRF.Validate] but the [TokenProvider] is called with `ctx` instead of the request context,
+// e.g. to impose own deadline on slow store in background job
+func (c *CSRF) ValidateWithContext(ctx context.Context, r *http.Request, sources ...TokenSourceFunc) (err error) {
+	ctx, span := c.tracer.Start(c.with_scope(c.with_subject(ctx, r), r), "csrf.Validate")
+	supplied, err := c.validate(ctx, r, sources, c.check)
+	// the span record the actual result also in report only mode
+	end_span(span, err)
+	return c.report(r, supplied, err)
+}
+
+// validate extract the token and pass it to `check`, backend error of `check` is ignored by [FailOpen] policy
+func (c *CSRF) validate(ctx context.Context, r *http.Request, sources []TokenSourceFunc, check func(context.Context, string) error) (supplied_token, error) {
+	token, supplied, err := c.extract(ctx, r, sources)
+	if err != nil {
+		return supplied, err
+	}
+
+	c.observe_token_age(ctx, token)
+	err = check(ctx, token)
+	if c.failure_policy == FailOpen && is_backend_error(ctx, err) {
+		c.fail_open(r, supplied, err)
+		return supplied, nil
+	}
+
+	return supplied, err
+}
+
+// supplied_token is the value as read from the source, before unmasking, for the failure hooks
+type supplied_token struct {
+	source TokenSourceFunc
+	value  string
+}
+
+// extract run the validators and return the token from the sources if it pass every check but the provider,
+// along with the source which supplied the token and its value
+func (c *CSRF) extract(ctx context.Context, r *http.Request, sources []TokenSourceFunc) (string, supplied_token, error) {
 	if len(sources) == 0 {
-		panic("`sources` paramter is required")
+		return "", supplied_token{}, ErrNoSources
+	}
+
+	if err := c.run_validators(r); err != nil {
+		return "", supplied_token{}, err
 	}
 
 	token := ""
+	var supplied supplied_token
 	for _, source := range sources {
+		// the raw value is checked before unmasking so oversized garbage cost nothing but the read
+		raw := source(r)
+		if !c.well_formed(raw) {
+			return "", supplied_token{source: source, value: raw}, ErrTokenMalformed
+		}
+
+		value := raw
+		if c.masking {
+			value = UnmaskToken(raw)
+		}
+
 		if token == "" {
-			token = source(r)
+			token = value
+			if token != "" {
+				supplied = supplied_token{source: source, value: raw}
+				record_source(ctx, source)
+			}
 			continue
 		}
 
-		if token != source(r) {
-			return errInconsistentTokenBetweenSources
+		if subtle.ConstantTimeCompare([]byte(token), []byte(value)) != 1 {
+			return "", supplied, ErrInconsistentTokenBetweenSources
 		}
 	}
 
 	// shortcut for bad SourceFunc
 	if token == "" {
-		return ErrInvalidToken
+		return "", supplied_token{}, ErrTokenMissing
+	}
+
+	if err := c.precheck(ctx, token); err != nil {
+		return "", supplied, err
 	}
 
-	return c.TokenProvider.Check(r.Context(), token)
+	return token, supplied, nil
 }
 
-func (c *CSRF) ValidateMiddleware(handle_err func(http.ResponseWriter, *http.Request, error), sources ...TokenSourceFunc) func(next http.Handler) http.Handler {
+// run_validators return the first error of [WithValidators], they see the safe methods of [WithSafeMethods]
+func (c *CSRF) run_validators(r *http.Request) error {
+	if len(c.validators) == 0 {
+		return nil
+	}
+
+	r = c.with_safe_methods(r)
+	for _, validate := range c.validators {
+		if err := validate(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// precheck run the checks of unmasked `token` which do not consume it, before it is checked by the provider
+func (c *CSRF) precheck(ctx context.Context, token string) error {
+	if c.token_format != nil && !c.token_format(token) {
+		return ErrTokenMalformed
+	}
+
+	return c.check_issued_after(ctx, token)
+}
+
+// ValidateMiddleware validate the request and pass the result to `handle_err`,
+// request with safe method (see [WithSafeMethods]) is passed to `next` without validation.
+//
+// Deprecated: `next` is never called for validated request, use [CSRF.Protect] instead
+func (c *CSRF) ValidateMiddleware(handle_err ErrorHandlerFunc, sources ...TokenSourceFunc) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if c.Skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			handle_err(w, r, c.Validate(r, sources...))
 		})
 	}
 }
+
+// FailureHandlerFunc handle validation failure in [CSRF.Protect],
+// it return true to continue to the next handler or false to stop
+type FailureHandlerFunc func(http.ResponseWriter, *http.Request, error) bool
+
+// Protect validate the request and call `next` on success, on failure `handle_failure` decide
+// whether the request continue to `next`. request with safe method (see [WithSafeMethods]) is not validated
+func (c *CSRF) Protect(handle_failure FailureHandlerFunc, sources ...TokenSourceFunc) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !c.Skip(r) {
+				if err := c.Validate(r, sources...); err != nil && !handle_failure(w, r, err) {
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestDefaultTokenProviderRotate(t *testing.T) {
+	tp := new_provider(t, csrf.Config{})
+	ctx := context.Background()
+
+	before, err := tp.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tp.Rotate()
+
+	after, err := tp.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"before rotate", before, csrf.ErrTokenExpired},
+		{"after rotate", after, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tp.Check(ctx, tt.token); !errors.Is(err, tt.want) {
+				t.Errorf("Check = %v, want %v", err, tt.want)
+			}
+
+			if err := tp.CheckAndConsume(ctx, tt.token); !errors.Is(err, tt.want) {
+				t.Errorf("CheckAndConsume = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	tp.Rotate()
+	if err := tp.Check(ctx, after); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("Check of consumed token after second rotate = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+}
+
+func TestValidateWithContext(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithTokenMode(csrf.MultiUse))
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		request context.Context
+		want    error
+	}{
+		{"live", context.Background(), context.Background(), nil},
+		{"canceled", canceled, context.Background(), context.Canceled},
+		{"deadline", expired, context.Background(), context.DeadlineExceeded},
+		{"canceled request", context.Background(), canceled, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := post("/", token).WithContext(tt.request)
+			if err := c.ValidateWithContext(tt.ctx, r, csrf.HeaderTokenSource); !errors.Is(err, tt.want) {
+				t.Errorf("ValidateWithContext = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtect(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithTokenMode(csrf.MultiUse))
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		r        *http.Request
+		proceed  bool
+		reached  bool
+		failures int
+	}{
+		{"valid", post("/", token), false, true, 0},
+		{"safe method", httptest.NewRequest(http.MethodGet, "/", nil), false, true, 0},
+		{"stop", post("/", "unknown"), false, false, 1},
+		{"proceed", post("/", "unknown"), true, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failures []error
+			mw := c.Protect(func(w http.ResponseWriter, r *http.Request, err error) bool {
+				failures = append(failures, err)
+				if !tt.proceed {
+					w.WriteHeader(http.StatusForbidden)
+				}
+				return tt.proceed
+			}, csrf.HeaderTokenSource)
+
+			if reached, _ := csrftest.Serve(mw, tt.r); reached != tt.reached {
+				t.Errorf("reached = %v, want %v", reached, tt.reached)
+			}
+
+			if len(failures) != tt.failures {
+				t.Errorf("handle_failure called with %v, want %d calls", failures, tt.failures)
+			}
+		})
+	}
+}
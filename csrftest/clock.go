@@ -0,0 +1,83 @@
+package csrftest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bokunodev/csrf"
+)
+
+// Clock is manual [csrf.Clock], time only moves on [Clock.Advance] and timers fire when their deadline is passed
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*timer
+}
+
+var _ csrf.Clock = (*Clock)(nil)
+
+// NewClock return [Clock] starting at `now`
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *Clock) NewTimer(d time.Duration) csrf.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &timer{clock: c, c: make(chan time.Time, 1), deadline: c.now.Add(d), active: true}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance move the clock forward by `d` and fire every timer whose deadline is passed
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if t.active && !t.deadline.After(c.now) {
+			t.active = false
+			select {
+			case t.c <- c.now:
+			default:
+			}
+		}
+	}
+}
+
+type timer struct {
+	clock    *Clock
+	c        chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+func (t *timer) C() <-chan time.Time { return t.c }
+
+func (t *timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	was_active := t.active
+	t.active = false
+	return was_active
+}
+
+func (t *timer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	was_active := t.active
+	t.active = true
+	t.deadline = t.clock.now.Add(d)
+	return was_active
+}
@@ -0,0 +1,127 @@
+// Package csrftest provide utilities to test handlers protected by package csrf
+package csrftest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+// AttachFunc place `token` on the request where the middleware under test read it
+type AttachFunc func(r *http.Request, token string)
+
+// Header set the token on header `name`, default to [csrf.DefaultHeaderName]
+func Header(name string) AttachFunc {
+	if name == "" {
+		name = csrf.DefaultHeaderName
+	}
+
+	return func(r *http.Request, token string) {
+		r.Header.Set(name, token)
+	}
+}
+
+// Form replace the request body with url encoded form holding the token in field `field`,
+// default to [csrf.DefaultFormField]
+func Form(field string) AttachFunc {
+	if field == "" {
+		field = csrf.DefaultFormField
+	}
+
+	return func(r *http.Request, token string) {
+		body := url.Values{field: {token}}.Encode()
+		r.Body = io.NopCloser(strings.NewReader(body))
+		r.ContentLength = int64(len(body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Form, r.PostForm = nil, nil
+	}
+}
+
+// Cookie add the token as cookie `name`, default to [csrf.DefaultCookieName]
+func Cookie(name string) AttachFunc {
+	if name == "" {
+		name = csrf.DefaultCookieName
+	}
+
+	return func(r *http.Request, token string) {
+		r.AddCookie(&http.Cookie{Name: name, Value: token})
+	}
+}
+
+// Token issue token for `r` the same way [csrf.CSRF.TokenMiddleware] does, so subject and bindings
+// configured on `c` are honored. the session id must already be in the request context if the provider needs one
+func Token(tb testing.TB, c *csrf.CSRF, r *http.Request) string {
+	tb.Helper()
+
+	var token string
+	rec := httptest.NewRecorder()
+	c.TokenMiddleware(0)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		token = csrf.TokenFromContext(r.Context())
+	})).ServeHTTP(rec, r)
+
+	if token == "" {
+		tb.Fatalf("csrftest: issue token: %d %s", rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+
+	return token
+}
+
+// Attach issue token for `r` and place it with every `attach`, e.g. [Header] and [Cookie] for double submit
+func Attach(tb testing.TB, c *csrf.CSRF, r *http.Request, attach ...AttachFunc) *http.Request {
+	tb.Helper()
+
+	token := Token(tb, c, r)
+	for _, attach := range attach {
+		attach(r, token)
+	}
+
+	return r
+}
+
+// NewRequest is [httptest.NewRequest] with token attached by [Attach]
+func NewRequest(tb testing.TB, c *csrf.CSRF, method, target string, attach ...AttachFunc) *http.Request {
+	tb.Helper()
+
+	return Attach(tb, c, httptest.NewRequest(method, target, nil), attach...)
+}
+
+// Serve `r` through `mw` and report whether it reached the protected handler
+func Serve(mw func(http.Handler) http.Handler, r *http.Request) (reached bool, rec *httptest.ResponseRecorder) {
+	rec = httptest.NewRecorder()
+	mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(rec, r)
+
+	return reached, rec
+}
+
+// AssertAllowed fail the test if `r` does not reach the handler behind `mw`
+func AssertAllowed(tb testing.TB, mw func(http.Handler) http.Handler, r *http.Request) {
+	tb.Helper()
+
+	if reached, rec := Serve(mw, r); !reached {
+		tb.Errorf("csrftest: %s %s rejected with %d %s", r.Method, r.URL, rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+}
+
+// AssertRejected fail the test if `r` reach the handler behind `mw`, or the response status is not `status`.
+// zero status accept any status
+func AssertRejected(tb testing.TB, mw func(http.Handler) http.Handler, r *http.Request, status int) {
+	tb.Helper()
+
+	reached, rec := Serve(mw, r)
+	if reached {
+		tb.Errorf("csrftest: %s %s was not rejected", r.Method, r.URL)
+		return
+	}
+
+	if status != 0 && rec.Code != status {
+		tb.Errorf("csrftest: %s %s rejected with %d, want %d", r.Method, r.URL, rec.Code, status)
+	}
+}
@@ -0,0 +1,206 @@
+package csrftest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+// recorder is [testing.TB] recording whether the assertion under test failed
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Helper()                      {}
+func (r *recorder) Errorf(string, ...any)        { r.failed = true }
+func (r *recorder) Fatalf(string, ...any)        { r.failed = true }
+func (r *recorder) Logf(format string, a ...any) {}
+
+func TestNewRequest(t *testing.T) {
+	c := csrf.New(csrftest.NewProvider())
+
+	tests := []struct {
+		name   string
+		attach []csrftest.AttachFunc
+		source csrf.TokenSourceFunc
+		valid  bool
+	}{
+		{"header", []csrftest.AttachFunc{csrftest.Header("")}, c.HeaderSource(), true},
+		{"custom header", []csrftest.AttachFunc{csrftest.Header("X-Token")}, func(r *http.Request) string { return r.Header.Get("X-Token") }, true},
+		{"form", []csrftest.AttachFunc{csrftest.Form("")}, c.FormSource(), true},
+		{"cookie", []csrftest.AttachFunc{csrftest.Cookie("")}, c.CookieSource(), true},
+		{"nothing attached", nil, c.HeaderSource(), false},
+		{"wrong place", []csrftest.AttachFunc{csrftest.Cookie("")}, c.HeaderSource(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := csrftest.NewRequest(t, c, http.MethodPost, "/", tt.attach...)
+			err := c.Validate(r, tt.source)
+			if tt.valid && err != nil {
+				t.Fatalf("Validate = %v", err)
+			}
+
+			if !tt.valid && !errors.Is(err, csrf.ErrInvalidToken) {
+				t.Fatalf("Validate = %v, want %v", err, csrf.ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestTokenHonorSubject(t *testing.T) {
+	c := csrf.New(csrftest.NewProvider(), csrf.WithSubject(func(r *http.Request) string {
+		return r.Header.Get("X-User")
+	}))
+
+	r := csrftest.NewRequest(t, c, http.MethodPost, "/", csrftest.Header(""))
+	r.Header.Set("X-User", "bob")
+	if err := c.Validate(r, c.HeaderSource()); err == nil {
+		t.Fatal("token issued for anonymous validated for bob")
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-User", "bob")
+	csrftest.Attach(t, c, r, csrftest.Header(""))
+	if err := c.Validate(r, c.HeaderSource()); err != nil {
+		t.Fatalf("Validate = %v", err)
+	}
+}
+
+func TestAssert(t *testing.T) {
+	c := csrf.New(csrftest.NewProvider())
+	mw := c.Middleware()
+
+	tests := []struct {
+		name    string
+		attach  bool
+		assert  func(testing.TB, *http.Request)
+		failure bool
+	}{
+		{"allowed with token", true, func(tb testing.TB, r *http.Request) { csrftest.AssertAllowed(tb, mw, r) }, false},
+		{"allowed without token", false, func(tb testing.TB, r *http.Request) { csrftest.AssertAllowed(tb, mw, r) }, true},
+		{"rejected without token", false, func(tb testing.TB, r *http.Request) { csrftest.AssertRejected(tb, mw, r, http.StatusForbidden) }, false},
+		{"rejected any status", false, func(tb testing.TB, r *http.Request) { csrftest.AssertRejected(tb, mw, r, 0) }, false},
+		{"rejected wrong status", false, func(tb testing.TB, r *http.Request) { csrftest.AssertRejected(tb, mw, r, http.StatusBadRequest) }, true},
+		{"rejected with token", true, func(tb testing.TB, r *http.Request) { csrftest.AssertRejected(tb, mw, r, 0) }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attach []csrftest.AttachFunc
+			if tt.attach {
+				attach = append(attach, csrftest.Header(""))
+			}
+
+			rec := &recorder{TB: t}
+			tt.assert(rec, csrftest.NewRequest(t, c, http.MethodPost, "/", attach...))
+			if rec.failed != tt.failure {
+				t.Fatalf("failed = %v, want %v", rec.failed, tt.failure)
+			}
+		})
+	}
+}
+
+func TestProvider(t *testing.T) {
+	p := csrftest.NewProvider()
+	ctx := csrf.WithSessionID(context.Background(), "session")
+
+	first, _ := p.Get(ctx)
+	second, _ := p.Get(ctx)
+	if first != "token-1" || second != "token-2" {
+		t.Fatalf("Get = %q, %q, want token-1, token-2", first, second)
+	}
+
+	if err := p.Check(context.Background(), first); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("Check of other session = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+
+	p.Expire(first)
+	if err := p.Check(ctx, first); !errors.Is(err, csrf.ErrTokenExpired) {
+		t.Errorf("Check after Expire = %v, want %v", err, csrf.ErrTokenExpired)
+	}
+
+	if err := p.CheckAndConsume(ctx, second); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Check(ctx, second); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("Check after consume = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+
+	if len(p.Consumed) != 1 || p.Consumed[0] != second {
+		t.Errorf("Consumed = %v, want [%s]", p.Consumed, second)
+	}
+
+	if len(p.Checked) != 4 {
+		t.Errorf("Checked = %v, want 4 calls", p.Checked)
+	}
+
+	third, _ := p.Get(ctx)
+	if err := p.RevokeAll(ctx, "session"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Check(ctx, third); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("Check after RevokeAll = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+
+	outage := errors.New("outage")
+	p.Err = outage
+	if _, err := p.Get(ctx); !errors.Is(err, outage) {
+		t.Errorf("Get = %v, want %v", err, outage)
+	}
+
+	if err := p.Check(ctx, first); !errors.Is(err, outage) {
+		t.Errorf("Check = %v, want %v", err, outage)
+	}
+}
+
+func TestClock(t *testing.T) {
+	start := time.Unix(1_000_000, 0)
+	clock := csrftest.NewClock(start)
+
+	timer := clock.NewTimer(time.Minute)
+	clock.Advance(time.Minute - time.Nanosecond)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired before its deadline")
+	default:
+	}
+
+	clock.Advance(time.Nanosecond)
+	select {
+	case now := <-timer.C():
+		if !now.Equal(start.Add(time.Minute)) {
+			t.Errorf("fired at %v, want %v", now, start.Add(time.Minute))
+		}
+	default:
+		t.Fatal("timer did not fire at its deadline")
+	}
+
+	if timer.Reset(time.Second) {
+		t.Error("Reset of fired timer reported active")
+	}
+
+	if !timer.Stop() {
+		t.Error("Stop of reset timer reported inactive")
+	}
+
+	clock.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+
+	if got := clock.Now(); !got.Equal(start.Add(time.Hour + time.Minute)) {
+		t.Errorf("Now = %v, want %v", got, start.Add(time.Hour+time.Minute))
+	}
+}
@@ -0,0 +1,115 @@
+package csrftest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bokunodev/csrf"
+)
+
+// Provider is deterministic in memory [csrf.TokenProvider], tokens are `token-1`, `token-2`, ... in order of issuance
+// and never expire. it records every call so tests can assert how the provider was used
+type Provider struct {
+	mu     sync.Mutex
+	next   int
+	tokens map[string]string
+	// expired are tokens marked by Expire
+	expired map[string]bool
+	// Err is returned by every call if not nil, e.g. to simulate backend outage
+	Err error
+
+	// Issued are the issued tokens in order
+	Issued []string
+	// Checked are the tokens passed to Check and CheckAndConsume in order
+	Checked []string
+	// Consumed are the tokens consumed by CheckAndConsume in order
+	Consumed []string
+}
+
+var (
+	_ csrf.TokenProvider = (*Provider)(nil)
+	_ csrf.Consumer      = (*Provider)(nil)
+	_ csrf.Revoker       = (*Provider)(nil)
+)
+
+// NewProvider return empty [Provider]
+func NewProvider() *Provider {
+	return &Provider{tokens: make(map[string]string), expired: make(map[string]bool)}
+}
+
+// Get issue the next token bound to the session id from context, see [csrf.WithSessionID]
+func (p *Provider) Get(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Err != nil {
+		return "", p.Err
+	}
+
+	p.next++
+	token := fmt.Sprintf("token-%d", p.next)
+	p.tokens[token] = csrf.SessionIDFromContext(ctx)
+	p.Issued = append(p.Issued, token)
+	return token, nil
+}
+
+func (p *Provider) Check(ctx context.Context, token string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.check(ctx, token)
+}
+
+func (p *Provider) CheckAndConsume(ctx context.Context, token string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.check(ctx, token); err != nil {
+		return err
+	}
+
+	delete(p.tokens, token)
+	p.Consumed = append(p.Consumed, token)
+	return nil
+}
+
+// check must be called with p.mu held
+func (p *Provider) check(ctx context.Context, token string) error {
+	p.Checked = append(p.Checked, token)
+	if p.Err != nil {
+		return p.Err
+	}
+
+	subject, found := p.tokens[token]
+	if !found || subject != csrf.SessionIDFromContext(ctx) {
+		return csrf.ErrTokenNotFound
+	}
+
+	if p.expired[token] {
+		return csrf.ErrTokenExpired
+	}
+
+	return nil
+}
+
+func (p *Provider) RevokeAll(_ context.Context, subject string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for token, s := range p.tokens {
+		if csrf.MatchSubject(s, subject) {
+			delete(p.tokens, token)
+		}
+	}
+
+	return nil
+}
+
+// Expire make Check of `token` return [csrf.ErrTokenExpired] from now on
+func (p *Provider) Expire(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.expired[token] = true
+}
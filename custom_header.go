@@ -0,0 +1,31 @@
+package csrf
+
+import (
+	"errors"
+	"net/http"
+)
+
+var ErrCustomHeaderMissing = errors.New("custom header missing")
+
+// CustomHeaderCheck return [ErrCustomHeaderMissing] for unsafe request without header `name`, or whose value is not `value`
+// if it is not empty. html form can not set custom header and cross origin script can not send it without CORS preflight,
+// so it protect same origin only AJAX API without token, or combined with token validation with [AllOf] as defense in depth
+func CustomHeaderCheck(name, value string) ValidatorFunc {
+	return func(r *http.Request) error {
+		if is_safe_method(r) {
+			return nil
+		}
+
+		got := r.Header.Get(name)
+		if got == "" || (value != "" && got != value) {
+			return ErrCustomHeaderMissing
+		}
+
+		return nil
+	}
+}
+
+// RequestedWithCheck is [CustomHeaderCheck] requiring `X-Requested-With: XMLHttpRequest`
+func RequestedWithCheck() ValidatorFunc {
+	return CustomHeaderCheck("X-Requested-With", "XMLHttpRequest")
+}
@@ -0,0 +1,38 @@
+package csrf_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestCustomHeaderCheck(t *testing.T) {
+	with := func(r *http.Request, name, value string) *http.Request {
+		r.Header.Set(name, value)
+		return r
+	}
+
+	tests := []struct {
+		name  string
+		check csrf.ValidatorFunc
+		r     *http.Request
+		want  error
+	}{
+		{"any value", csrf.CustomHeaderCheck("X-Api", ""), with(post("/", ""), "X-Api", "1"), nil},
+		{"missing", csrf.CustomHeaderCheck("X-Api", ""), post("/", ""), csrf.ErrCustomHeaderMissing},
+		{"safe method", csrf.CustomHeaderCheck("X-Api", ""), httptest.NewRequest(http.MethodGet, "/", nil), nil},
+		{"requested with", csrf.RequestedWithCheck(), with(post("/", ""), "X-Requested-With", "XMLHttpRequest"), nil},
+		{"requested with other value", csrf.RequestedWithCheck(), with(post("/", ""), "X-Requested-With", "fetch"), csrf.ErrCustomHeaderMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.check(tt.r); !errors.Is(err, tt.want) {
+				t.Errorf("check = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
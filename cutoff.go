@@ -0,0 +1,55 @@
+package csrf
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errIssuedAtNotTracked returned by [IssuedAtProvider] wrapper when the wrapped provider does not track issuance time
+var errIssuedAtNotTracked = errors.New("issuance time is not tracked")
+
+// WithIssuedAfter reject tokens issued before `t` even if they are otherwise valid,
+// it only take effect if the [TokenProvider] implements [IssuedAtProvider]
+func WithIssuedAfter(t time.Time) Option {
+	return func(c *CSRF) {
+		c.SetIssuedAfter(t)
+	}
+}
+
+// SetIssuedAfter atomically update the cutoff set by [WithIssuedAfter], zero `t` disable the cutoff
+func (c *CSRF) SetIssuedAfter(t time.Time) {
+	if t.IsZero() {
+		c.issued_after.Store(0)
+		return
+	}
+
+	c.issued_after.Store(t.UnixNano())
+}
+
+func (c *CSRF) check_issued_after(ctx context.Context, token string) error {
+	cutoff := c.issued_after.Load()
+	if cutoff == 0 {
+		return nil
+	}
+
+	iap, ok := c.TokenProvider.(IssuedAtProvider)
+	if !ok {
+		return nil
+	}
+
+	issued_at, err := iap.IssuedAt(ctx, token)
+	if errors.Is(err, errIssuedAtNotTracked) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if issued_at.UnixNano() < cutoff {
+		return ErrTokenExpired
+	}
+
+	return nil
+}
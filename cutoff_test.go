@@ -0,0 +1,63 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestWithIssuedAfter(t *testing.T) {
+	clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+	c := csrf.New(new_provider(t, csrf.Config{Clock: clock}), csrf.WithTokenMode(csrf.MultiUse))
+
+	before, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(time.Minute)
+	c.SetIssuedAfter(clock.Now())
+
+	after, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		cutoff time.Time
+		token  string
+		want   error
+	}{
+		{"before cutoff", clock.Now(), before, csrf.ErrTokenExpired},
+		{"at cutoff", clock.Now(), after, nil},
+		{"cutoff moved", clock.Now().Add(time.Second), after, csrf.ErrTokenExpired},
+		{"cutoff disabled", time.Time{}, before, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.SetIssuedAfter(tt.cutoff)
+			if err := c.Validate(post("/", tt.token), csrf.HeaderTokenSource); !errors.Is(err, tt.want) {
+				t.Errorf("Validate = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithIssuedAfterUntracked(t *testing.T) {
+	// the fake provider does not track issuance time, the cutoff has no effect
+	c := csrf.New(csrftest.NewProvider(), csrf.WithIssuedAfter(time.Now().Add(time.Hour)))
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Validate(post("/", token), csrf.HeaderTokenSource); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+}
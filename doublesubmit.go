@@ -0,0 +1,59 @@
+package csrf
+
+import (
+	"net/http"
+	"slices"
+)
+
+const double_submit_separator = "."
+
+// DoubleSubmit implements signed double submit cookie pattern, see [CSRF.DoubleSubmit]
+type DoubleSubmit struct {
+	c           *CSRF
+	cookie_name string
+	key         []byte
+}
+
+// DoubleSubmit return [DoubleSubmit] storing the token in cookie named `cookie_name` signed with `key`
+func (c *CSRF) DoubleSubmit(cookie_name string, key []byte) *DoubleSubmit {
+	return &DoubleSubmit{c: c, cookie_name: cookie_name, key: key}
+}
+
+// Source return token from the signed cookie or empty string if the cookie is missing or tampered
+func (ds *DoubleSubmit) Source() TokenSourceFunc {
+	return PairCookieTokenSource(ds.cookie_name, double_submit_separator, ds.key)
+}
+
+// Middleware issue new token and set it as signed cookie if the request does not carry one,
+// or on safe request if the token is no longer valid in the [TokenProvider], e.g. consumed by [SingleUse] validation or expired.
+// the token is stored in the request context for the next handler, see [ContextTokenSource]
+func (ds *DoubleSubmit) Middleware(next http.Handler) http.Handler {
+	source := ds.Source()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := source(r)
+		if token == "" || slices.Contains(ds.c.safe_methods, r.Method) && !ds.c.valid_token(r, token) {
+			var err error
+			token, err = ds.c.GetToken(ds.c.request_context(r))
+			if err != nil {
+				issue_error(w, err)
+				return
+			}
+
+			http.SetCookie(w, token_cookie(r, ds.cookie_name, SignTokenPair(token, double_submit_separator, ds.key), true))
+		}
+
+		next.ServeHTTP(w, r.WithContext(with_token(r.Context(), token)))
+	})
+}
+
+// Validate compare the token from the signed cookie against every `sources` in constant time
+// and then check it with the [TokenProvider]
+func (ds *DoubleSubmit) Validate(r *http.Request, sources ...TokenSourceFunc) error {
+	token := ds.Source()(r)
+	if token == "" {
+		return ErrTokenMissing
+	}
+
+	cookie := func(*http.Request) string { return token }
+	return ds.c.Validate(r, append([]TokenSourceFunc{cookie}, sources...)...)
+}
@@ -0,0 +1,92 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestDoubleSubmitRefreshConsumedToken(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}))
+	ds := c.DoubleSubmit("csrf", []byte("key"))
+
+	var token string
+	mw := ds.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		token = csrf.TokenFromContext(r.Context())
+	}))
+
+	serve := func(r *http.Request, jar *http.Cookie) *http.Cookie {
+		if jar != nil {
+			r.AddCookie(jar)
+		}
+
+		rec := httptest.NewRecorder()
+		mw.ServeHTTP(rec, r)
+		if set := cookie(rec, "csrf"); set != nil {
+			return set
+		}
+
+		return jar
+	}
+
+	jar := serve(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	if jar == nil {
+		t.Fatal("no cookie issued")
+	}
+
+	for i := range 3 {
+		if again := serve(httptest.NewRequest(http.MethodGet, "/", nil), jar); again != jar {
+			t.Fatalf("#%d: valid cookie was replaced", i)
+		}
+
+		r := post("/", token)
+		r.AddCookie(jar)
+		if err := ds.Validate(r, csrf.HeaderTokenSource); err != nil {
+			t.Fatalf("#%d: Validate = %v", i, err)
+		}
+
+		// the token is consumed, the next safe request must issue new one
+		if jar = serve(httptest.NewRequest(http.MethodGet, "/", nil), jar); jar.Value == "" {
+			t.Fatalf("#%d: no cookie", i)
+		}
+	}
+}
+
+func TestDoubleSubmitValidate(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithTokenMode(csrf.MultiUse))
+	ds := c.DoubleSubmit("csrf", []byte("key"))
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		cookie string
+		header string
+		want   error
+	}{
+		{"match", csrf.SignTokenPair(token, ".", []byte("key")), token, nil},
+		{"mismatch", csrf.SignTokenPair(token, ".", []byte("key")), "other", csrf.ErrInconsistentTokenBetweenSources},
+		{"tampered", token + ".bad", token, csrf.ErrTokenMissing},
+		{"wrong key", csrf.SignTokenPair(token, ".", []byte("other")), token, csrf.ErrTokenMissing},
+		{"no cookie", "", token, csrf.ErrTokenMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := post("/", tt.header)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "csrf", Value: tt.cookie})
+			}
+
+			if err := ds.Validate(r, csrf.HeaderTokenSource); !errors.Is(err, tt.want) {
+				t.Errorf("Validate = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
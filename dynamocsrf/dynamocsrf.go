@@ -0,0 +1,374 @@
+// Package dynamocsrf implements [csrf.TokenProvider] backed by DynamoDB
+package dynamocsrf
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bokunodev/csrf"
+)
+
+// API is the subset of [dynamodb.Client] used by [TokenProvider]
+type API interface {
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+// ErrSubjectRequired is returned by [TokenProvider.RevokeAll] of empty subject, tokens without subject are not indexed
+var ErrSubjectRequired = errors.New("dynamocsrf: tokens without subject can not be revoked")
+
+var _ API = (*dynamodb.Client)(nil)
+
+// Config for [New]
+type Config struct {
+	// Table name, default to `csrf_tokens`
+	Table string
+	// SubjectIndex is the name of the global secondary index used by [TokenProvider.RevokeAll], default to `subject-index`.
+	// its partition key is the `owner` string attribute and it must project the `subject` attribute
+	SubjectIndex string
+	// TTL of issued token, default to [csrf.DefaultTTL]
+	TTL time.Duration
+	// Generator of new token, default to [csrf.UUIDTokenGenerator]
+	Generator csrf.GenerateTokenFunc
+	// Clock default to [csrf.SystemClock]
+	Clock csrf.Clock
+}
+
+// TokenProvider store tokens in DynamoDB table with `token` string partition key.
+// time to live should be enabled on the `expire_at` attribute of the table for cleanup,
+// the expiry is checked on read since DynamoDB may take a while to delete expired items.
+// the `owner` attribute hold the subject without the bindings and the scope (see [csrf.SubjectOf]),
+// it is only set for tokens with subject so the subject index stay sparse
+type TokenProvider struct {
+	api       API
+	table     *string
+	index     *string
+	token_ttl time.Duration
+	generate  csrf.GenerateTokenFunc
+	clock     csrf.Clock
+}
+
+var (
+	_ csrf.TokenProvider    = (*TokenProvider)(nil)
+	_ csrf.Consumer         = (*TokenProvider)(nil)
+	_ csrf.IssuedAtProvider = (*TokenProvider)(nil)
+	_ csrf.TTLProvider      = (*TokenProvider)(nil)
+	_ csrf.HealthChecker    = (*TokenProvider)(nil)
+	_ csrf.Lister           = (*TokenProvider)(nil)
+	_ csrf.Revoker          = (*TokenProvider)(nil)
+	_ csrf.IDRevoker        = (*TokenProvider)(nil)
+)
+
+func New(api API, cfg Config) *TokenProvider {
+	if cfg.Table == "" {
+		cfg.Table = "csrf_tokens"
+	}
+
+	if cfg.SubjectIndex == "" {
+		cfg.SubjectIndex = "subject-index"
+	}
+
+	if cfg.TTL <= 0 {
+		cfg.TTL = csrf.DefaultTTL
+	}
+
+	if cfg.Generator == nil {
+		cfg.Generator = csrf.UUIDTokenGenerator
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = csrf.SystemClock{}
+	}
+
+	return &TokenProvider{
+		api:       api,
+		table:     aws.String(cfg.Table),
+		index:     aws.String(cfg.SubjectIndex),
+		token_ttl: cfg.TTL,
+		generate:  cfg.Generator,
+		clock:     cfg.Clock,
+	}
+}
+
+func number(n int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
+
+func key(token string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"token": &types.AttributeValueMemberS{Value: token}}
+}
+
+// Get issue token bound to the session id from context, see [csrf.WithSessionID]
+func (dtp *TokenProvider) Get(ctx context.Context) (string, error) {
+	token, err := csrf.GenerateToken(dtp.generate)
+	if err != nil {
+		return "", err
+	}
+
+	now := dtp.clock.Now()
+	subject := csrf.SessionIDFromContext(ctx)
+	item := map[string]types.AttributeValue{
+		"token":     &types.AttributeValueMemberS{Value: token},
+		"subject":   &types.AttributeValueMemberS{Value: subject},
+		"expire_at": number(now.Add(csrf.TTLFromContext(ctx, dtp.token_ttl)).Unix()),
+		"issued_at": number(now.UnixNano()),
+	}
+	if owner := csrf.SubjectOf(subject); owner != "" {
+		item["owner"] = &types.AttributeValueMemberS{Value: owner}
+	}
+
+	_, err = dtp.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           dtp.table,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(#token)"),
+		ExpressionAttributeNames: map[string]string{
+			"#token": "token",
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (dtp *TokenProvider) get(ctx context.Context, token string) (map[string]types.AttributeValue, error) {
+	out, err := dtp.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      dtp.table,
+		Key:            key(token),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, csrf.ErrTokenNotFound
+	}
+
+	return out.Item, nil
+}
+
+func int_attr(item map[string]types.AttributeValue, name string) int64 {
+	v, ok := item[name].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0
+	}
+
+	n, _ := strconv.ParseInt(v.Value, 10, 64)
+	return n
+}
+
+// Check return [csrf.ErrTokenNotFound] if there is no item bound to the session id from context
+// and [csrf.ErrTokenExpired] if the item is expired but not yet deleted
+func (dtp *TokenProvider) Check(ctx context.Context, token string) error {
+	item, err := dtp.get(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	subject, _ := item["subject"].(*types.AttributeValueMemberS)
+	if subject == nil || subtle.ConstantTimeCompare([]byte(subject.Value), []byte(csrf.SessionIDFromContext(ctx))) != 1 {
+		return csrf.ErrTokenNotFound
+	}
+
+	if !(dtp.clock.Now().Unix() < int_attr(item, "expire_at")) {
+		return csrf.ErrTokenExpired
+	}
+
+	return nil
+}
+
+// CheckAndConsume delete the token with conditional DeleteItem,
+// [csrf.ErrTokenNotFound] is returned if no unexpired item bound to the session id from context was deleted
+func (dtp *TokenProvider) CheckAndConsume(ctx context.Context, token string) error {
+	_, err := dtp.api.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           dtp.table,
+		Key:                 key(token),
+		ConditionExpression: aws.String("attribute_exists(#token) AND #subject = :subject AND #expire_at > :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#token":     "token",
+			"#subject":   "subject",
+			"#expire_at": "expire_at",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":subject": &types.AttributeValueMemberS{Value: csrf.SessionIDFromContext(ctx)},
+			":now":     number(dtp.clock.Now().Unix()),
+		},
+	})
+
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return csrf.ErrTokenNotFound
+	}
+
+	return err
+}
+
+func (dtp *TokenProvider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	item, err := dtp.get(ctx, token)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(0, int_attr(item, "issued_at")), nil
+}
+
+func (dtp *TokenProvider) TTL() time.Duration { return dtp.token_ttl }
+
+// Ping read nonexistent item, which verify both the table and the permissions
+func (dtp *TokenProvider) Ping(ctx context.Context) error {
+	_, err := dtp.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: dtp.table,
+		Key:       key("\x00ping"),
+	})
+	return err
+}
+
+// List scan the whole table, the scan order follow the raw tokens so every page read every item
+// and sort the ones after the cursor by [csrf.TokenID]. it consume read capacity of the whole table
+// and is meant for ops tooling
+func (dtp *TokenProvider) List(ctx context.Context, cursor string, limit int) ([]csrf.TokenInfo, string, error) {
+	if limit <= 0 {
+		return nil, "", nil
+	}
+
+	now := dtp.clock.Now().Unix()
+	var tokens []csrf.TokenInfo
+	err := dtp.scan(ctx, func(item map[string]types.AttributeValue) error {
+		token, _ := item["token"].(*types.AttributeValueMemberS)
+		subject, _ := item["subject"].(*types.AttributeValueMemberS)
+		expire_at := int_attr(item, "expire_at")
+		if token == nil || subject == nil || expire_at <= now {
+			return nil
+		}
+
+		if id := csrf.TokenID(token.Value); id > cursor {
+			tokens = append(tokens, csrf.TokenInfo{
+				ID:       id,
+				Subject:  subject.Value,
+				IssuedAt: time.Unix(0, int_attr(item, "issued_at")),
+				ExpireAt: time.Unix(expire_at, 0),
+			})
+		}
+
+		return nil
+	}, "token", "subject", "expire_at", "issued_at")
+	if err != nil {
+		return nil, "", err
+	}
+
+	slices.SortFunc(tokens, func(a, b csrf.TokenInfo) int { return strings.Compare(a.ID, b.ID) })
+	tokens = tokens[:min(limit, len(tokens))]
+	return tokens, csrf.NextCursor(tokens, limit), nil
+}
+
+// RevokeID scan the whole table like List to find the token with [csrf.TokenID] `id`
+func (dtp *TokenProvider) RevokeID(ctx context.Context, id string) error {
+	return dtp.scan(ctx, func(item map[string]types.AttributeValue) error {
+		token, _ := item["token"].(*types.AttributeValueMemberS)
+		if token == nil || csrf.TokenID(token.Value) != id {
+			return nil
+		}
+
+		_, err := dtp.api.DeleteItem(ctx, &dynamodb.DeleteItemInput{TableName: dtp.table, Key: key(token.Value)})
+		return err
+	}, "token")
+}
+
+// scan call `fn` for every item of the table with only the attributes `attrs`
+func (dtp *TokenProvider) scan(ctx context.Context, fn func(item map[string]types.AttributeValue) error, attrs ...string) error {
+	names := make(map[string]string, len(attrs))
+	projection := make([]string, len(attrs))
+	for i, attr := range attrs {
+		names["#"+attr] = attr
+		projection[i] = "#" + attr
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:                dtp.table,
+		ProjectionExpression:     aws.String(strings.Join(projection, ", ")),
+		ExpressionAttributeNames: names,
+		ConsistentRead:           aws.Bool(true),
+	}
+
+	for {
+		out, err := dtp.api.Scan(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range out.Items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		if len(out.LastEvaluatedKey) == 0 {
+			return nil
+		}
+
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// RevokeAll query the subject index and delete every token matching `subject` (see [csrf.MatchSubject]).
+// the index is eventually consistent, token issued just before the call may survive
+func (dtp *TokenProvider) RevokeAll(ctx context.Context, subject string) error {
+	owner := csrf.SubjectOf(subject)
+	if owner == "" {
+		return ErrSubjectRequired
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              dtp.table,
+		IndexName:              dtp.index,
+		KeyConditionExpression: aws.String("#owner = :owner"),
+		ProjectionExpression:   aws.String("#token, #subject"),
+		ExpressionAttributeNames: map[string]string{
+			"#owner":   "owner",
+			"#token":   "token",
+			"#subject": "subject",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":owner": &types.AttributeValueMemberS{Value: owner},
+		},
+	}
+
+	for {
+		out, err := dtp.api.Query(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range out.Items {
+			token, _ := item["token"].(*types.AttributeValueMemberS)
+			session_id, _ := item["subject"].(*types.AttributeValueMemberS)
+			if token == nil || session_id == nil || !csrf.MatchSubject(session_id.Value, subject) {
+				continue
+			}
+
+			_, err := dtp.api.DeleteItem(ctx, &dynamodb.DeleteItemInput{TableName: dtp.table, Key: key(token.Value)})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(out.LastEvaluatedKey) == 0 {
+			return nil
+		}
+
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
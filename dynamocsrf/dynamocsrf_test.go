@@ -0,0 +1,180 @@
+package dynamocsrf_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/dynamocsrf"
+)
+
+// fake_api keep the items in memory, Query return one item per page of the subject index
+type fake_api struct {
+	dynamocsrf.API
+	items   map[string]map[string]types.AttributeValue
+	queries int
+}
+
+func str(item map[string]types.AttributeValue, name string) string {
+	v, _ := item[name].(*types.AttributeValueMemberS)
+	if v == nil {
+		return ""
+	}
+
+	return v.Value
+}
+
+func (api *fake_api) PutItem(_ context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	api.items[str(in.Item, "token")] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (api *fake_api) DeleteItem(_ context.Context, in *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	delete(api.items, str(in.Key, "token"))
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (api *fake_api) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	api.queries++
+	owner := str(in.ExpressionAttributeValues, ":owner")
+
+	var tokens []string
+	for token, item := range api.items {
+		if str(item, "owner") == owner && token > str(in.ExclusiveStartKey, "token") {
+			tokens = append(tokens, token)
+		}
+	}
+
+	if len(tokens) == 0 {
+		return &dynamodb.QueryOutput{}, nil
+	}
+
+	slices.Sort(tokens)
+	item := api.items[tokens[0]]
+	return &dynamodb.QueryOutput{
+		Items:            []map[string]types.AttributeValue{item},
+		LastEvaluatedKey: map[string]types.AttributeValue{"token": item["token"]},
+	}, nil
+}
+
+// Scan return two items per page in token order
+func (api *fake_api) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	var tokens []string
+	for token := range api.items {
+		if token > str(in.ExclusiveStartKey, "token") {
+			tokens = append(tokens, token)
+		}
+	}
+
+	slices.Sort(tokens)
+	out := &dynamodb.ScanOutput{}
+	for _, token := range tokens[:min(2, len(tokens))] {
+		out.Items = append(out.Items, api.items[token])
+		out.LastEvaluatedKey = map[string]types.AttributeValue{"token": api.items[token]["token"]}
+	}
+
+	return out, nil
+}
+
+func TestList(t *testing.T) {
+	api := &fake_api{items: make(map[string]map[string]types.AttributeValue)}
+	dtp := dynamocsrf.New(api, dynamocsrf.Config{})
+	ctx := csrf.WithSessionID(context.Background(), "alice")
+
+	var tokens, want []string
+	for range 5 {
+		token, err := dtp.Get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokens = append(tokens, token)
+		want = append(want, csrf.TokenID(token))
+	}
+	slices.Sort(want)
+
+	var got []string
+	for cursor := ""; ; {
+		page, next, err := dtp.List(ctx, cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, info := range page {
+			got = append(got, info.ID)
+		}
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("listed %v, want %v", got, want)
+	}
+
+	if err := dtp.RevokeID(ctx, csrf.TokenID(tokens[0])); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found := api.items[tokens[0]]; found {
+		t.Error("token not revoked")
+	}
+
+	if len(api.items) != len(tokens)-1 {
+		t.Errorf("tokens after RevokeID = %d, want %d", len(api.items), len(tokens)-1)
+	}
+}
+
+func TestRevokeAll(t *testing.T) {
+	api := &fake_api{items: make(map[string]map[string]types.AttributeValue)}
+	dtp := dynamocsrf.New(api, dynamocsrf.Config{})
+	ctx := context.Background()
+
+	issue := func(session_id string) string {
+		t.Helper()
+
+		token, err := dtp.Get(csrf.WithSessionID(ctx, session_id))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	}
+
+	revoked := []string{issue("alice"), issue("alice\x00192.0.2.1"), issue("alice\x01/delete")}
+	kept := []string{issue("bob"), issue("alice2"), issue("")}
+
+	if err := dtp.RevokeAll(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range revoked {
+		if _, found := api.items[token]; found {
+			t.Errorf("token of %q not revoked", str(api.items[token], "subject"))
+		}
+	}
+
+	for _, token := range kept {
+		if _, found := api.items[token]; !found {
+			t.Errorf("token %s revoked", token)
+		}
+	}
+
+	if api.queries != len(revoked)+1 {
+		t.Errorf("queries = %d, want %d", api.queries, len(revoked)+1)
+	}
+
+	if _, found := api.items[kept[2]]["owner"]; found {
+		t.Error("token without subject has owner")
+	}
+
+	if err := dtp.RevokeAll(ctx, ""); !errors.Is(err, dynamocsrf.ErrSubjectRequired) {
+		t.Errorf("RevokeAll of empty subject = %v, want %v", err, dynamocsrf.ErrSubjectRequired)
+	}
+}
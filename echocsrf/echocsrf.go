@@ -0,0 +1,77 @@
+// Package echocsrf adapt [csrf.CSRF] to echo, following the conventions of echo's built-in csrf middleware
+package echocsrf
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bokunodev/csrf"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// Config for [Middleware]
+type Config struct {
+	// Skipper default to [middleware.DefaultSkipper]
+	Skipper middleware.Skipper
+	// Sources of the token, default to [csrf.CSRF.HeaderSource]
+	Sources []csrf.TokenSourceFunc
+	// Expose the issued token in addition to the echo context, see [csrf.TokenExposure]
+	Expose csrf.TokenExposure
+	// ContextKey of the issued token in echo context, default to `csrf` as echo's built-in middleware
+	ContextKey string
+	// ErrorHandler return the error passed to echo's HTTPErrorHandler, default to [DefaultErrorHandler]
+	ErrorHandler func(err error, ctx echo.Context) error
+}
+
+// DefaultErrorHandler return 400 Bad Request for missing token and 403 Forbidden otherwise,
+// the same as echo's built-in middleware
+func DefaultErrorHandler(err error, _ echo.Context) error {
+	if errors.Is(err, csrf.ErrTokenMissing) {
+		return echo.NewHTTPError(http.StatusBadRequest, "missing csrf token").SetInternal(err)
+	}
+
+	return echo.NewHTTPError(http.StatusForbidden, "invalid csrf token").SetInternal(err)
+}
+
+// Middleware validate unsafe request with [csrf.CSRF.Protect], then store token for the handler
+// as `ctx.Get("csrf")`, which make it drop-in replacement of echo's built-in middleware.
+// safe request reuse the token the client carry back while it is valid, see [csrf.CSRF.TokenMiddleware]
+func Middleware(c *csrf.CSRF, cfg Config) echo.MiddlewareFunc {
+	if cfg.Skipper == nil {
+		cfg.Skipper = middleware.DefaultSkipper
+	}
+
+	if len(cfg.Sources) == 0 {
+		cfg.Sources = []csrf.TokenSourceFunc{c.HeaderSource()}
+	}
+
+	if cfg.ContextKey == "" {
+		cfg.ContextKey = "csrf"
+	}
+
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = DefaultErrorHandler
+	}
+
+	issue := c.TokenMiddleware(cfg.Expose)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if cfg.Skipper(ctx) {
+				return next(ctx)
+			}
+
+			var err error
+			c.Protect(func(_ http.ResponseWriter, _ *http.Request, verr error) bool {
+				err = cfg.ErrorHandler(verr, ctx)
+				return false
+			}, cfg.Sources...)(issue(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				ctx.SetRequest(r)
+				ctx.Set(cfg.ContextKey, csrf.TokenFromContext(r.Context()))
+				err = next(ctx)
+			}))).ServeHTTP(ctx.Response(), ctx.Request())
+
+			return err
+		}
+	}
+}
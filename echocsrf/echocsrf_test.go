@@ -0,0 +1,75 @@
+package echocsrf_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/echocsrf"
+	"github.com/labstack/echo/v4"
+)
+
+func TestMiddleware(t *testing.T) {
+	tp := csrf.NewDefaultTokenProviderConfig(context.Background(), csrf.Config{})
+	t.Cleanup(func() { tp.Close() })
+	c := csrf.New(tp)
+
+	e := echo.New()
+	e.Use(echocsrf.Middleware(c, echocsrf.Config{Expose: csrf.ExposeCookie}))
+	handler := func(ctx echo.Context) error {
+		return ctx.String(http.StatusOK, ctx.Get("csrf").(string))
+	}
+	e.GET("/", handler)
+	e.POST("/", handler)
+
+	serve := func(method, cookie, header string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/", nil)
+		if cookie != "" {
+			r.AddCookie(&http.Cookie{Name: csrf.DefaultCookieName, Value: cookie})
+		}
+
+		if header != "" {
+			r.Header.Set(csrf.DefaultHeaderName, header)
+		}
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, r)
+		return rec
+	}
+
+	first := serve(http.MethodGet, "", "").Body.String()
+	if first == "" {
+		t.Fatal("no token issued")
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		status int
+		reuse  bool
+	}{
+		{"navigation", http.MethodGet, http.StatusOK, true},
+		{"asset", http.MethodGet, http.StatusOK, true},
+		{"submit", http.MethodPost, http.StatusOK, false},
+		{"replay", http.MethodPost, http.StatusForbidden, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.method, first, first)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if tt.status == http.StatusOK && (rec.Body.String() == first) != tt.reuse {
+				t.Errorf("token = %q, reuse of %q = %v", rec.Body.String(), first, tt.reuse)
+			}
+		})
+	}
+
+	if issued := tp.Stats().Issued; issued != 2 {
+		t.Errorf("issued = %d, want 2", issued)
+	}
+}
@@ -0,0 +1,210 @@
+package csrf
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// Claims embedded in token of [EncryptedTokenProvider]
+type Claims struct {
+	IssuedAt time.Time
+	ExpireAt time.Time
+	// Subject is the session id from context at issuance, see [WithSessionID]
+	Subject string
+}
+
+// EncryptedTokenProvider is stateless [TokenProvider] where the token is key id followed by AES-GCM encrypted [Claims],
+// the claims are confidential to the client and the subject must match the session id from context on Check.
+// like [HMACTokenProvider] the token can not be consumed and stay valid until expired
+type EncryptedTokenProvider struct {
+	aeads     keyring[cipher.AEAD]
+	token_ttl time.Duration
+	clock     Clock
+}
+
+// EncryptedConfig for [NewEncryptedTokenProviderConfig]
+type EncryptedConfig struct {
+	// Keys are 16, 24 or 32 bytes AES keys, new tokens are encrypted with the first key, at least one is required
+	Keys [][]byte
+	// TTL of issued token, default to [DefaultTTL]
+	TTL time.Duration
+	// Clock default to [SystemClock]
+	Clock Clock
+}
+
+var (
+	_ TokenProvider    = (*EncryptedTokenProvider)(nil)
+	_ IssuedAtProvider = (*EncryptedTokenProvider)(nil)
+	_ TTLProvider      = (*EncryptedTokenProvider)(nil)
+)
+
+// NewEncryptedTokenProvider return [EncryptedTokenProvider], `keys` must be 16, 24 or 32 bytes AES key.
+// new tokens are encrypted with the first key, the rest are only used to decrypt so the keys can be rotated
+// by prepending new key and dropping the oldest once its tokens have expired, see also [EncryptedTokenProvider.RotateKey]
+func NewEncryptedTokenProvider(token_ttl time.Duration, keys ...[]byte) (*EncryptedTokenProvider, error) {
+	return NewEncryptedTokenProviderConfig(EncryptedConfig{Keys: keys, TTL: token_ttl})
+}
+
+// NewEncryptedTokenProviderConfig return [EncryptedTokenProvider] configured by `cfg`, see [NewEncryptedTokenProvider]
+func NewEncryptedTokenProviderConfig(cfg EncryptedConfig) (*EncryptedTokenProvider, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock{}
+	}
+
+	etp := &EncryptedTokenProvider{token_ttl: cfg.TTL, clock: cfg.Clock}
+	for _, key := range cfg.Keys {
+		e, err := new_aead_entry(key)
+		if err != nil {
+			return nil, err
+		}
+
+		etp.aeads.keys = append(etp.aeads.keys, e)
+	}
+
+	return etp, nil
+}
+
+func new_aead_entry(key []byte) (keyring_entry[cipher.AEAD], error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return keyring_entry[cipher.AEAD]{}, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return keyring_entry[cipher.AEAD]{}, err
+	}
+
+	return keyring_entry[cipher.AEAD]{id: derive_key_id(key), key: aead}, nil
+}
+
+func (etp *EncryptedTokenProvider) RotateKey(key []byte) error {
+	e, err := new_aead_entry(key)
+	if err != nil {
+		return err
+	}
+
+	etp.aeads.rotate(e)
+	return nil
+}
+
+func (etp *EncryptedTokenProvider) Get(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	now := etp.clock.Now()
+	plaintext := make([]byte, 16, 16+len(SessionIDFromContext(ctx)))
+	binary.BigEndian.PutUint64(plaintext, uint64(now.Unix()))
+	binary.BigEndian.PutUint64(plaintext[8:], uint64(now.Add(TTLFromContext(ctx, etp.token_ttl)).Unix()))
+	plaintext = append(plaintext, SessionIDFromContext(ctx)...)
+
+	current := etp.aeads.current()
+	aead := current.key
+	buf := make([]byte, key_id_size+aead.NonceSize(), key_id_size+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	copy(buf, current.id[:])
+	nonce := buf[key_id_size:]
+	if _, err := rand.Read(nonce); err != nil {
+		return "", entropy_error(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(buf, nonce, plaintext, current.id[:])), nil
+}
+
+// Claims decrypt the token, it does not check the expiry nor the subject
+func (etp *EncryptedTokenProvider) Claims(token string) (Claims, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if len(buf) < key_id_size {
+		return Claims{}, ErrInvalidToken
+	}
+
+	aead, ok := etp.aeads.lookup(buf[:key_id_size])
+	if !ok {
+		return Claims{}, ErrInvalidToken
+	}
+
+	claims, ok := open_claims(aead, buf[key_id_size:], buf[:key_id_size])
+	if !ok {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// open_claims decrypt `buf` in the form of `nonce || ciphertext`
+func open_claims(aead cipher.AEAD, buf, additional_data []byte) (Claims, bool) {
+	if len(buf) < aead.NonceSize() {
+		return Claims{}, false
+	}
+
+	plaintext, err := aead.Open(nil, buf[:aead.NonceSize()], buf[aead.NonceSize():], additional_data)
+	if err != nil || len(plaintext) < 16 {
+		return Claims{}, false
+	}
+
+	return Claims{
+		IssuedAt: time.Unix(int64(binary.BigEndian.Uint64(plaintext)), 0),
+		ExpireAt: time.Unix(int64(binary.BigEndian.Uint64(plaintext[8:])), 0),
+		Subject:  string(plaintext[16:]),
+	}, true
+}
+
+func (etp *EncryptedTokenProvider) verify(ctx context.Context, token string) (Claims, error) {
+	if err := ctx.Err(); err != nil {
+		return Claims{}, err
+	}
+
+	claims, err := etp.Claims(token)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.Subject), []byte(SessionIDFromContext(ctx))) != 1 {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func (etp *EncryptedTokenProvider) Check(ctx context.Context, token string) error {
+	claims, err := etp.verify(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if !etp.clock.Now().Before(claims.ExpireAt) {
+		return ErrTokenExpired
+	}
+
+	return nil
+}
+
+func (etp *EncryptedTokenProvider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	claims, err := etp.verify(ctx, token)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return claims.IssuedAt, nil
+}
+
+func (etp *EncryptedTokenProvider) TTL() time.Duration { return etp.token_ttl }
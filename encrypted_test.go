@@ -0,0 +1,98 @@
+package csrf_test
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+var (
+	aes_key  = []byte("0123456789abcdef0123456789abcdef")
+	aes_key2 = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func TestEncryptedTokenProvider(t *testing.T) {
+	clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+	etp, err := csrf.NewEncryptedTokenProviderConfig(csrf.EncryptedConfig{Keys: [][]byte{aes_key}, TTL: time.Minute, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice := csrf.WithSessionID(context.Background(), "alice")
+	token := must_get(t, etp, alice)
+
+	claims, err := etp.Claims(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := csrf.Claims{IssuedAt: clock.Now(), ExpireAt: clock.Now().Add(time.Minute), Subject: "alice"}
+	if claims != want {
+		t.Errorf("Claims = %+v, want %+v", claims, want)
+	}
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		ctx     context.Context
+		err     error
+	}{
+		{"valid", 0, alice, nil},
+		{"other subject", 0, context.Background(), csrf.ErrInvalidToken},
+		{"before expiry", 59 * time.Second, alice, nil},
+		{"expired", time.Second, alice, csrf.ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		if err := etp.Check(tt.ctx, token); !errors.Is(err, tt.err) {
+			t.Errorf("Check %s = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestEncryptedTokenProviderKeys(t *testing.T) {
+	ctx := context.Background()
+	etp, err := csrf.NewEncryptedTokenProvider(0, aes_key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if etp.TTL() != csrf.DefaultTTL {
+		t.Errorf("TTL = %v, want %v", etp.TTL(), csrf.DefaultTTL)
+	}
+
+	old := must_get(t, etp, ctx)
+	if err := etp.RotateKey(aes_key2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := etp.Check(ctx, old); err != nil {
+		t.Errorf("Check of token of previous key = %v", err)
+	}
+
+	if err := etp.Check(ctx, must_get(t, etp, ctx)); err != nil {
+		t.Errorf("Check of token of current key = %v", err)
+	}
+
+	// `nonce || ciphertext` without key id, as issued before key rotation was supported
+	block, _ := aes.NewCipher(aes_key)
+	aead, _ := cipher.NewGCM(block)
+	plaintext := binary.BigEndian.AppendUint64(binary.BigEndian.AppendUint64(nil, uint64(time.Now().Unix())), uint64(time.Now().Add(time.Hour).Unix()))
+	nonce := make([]byte, aead.NonceSize())
+	legacy := base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil))
+
+	for _, token := range []string{legacy, "", "AAAA", "not base64!"} {
+		if err := etp.Check(ctx, token); !errors.Is(err, csrf.ErrInvalidToken) {
+			t.Errorf("Check(%q) = %v, want %v", token, err, csrf.ErrInvalidToken)
+		}
+	}
+}
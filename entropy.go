@@ -0,0 +1,34 @@
+package csrf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrEntropy returned when the system entropy source fail to produce random bytes for new token or mask
+var ErrEntropy = errors.New("system entropy source failed")
+
+// entropy_failure is the panic value of the generators of this package, recovered by [GenerateToken]
+type entropy_failure struct{ err error }
+
+// entropy_error wrap error of crypto/rand with [ErrEntropy]
+func entropy_error(err error) error {
+	return fmt.Errorf("%w: %w", ErrEntropy, err)
+}
+
+// GenerateToken call `generate` and return error wrapping [ErrEntropy] instead of panicking when generator of this package
+// fail to read the system entropy source, so the providers can report it as error. any other panic is propagated
+func GenerateToken(generate GenerateTokenFunc) (token string, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			failure, ok := v.(entropy_failure)
+			if !ok {
+				panic(v)
+			}
+
+			err = entropy_error(failure.err)
+		}
+	}()
+
+	return generate(), nil
+}
@@ -0,0 +1,99 @@
+package csrf
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+)
+
+// ErrorPage is the template rendered by [DefaultErrorHandler], it is executed with [ErrorPageData]
+var ErrorPage = template.Must(template.New("csrf").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Status}} {{.StatusText}}</title>
+</head>
+<body>
+<h1>{{.StatusText}}</h1>
+<p>{{.Message}}</p>
+</body>
+</html>
+`))
+
+// ErrorPageData is passed to the template of [HTMLErrorHandler]
+type ErrorPageData struct {
+	Status     int
+	StatusText string
+	// Reason is [FailureReason] of the error
+	Reason string
+	// Message is [FailureMessage] of the error
+	Message string
+}
+
+// FailureMessage return human readable description of validation error which is safe to show to the user
+func FailureMessage(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrTokenMissing):
+		return "The form is missing its security token. Please reload the page and try again."
+	case errors.Is(err, ErrTokenExpired):
+		return "The form has expired. Please reload the page and try again."
+	case errors.Is(err, ErrTokenNotFound), errors.Is(err, ErrInconsistentTokenBetweenSources),
+		errors.Is(err, ErrTokenMalformed), errors.Is(err, ErrInvalidToken):
+		return "The security token of the form is invalid. Please reload the page and try again."
+	case errors.Is(err, ErrContentTypeNotAllowed):
+		return "The request content type is not allowed."
+	case errors.Is(err, ErrCustomHeaderMissing):
+		return "The request is missing a required header."
+	case errors.Is(err, ErrOriginNotAllowed), errors.Is(err, ErrCrossSiteRequest):
+		return "Cross-site requests are not allowed."
+	}
+
+	return "The request could not be verified. Please try again later."
+}
+
+// HTMLErrorHandler return [ErrorHandlerFunc] which respond with 403 Forbidden rendered from `tmpl` if `err` is not nil
+func HTMLErrorHandler(tmpl *template.Template) ErrorHandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request, err error) {
+		if err == nil {
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusForbidden)
+		_ = tmpl.Execute(w, ErrorPageData{
+			Status:     http.StatusForbidden,
+			StatusText: http.StatusText(http.StatusForbidden),
+			Reason:     FailureReason(err),
+			Message:    FailureMessage(err),
+		})
+	}
+}
+
+// DefaultErrorHandler respond with 403 Forbidden rendered from [ErrorPage] if `err` is not nil
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	HTMLErrorHandler(ErrorPage)(w, r, err)
+}
+
+// JSONErrorHandler respond with 403 Forbidden and `{"error":"csrf_token_invalid","reason":"...","message":"..."}`
+// if `err` is not nil, the reason is [FailureReason] and the message is [FailureMessage] of `err`
+func JSONErrorHandler(w http.ResponseWriter, _ *http.Request, err error) {
+	if err == nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusForbidden)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":   "csrf_token_invalid",
+		"reason":  FailureReason(err),
+		"message": FailureMessage(err),
+	})
+}
@@ -0,0 +1,310 @@
+// Package etcdcsrf implements [csrf.TokenProvider] backed by etcd leases
+package etcdcsrf
+
+import (
+	"context"
+	"crypto/subtle"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Config for [New]
+type Config struct {
+	// Prefix of the keys, default to `csrf/`
+	Prefix string
+	// TTL of issued token, default to [csrf.DefaultTTL]. it is rounded up to second for the lease
+	TTL time.Duration
+	// Generator of new token, default to [csrf.UUIDTokenGenerator]
+	Generator csrf.GenerateTokenFunc
+	// Clock of the issue time, default to [csrf.SystemClock]. the expiry is enforced by the etcd lease
+	Clock csrf.Clock
+	// PageSize is the number of keys read at once by RevokeAll, RevokeID and List, default to [csrf.DefaultGCBatchSize]
+	PageSize int
+}
+
+// TokenProvider store each token as key attached to its own lease, etcd remove the key when the lease expire.
+// the value is `issued_at:subject`
+type TokenProvider struct {
+	client    *clientv3.Client
+	prefix    string
+	token_ttl time.Duration
+	generate  csrf.GenerateTokenFunc
+	clock     csrf.Clock
+	page_size int
+}
+
+var (
+	_ csrf.TokenProvider    = (*TokenProvider)(nil)
+	_ csrf.Consumer         = (*TokenProvider)(nil)
+	_ csrf.IssuedAtProvider = (*TokenProvider)(nil)
+	_ csrf.TTLProvider      = (*TokenProvider)(nil)
+	_ csrf.HealthChecker    = (*TokenProvider)(nil)
+	_ csrf.Revoker          = (*TokenProvider)(nil)
+	_ csrf.Lister           = (*TokenProvider)(nil)
+	_ csrf.IDRevoker        = (*TokenProvider)(nil)
+)
+
+// New return [TokenProvider] using `client`, the client is owned by the caller
+func New(client *clientv3.Client, cfg Config) *TokenProvider {
+	if cfg.Prefix == "" {
+		cfg.Prefix = "csrf/"
+	}
+
+	if cfg.TTL <= 0 {
+		cfg.TTL = csrf.DefaultTTL
+	}
+
+	if cfg.Generator == nil {
+		cfg.Generator = csrf.UUIDTokenGenerator
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = csrf.SystemClock{}
+	}
+
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = csrf.DefaultGCBatchSize
+	}
+
+	return &TokenProvider{
+		client:    client,
+		prefix:    cfg.Prefix,
+		token_ttl: cfg.TTL,
+		generate:  cfg.Generator,
+		clock:     cfg.Clock,
+		page_size: cfg.PageSize,
+	}
+}
+
+func encode(issued_at time.Time, subject string) string {
+	return strconv.FormatInt(issued_at.UnixNano(), 10) + ":" + subject
+}
+
+func decode(value []byte) (time.Time, string, bool) {
+	issued_at, subject, ok := strings.Cut(string(value), ":")
+	if !ok {
+		return time.Time{}, "", false
+	}
+
+	ns, err := strconv.ParseInt(issued_at, 10, 64)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+
+	return time.Unix(0, ns), subject, true
+}
+
+// Get issue token bound to the session id from context, see [csrf.WithSessionID]
+func (etp *TokenProvider) Get(ctx context.Context) (string, error) {
+	// the token is generated first so a failure does not leak the lease
+	token, err := csrf.GenerateToken(etp.generate)
+	if err != nil {
+		return "", err
+	}
+
+	lease, err := etp.client.Grant(ctx, int64((csrf.TTLFromContext(ctx, etp.token_ttl)+time.Second-1)/time.Second))
+	if err != nil {
+		return "", err
+	}
+
+	_, err = etp.client.Put(ctx, etp.prefix+token, encode(etp.clock.Now(), csrf.SessionIDFromContext(ctx)), clientv3.WithLease(lease.ID))
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// lookup return the revision of the key bound to the session id from context
+func (etp *TokenProvider) lookup(ctx context.Context, token string) (int64, time.Time, error) {
+	resp, err := etp.client.Get(ctx, etp.prefix+token)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	if len(resp.Kvs) == 0 {
+		return 0, time.Time{}, csrf.ErrTokenNotFound
+	}
+
+	kv := resp.Kvs[0]
+	issued_at, subject, ok := decode(kv.Value)
+	if !ok || subtle.ConstantTimeCompare([]byte(subject), []byte(csrf.SessionIDFromContext(ctx))) != 1 {
+		return 0, time.Time{}, csrf.ErrTokenNotFound
+	}
+
+	return kv.ModRevision, issued_at, nil
+}
+
+// Check return [csrf.ErrTokenNotFound] if there is no key bound to the session id from context
+func (etp *TokenProvider) Check(ctx context.Context, token string) error {
+	_, _, err := etp.lookup(ctx, token)
+	return err
+}
+
+// CheckAndConsume delete the key in transaction conditioned on its revision,
+// so concurrent consumers of the same token can not both succeed
+func (etp *TokenProvider) CheckAndConsume(ctx context.Context, token string) error {
+	rev, _, err := etp.lookup(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	key := etp.prefix + token
+	resp, err := etp.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", rev)).
+		Then(clientv3.OpDelete(key)).
+		Commit()
+	if err != nil {
+		return err
+	}
+
+	if !resp.Succeeded {
+		return csrf.ErrTokenNotFound
+	}
+
+	return nil
+}
+
+func (etp *TokenProvider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	resp, err := etp.client.Get(ctx, etp.prefix+token)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if len(resp.Kvs) == 0 {
+		return time.Time{}, csrf.ErrTokenNotFound
+	}
+
+	issued_at, _, ok := decode(resp.Kvs[0].Value)
+	if !ok {
+		return time.Time{}, csrf.ErrTokenNotFound
+	}
+
+	return issued_at, nil
+}
+
+func (etp *TokenProvider) TTL() time.Duration { return etp.token_ttl }
+
+// RevokeAll scan every key under the prefix in pages of [Config.PageSize] keys,
+// it is meant for rare events like logout or password change
+func (etp *TokenProvider) RevokeAll(ctx context.Context, subject string) error {
+	return etp.walk(ctx, func(kv *mvccpb.KeyValue) error {
+		if _, s, ok := decode(kv.Value); ok && csrf.MatchSubject(s, subject) {
+			_, err := etp.client.Delete(ctx, string(kv.Key))
+			return err
+		}
+
+		return nil
+	})
+}
+
+// RevokeID scan every key under the prefix like RevokeAll to find the token with [csrf.TokenID] `id`
+func (etp *TokenProvider) RevokeID(ctx context.Context, id string) error {
+	return etp.walk(ctx, func(kv *mvccpb.KeyValue) error {
+		if csrf.TokenID(strings.TrimPrefix(string(kv.Key), etp.prefix)) == id {
+			_, err := etp.client.Delete(ctx, string(kv.Key))
+			return err
+		}
+
+		return nil
+	})
+}
+
+// walk call `fn` for every key under the prefix, read in key order in pages of [Config.PageSize] keys
+func (etp *TokenProvider) walk(ctx context.Context, fn func(kv *mvccpb.KeyValue) error) error {
+	start, end := etp.prefix, clientv3.GetPrefixRangeEnd(etp.prefix)
+	for {
+		resp, err := etp.client.Get(ctx, start,
+			clientv3.WithRange(end),
+			clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend),
+			clientv3.WithLimit(int64(etp.page_size)),
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, kv := range resp.Kvs {
+			if err := fn(kv); err != nil {
+				return err
+			}
+		}
+
+		if !resp.More || len(resp.Kvs) == 0 {
+			return nil
+		}
+
+		// the smallest key after the last one of the page
+		start = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+}
+
+// Ping read nonexistent key with linearizable read, which require quorum of the cluster
+func (etp *TokenProvider) Ping(ctx context.Context) error {
+	_, err := etp.client.Get(ctx, etp.prefix+"\x00ping", clientv3.WithCountOnly())
+	return err
+}
+
+// List scan every key under the prefix since the keys are in token order, the tokens after the cursor are sorted
+// by [csrf.TokenID]. the expiry is the remaining time to live of each token lease, a page may hold less than `limit`
+// tokens while `next` is not empty since the leases expired after the scan are skipped
+func (etp *TokenProvider) List(ctx context.Context, cursor string, limit int) ([]csrf.TokenInfo, string, error) {
+	if limit <= 0 {
+		return nil, "", nil
+	}
+
+	type listed struct {
+		id string
+		kv *mvccpb.KeyValue
+	}
+
+	var found []listed
+	err := etp.walk(ctx, func(kv *mvccpb.KeyValue) error {
+		if id := csrf.TokenID(strings.TrimPrefix(string(kv.Key), etp.prefix)); id > cursor {
+			found = append(found, listed{id, kv})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	slices.SortFunc(found, func(a, b listed) int { return strings.Compare(a.id, b.id) })
+
+	next := ""
+	if len(found) > limit {
+		found, next = found[:limit], found[limit-1].id
+	}
+
+	tokens := make([]csrf.TokenInfo, 0, len(found))
+	for _, f := range found {
+		issued_at, subject, ok := decode(f.kv.Value)
+		if !ok {
+			continue
+		}
+
+		lease, err := etp.client.TimeToLive(ctx, clientv3.LeaseID(f.kv.Lease))
+		if err != nil {
+			return nil, "", err
+		}
+
+		if lease.TTL <= 0 {
+			continue
+		}
+
+		tokens = append(tokens, csrf.TokenInfo{
+			ID:       f.id,
+			Subject:  subject,
+			IssuedAt: issued_at,
+			ExpireAt: etp.clock.Now().Add(time.Duration(lease.TTL) * time.Second),
+		})
+	}
+
+	return tokens, next, nil
+}
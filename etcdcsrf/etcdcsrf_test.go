@@ -0,0 +1,289 @@
+package etcdcsrf_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+	"github.com/bokunodev/csrf/etcdcsrf"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fake_etcd keep the keys and leases in memory, it implements the calls made by [etcdcsrf.TokenProvider].
+// the options of the calls are opaque, so range read return the whole range at once
+// and put attach the last granted lease, which is what Get does
+type fake_etcd struct {
+	clientv3.KV
+	clientv3.Lease
+
+	mu     sync.Mutex
+	rev    int64
+	kvs    map[string]*mvccpb.KeyValue
+	leases map[clientv3.LeaseID]int64
+	lease  clientv3.LeaseID
+	// before_commit is called before the transaction compare the revisions
+	before_commit func()
+}
+
+func new_client() (*clientv3.Client, *fake_etcd) {
+	fake := &fake_etcd{kvs: make(map[string]*mvccpb.KeyValue), leases: make(map[clientv3.LeaseID]int64)}
+	return &clientv3.Client{KV: fake, Lease: fake}, fake
+}
+
+func (f *fake_etcd) Put(_ context.Context, key, val string, _ ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.rev++
+	f.kvs[key] = &mvccpb.KeyValue{Key: []byte(key), Value: []byte(val), ModRevision: f.rev, Lease: int64(f.lease)}
+	return &clientv3.PutResponse{}, nil
+}
+
+func (f *fake_etcd) Get(_ context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	op := clientv3.OpGet(key, opts...)
+	resp := &clientv3.GetResponse{}
+	if end := op.RangeBytes(); end != nil {
+		var keys []string
+		for k := range f.kvs {
+			if k >= key && bytes.Compare([]byte(k), end) < 0 {
+				keys = append(keys, k)
+			}
+		}
+
+		slices.Sort(keys)
+		for _, k := range keys {
+			resp.Kvs = append(resp.Kvs, f.kvs[k])
+		}
+	} else if kv, ok := f.kvs[key]; ok {
+		resp.Kvs = []*mvccpb.KeyValue{kv}
+	}
+
+	resp.Count = int64(len(resp.Kvs))
+	if op.IsCountOnly() {
+		resp.Kvs = nil
+	}
+
+	return resp, nil
+}
+
+func (f *fake_etcd) Delete(_ context.Context, key string, _ ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.kvs, key)
+	return &clientv3.DeleteResponse{}, nil
+}
+
+func (f *fake_etcd) Txn(context.Context) clientv3.Txn { return &fake_txn{etcd: f} }
+
+func (f *fake_etcd) Grant(_ context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lease = clientv3.LeaseID(len(f.leases) + 1)
+	f.leases[f.lease] = ttl
+	return &clientv3.LeaseGrantResponse{ID: f.lease, TTL: ttl}, nil
+}
+
+func (f *fake_etcd) TimeToLive(_ context.Context, id clientv3.LeaseID, _ ...clientv3.LeaseOption) (*clientv3.LeaseTimeToLiveResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ttl, ok := f.leases[id]
+	if !ok {
+		ttl = -1
+	}
+
+	return &clientv3.LeaseTimeToLiveResponse{ID: id, TTL: ttl}, nil
+}
+
+// fake_txn support mod revision comparison followed by deletes
+type fake_txn struct {
+	etcd *fake_etcd
+	cmps []clientv3.Cmp
+	then []clientv3.Op
+}
+
+func (txn *fake_txn) If(cs ...clientv3.Cmp) clientv3.Txn   { txn.cmps = cs; return txn }
+func (txn *fake_txn) Then(ops ...clientv3.Op) clientv3.Txn { txn.then = ops; return txn }
+func (txn *fake_txn) Else(...clientv3.Op) clientv3.Txn     { return txn }
+
+func (txn *fake_txn) Commit() (*clientv3.TxnResponse, error) {
+	if txn.etcd.before_commit != nil {
+		txn.etcd.before_commit()
+	}
+
+	txn.etcd.mu.Lock()
+	defer txn.etcd.mu.Unlock()
+
+	for _, cmp := range txn.cmps {
+		kv, ok := txn.etcd.kvs[string(cmp.KeyBytes())]
+		if !ok || cmp.Target != pb.Compare_MOD || kv.ModRevision != cmp.TargetUnion.(*pb.Compare_ModRevision).ModRevision {
+			return &clientv3.TxnResponse{Succeeded: false}, nil
+		}
+	}
+
+	for _, op := range txn.then {
+		delete(txn.etcd.kvs, string(op.KeyBytes()))
+	}
+
+	return &clientv3.TxnResponse{Succeeded: true}, nil
+}
+
+func TestTokenProvider(t *testing.T) {
+	client, fake := new_client()
+	clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+	etp := etcdcsrf.New(client, etcdcsrf.Config{TTL: 90500 * time.Millisecond, Clock: clock})
+
+	// the subject carry the separator of the value
+	ctx := csrf.WithSessionID(context.Background(), "alice:127.0.0.1")
+	token, err := etp.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ttl := fake.leases[fake.lease]; ttl != 91 {
+		t.Errorf("lease ttl = %d, want rounded up to 91", ttl)
+	}
+
+	if issued_at, err := etp.IssuedAt(ctx, token); err != nil || !issued_at.Equal(clock.Now()) {
+		t.Errorf("IssuedAt = %v, %v, want %v", issued_at, err, clock.Now())
+	}
+
+	if err := etp.Check(ctx, token); err != nil {
+		t.Fatalf("Check = %v", err)
+	}
+
+	other := csrf.WithSessionID(context.Background(), "alice")
+	if err := etp.Check(other, token); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("Check of other subject = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+
+	if err := etp.CheckAndConsume(ctx, token); err != nil {
+		t.Fatalf("CheckAndConsume = %v", err)
+	}
+
+	if err := etp.CheckAndConsume(ctx, token); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("CheckAndConsume of consumed token = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+
+	if err := etp.Ping(context.Background()); err != nil {
+		t.Errorf("Ping = %v", err)
+	}
+}
+
+func TestCheckAndConsumeConcurrent(t *testing.T) {
+	client, fake := new_client()
+	etp := etcdcsrf.New(client, etcdcsrf.Config{})
+
+	ctx := context.Background()
+	token, err := etp.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// another consumer rewrite the key between the lookup and the transaction
+	fake.before_commit = func() {
+		fake.before_commit = nil
+		fake.Put(ctx, "csrf/"+token, string(fake.kvs["csrf/"+token].Value))
+	}
+
+	if err := etp.CheckAndConsume(ctx, token); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("CheckAndConsume of modified key = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+}
+
+func TestRevokeAll(t *testing.T) {
+	client, _ := new_client()
+	etp := etcdcsrf.New(client, etcdcsrf.Config{})
+
+	subjects := []string{"alice", "alice\x00127.0.0.1", "alicex", "bob"}
+	tokens := make([]string, len(subjects))
+	for i, subject := range subjects {
+		token, err := etp.Get(csrf.WithSessionID(context.Background(), subject))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[i] = token
+	}
+
+	if err := etp.RevokeAll(context.Background(), "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, subject := range subjects {
+		var want error
+		if i < 2 {
+			want = csrf.ErrTokenNotFound
+		}
+
+		if err := etp.Check(csrf.WithSessionID(context.Background(), subject), tokens[i]); !errors.Is(err, want) {
+			t.Errorf("Check of %q = %v, want %v", subject, err, want)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	client, _ := new_client()
+	clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+	etp := etcdcsrf.New(client, etcdcsrf.Config{TTL: time.Minute, Clock: clock})
+
+	ctx := csrf.WithSessionID(context.Background(), "alice")
+	var ids []string
+	for range 5 {
+		token, err := etp.Get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, csrf.TokenID(token))
+	}
+	slices.Sort(ids)
+
+	var listed []string
+	for cursor := ""; ; {
+		page, next, err := etp.List(context.Background(), cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, info := range page {
+			if info.Subject != "alice" || !info.IssuedAt.Equal(clock.Now()) || !info.ExpireAt.Equal(clock.Now().Add(time.Minute)) {
+				t.Errorf("info = %+v", info)
+			}
+			listed = append(listed, info.ID)
+		}
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if !slices.Equal(listed, ids) {
+		t.Fatalf("listed %v, want %v", listed, ids)
+	}
+
+	if err := etp.RevokeID(context.Background(), ids[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	page, _, err := etp.List(context.Background(), "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page) != 4 || slices.ContainsFunc(page, func(info csrf.TokenInfo) bool { return info.ID == ids[2] }) {
+		t.Errorf("List after RevokeID = %v, want without %s", page, ids[2])
+	}
+}
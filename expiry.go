@@ -0,0 +1,130 @@
+package csrf
+
+import (
+	"container/heap"
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+type expiry_item struct {
+	expire_at int64
+	token     string
+}
+
+// expiry_heap is min-heap of token expiry, it may contain items of already deleted
+// or refreshed token which are dropped or pushed back when popped
+type expiry_heap []expiry_item
+
+var _ heap.Interface = (*expiry_heap)(nil)
+
+func (h expiry_heap) Len() int           { return len(h) }
+func (h expiry_heap) Less(i, j int) bool { return h[i].expire_at < h[j].expire_at }
+func (h expiry_heap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *expiry_heap) Push(x any)        { *h = append(*h, x.(expiry_item)) }
+
+func (h *expiry_heap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// evict_oldest_locked delete the token closest to expiry, must be called with dtp.mu held
+func (dtp *DefaultTokenProvider) evict_oldest_locked() {
+	for dtp.expiry.Len() > 0 {
+		item := heap.Pop(&dtp.expiry).(expiry_item)
+		entry, found := dtp.tokens[item.token]
+		if !found {
+			continue
+		}
+
+		if entry.expire_at != item.expire_at {
+			heap.Push(&dtp.expiry, expiry_item{expire_at: entry.expire_at, token: item.token})
+			continue
+		}
+
+		dtp.delete_locked(item.token)
+		dtp.stats.evicted.Add(1)
+		dtp.logger.Debug("csrf: token evicted", "max_tokens", dtp.max_tokens)
+		return
+	}
+}
+
+// grace_seconds return [Config.GracePeriod] in the resolution of expire_at
+func (dtp *DefaultTokenProvider) grace_seconds() int64 {
+	return int64(dtp.grace / time.Second)
+}
+
+// jitter add random delay of up to [Config.GCJitter] to `interval`
+func (dtp *DefaultTokenProvider) jitter(interval time.Duration) time.Duration {
+	if dtp.gc_jitter <= 0 {
+		return interval
+	}
+
+	return interval + rand.N(dtp.gc_jitter)
+}
+
+// sweep remove expired tokens in O(k log n) and return the number of remaining tokens,
+// the lock is released every [Config.GCBatchSize] entries so Get and Check are not blocked for long
+// and at most [Config.GCMaxPerRun] entries are processed. it stop between batches once `ctx` is done
+func (dtp *DefaultTokenProvider) sweep(ctx context.Context) int {
+	current_time := dtp.clock.Now()
+	// tokens within the grace period are still valid
+	now := current_time.Unix() - dtp.grace_seconds()
+	removed, processed := 0, 0
+	for done := false; !done; {
+		var expired []TokenEvent
+		dtp.mu.Lock()
+		for range dtp.gc_batch {
+			if dtp.expiry.Len() == 0 || dtp.expiry[0].expire_at > now {
+				done = true
+				break
+			}
+
+			if dtp.gc_max_per_run > 0 && processed >= dtp.gc_max_per_run {
+				done = true
+				break
+			}
+			processed++
+
+			item := heap.Pop(&dtp.expiry).(expiry_item)
+			entry, found := dtp.tokens[item.token]
+			if !found {
+				continue
+			}
+
+			if entry.expire_at > now {
+				// refreshed by sliding expiration
+				heap.Push(&dtp.expiry, expiry_item{expire_at: entry.expire_at, token: item.token})
+				continue
+			}
+
+			dtp.delete_locked(item.token)
+			removed++
+			if dtp.hooks.OnExpire != nil {
+				expired = append(expired, entry.event(item.token))
+			}
+		}
+		dtp.mu.Unlock()
+
+		call_hook(dtp.hooks.OnExpire, expired...)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	dtp.mu.Lock()
+	remaining := len(dtp.tokens)
+	if remaining == 0 {
+		// drop items of deleted tokens
+		dtp.expiry = nil
+	}
+	dtp.mu.Unlock()
+
+	took := dtp.clock.Now().Sub(current_time)
+	dtp.stats.expired.Add(uint64(removed))
+	dtp.stats.last_gc.Store(int64(took))
+	dtp.logger.Debug("csrf: gc", "removed", removed, "remaining", remaining, "took", took)
+	return remaining
+}
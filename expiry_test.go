@@ -0,0 +1,122 @@
+package csrf_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+// reset_clock is [csrftest.Clock] reporting the duration of every timer started or reset, i.e. the gc interval
+type reset_clock struct {
+	*csrftest.Clock
+	resets chan time.Duration
+}
+
+func (rc reset_clock) NewTimer(d time.Duration) csrf.Timer {
+	timer := reset_timer{rc.Clock.NewTimer(d), rc.resets}
+	rc.resets <- d
+	return timer
+}
+
+type reset_timer struct {
+	csrf.Timer
+	resets chan time.Duration
+}
+
+func (rt reset_timer) Reset(d time.Duration) bool {
+	active := rt.Timer.Reset(d)
+	rt.resets <- d
+	return active
+}
+
+// next return the duration of the next timer started or reset by the gc
+func (rc reset_clock) next(t *testing.T) time.Duration {
+	t.Helper()
+
+	select {
+	case d := <-rc.resets:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("gc did not reset its timer")
+		return 0
+	}
+}
+
+func TestIdleBackoff(t *testing.T) {
+	clock := reset_clock{csrftest.NewClock(time.Unix(1_000_000, 0)), make(chan time.Duration)}
+	tp := new_provider(t, csrf.Config{Clock: clock, GCInterval: time.Second, IdleBackoff: 8 * time.Second})
+
+	next := func() time.Duration { return clock.next(t) }
+
+	interval := next()
+	for _, want := range []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second} {
+		clock.Advance(interval)
+		if interval = next(); interval != want {
+			t.Fatalf("idle interval = %v, want %v", interval, want)
+		}
+	}
+
+	if _, err := tp.Get(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if interval = next(); interval != time.Second {
+		t.Fatalf("interval after Get = %v, want %v", interval, time.Second)
+	}
+
+	// the token is not expired yet, the gc keep the base interval
+	clock.Advance(interval)
+	if interval = next(); interval != time.Second {
+		t.Errorf("interval with tokens = %v, want %v", interval, time.Second)
+	}
+}
+
+func TestGCJitter(t *testing.T) {
+	clock := reset_clock{csrftest.NewClock(time.Unix(1_000_000, 0)), make(chan time.Duration)}
+	new_provider(t, csrf.Config{Clock: clock, GCInterval: time.Second, GCJitter: 500 * time.Millisecond})
+
+	seen := make(map[time.Duration]bool)
+	interval := clock.next(t)
+	for range 8 {
+		if interval < time.Second || interval >= 1500*time.Millisecond {
+			t.Fatalf("interval = %v, want within [1s, 1.5s)", interval)
+		}
+
+		seen[interval] = true
+		clock.Advance(interval)
+		interval = clock.next(t)
+	}
+
+	if len(seen) == 1 {
+		t.Errorf("every interval is the same %v", seen)
+	}
+}
+
+func TestGCMaxPerRun(t *testing.T) {
+	clock := reset_clock{csrftest.NewClock(time.Unix(1_000_000, 0)), make(chan time.Duration)}
+	tp := new_provider(t, csrf.Config{
+		Clock:       clock,
+		TTL:         time.Minute,
+		GCInterval:  time.Minute,
+		GCBatchSize: 1,
+		GCMaxPerRun: 2,
+	})
+
+	interval := clock.next(t)
+	for range 5 {
+		if _, err := tp.Get(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []uint64{2, 4, 5} {
+		clock.Advance(interval)
+		interval = clock.next(t)
+		if got := tp.Stats().Expired; got != want {
+			t.Fatalf("expired = %d, want %d", got, want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package csrf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// FailurePolicy decide the validation result when the [TokenProvider] fail with backend error
+// (e.g. the store is unreachable) rather than rejecting the token
+type FailurePolicy int
+
+const (
+	// FailClosed reject the request on backend error, it is the default
+	FailClosed FailurePolicy = iota
+	// FailOpen allow the request on backend error, the error is still reported to [OnFailure] hooks
+	// with [Failure.FailOpen] set and counted by [FailOpenCollector]
+	FailOpen
+)
+
+// WithFailurePolicy set the [FailurePolicy], default to [FailClosed]
+func WithFailurePolicy(policy FailurePolicy) Option {
+	return func(c *CSRF) {
+		c.failure_policy = policy
+	}
+}
+
+// WithReportOnly allow every request regardless of the validation result, the failures are still counted by [Collector]
+// and passed to [OnFailure] hooks (e.g. [WithLogger], [WithAudit]) with [Failure.ReportOnly] set,
+// so existing application can measure the breakage before enforcing the protection
+func WithReportOnly() Option {
+	return func(c *CSRF) {
+		c.report_only = true
+	}
+}
+
+// FailOpenCollector is optional interface for [Collector] counting requests allowed by [FailOpen] policy,
+// such request is also counted as passed validation
+type FailOpenCollector interface {
+	FailOpen(reason string)
+}
+
+// is_backend_error report whether `err` of the provider is neither rejection of the token nor cancellation of the request
+func is_backend_error(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() == nil &&
+		!errors.Is(err, ErrInvalidToken) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// fail_open report backend error ignored by [FailOpen] policy
+func (c *CSRF) fail_open(r *http.Request, supplied supplied_token, err error) {
+	reason := FailureReason(err)
+	if foc, ok := c.metrics.(FailOpenCollector); ok {
+		foc.FailOpen(reason)
+	}
+
+	c.notify(Failure{Request: r, Err: err, Reason: reason, FailOpen: true}, supplied)
+}
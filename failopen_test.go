@@ -0,0 +1,50 @@
+package csrf_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+// reason_collector record the reasons of failed validations
+type reason_collector struct {
+	age_collector
+	reasons []string
+}
+
+func (rc *reason_collector) ValidationFailed(reason string) { rc.reasons = append(rc.reasons, reason) }
+
+func TestWithReportOnly(t *testing.T) {
+	var failures []csrf.Failure
+	collector := &reason_collector{}
+	c := csrf.New(new_provider(t, csrf.Config{}),
+		csrf.WithReportOnly(),
+		csrf.WithMetrics(collector),
+		csrf.OnFailure(func(f csrf.Failure) { failures = append(failures, f) }),
+	)
+
+	if reached, rec := csrftest.Serve(c.Middleware(), post("/", "unknown")); !reached {
+		t.Fatalf("report only request stopped with %d", rec.Code)
+	}
+
+	if err := c.Validate(post("/", ""), csrf.HeaderTokenSource); err != nil {
+		t.Errorf("Validate = %v, want nil in report only mode", err)
+	}
+
+	want := []string{csrf.FailureReason(csrf.ErrTokenNotFound), csrf.FailureReason(csrf.ErrTokenMissing)}
+	if !slices.Equal(collector.reasons, want) {
+		t.Errorf("counted reasons = %v, want %v", collector.reasons, want)
+	}
+
+	if len(failures) != 2 {
+		t.Fatalf("OnFailure called %d times, want 2", len(failures))
+	}
+
+	for _, f := range failures {
+		if !f.ReportOnly || f.Err == nil {
+			t.Errorf("failure = %+v, want ReportOnly with the error", f)
+		}
+	}
+}
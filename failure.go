@@ -0,0 +1,101 @@
+package csrf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+)
+
+// Failure describe failed validation passed to [OnFailure] hooks
+type Failure struct {
+	Request *http.Request
+	Err     error
+	// Reason is [FailureReason] of Err
+	Reason string
+	// Source is the name of the source which supplied the token (e.g. `csrf.(*CSRF).HeaderSource`),
+	// empty if no source supplied any token
+	Source string
+	// TokenHash is the first 8 bytes of hex encoded sha256 of the token supplied by Source (or the batch item
+	// of [CSRF.CheckBatch]), to correlate attempts without logging the token. it is empty if no token was supplied
+	TokenHash string
+	// FailOpen is set when the request was allowed regardless of Err by [FailOpen] policy
+	FailOpen bool
+	// ReportOnly is set when the request was allowed regardless of Err by [WithReportOnly]
+	ReportOnly bool
+}
+
+// OnFailure call `hook` on every failed validation, e.g. to feed security monitoring
+func OnFailure(hook func(Failure)) Option {
+	return func(c *CSRF) {
+		c.on_failure = append(c.on_failure, hook)
+	}
+}
+
+// WithLogger log every failed validation at warn level with the request details
+func WithLogger(logger *slog.Logger) Option {
+	return OnFailure(func(f Failure) {
+		logger.Warn("csrf: validation failed",
+			"remote_addr", f.Request.RemoteAddr,
+			"method", f.Request.Method,
+			"path", f.Request.URL.Path,
+			"source", f.Source,
+			"reason", f.Reason,
+			"error", f.Err,
+			"fail_open", f.FailOpen,
+			"report_only", f.ReportOnly,
+		)
+	})
+}
+
+// report record the validation result to metrics and failure hooks and return the error to act on,
+// which is nil in [WithReportOnly] mode
+func (c *CSRF) report(r *http.Request, supplied supplied_token, err error) error {
+	if err == nil {
+		c.metrics.ValidationPassed()
+		return nil
+	}
+
+	reason := FailureReason(err)
+	c.metrics.ValidationFailed(reason)
+	c.notify(Failure{Request: r, Err: err, Reason: reason, ReportOnly: c.report_only}, supplied)
+	if c.report_only {
+		return nil
+	}
+
+	return err
+}
+
+// token_hash return truncated hash of token `value` read from source, unmasked with [WithMasking]
+// so the same token hash the same across responses
+func (c *CSRF) token_hash(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	if c.masking && c.well_formed(value) {
+		if token := UnmaskToken(value); token != "" {
+			value = token
+		}
+	}
+
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
+
+// notify call the failure hooks with `f` of the `supplied` token, the source is not read again
+func (c *CSRF) notify(f Failure, supplied supplied_token) {
+	if len(c.on_failure) == 0 {
+		return
+	}
+
+	if supplied.source != nil {
+		f.Source = source_name(supplied.source)
+	}
+
+	f.TokenHash = c.token_hash(supplied.value)
+
+	for _, on_failure := range c.on_failure {
+		on_failure(f)
+	}
+}
@@ -0,0 +1,67 @@
+package csrf_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+func TestFailureTokenHash(t *testing.T) {
+	var failures []csrf.Failure
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.OnFailure(func(f csrf.Failure) { failures = append(failures, f) }))
+
+	reads := 0
+	source := func(r *http.Request) string {
+		reads++
+		return r.Header.Get(csrf.DefaultHeaderName)
+	}
+
+	if err := c.Validate(post("/", "invalid"), source); err == nil {
+		t.Fatal("Validate of invalid token passed")
+	}
+
+	if reads != 1 {
+		t.Errorf("source read %d times, want 1", reads)
+	}
+
+	valid, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.CheckBatch(post("/", ""), []string{valid, "forged"})
+
+	tests := []struct {
+		name   string
+		source string
+		hash   string
+	}{
+		{"validate", "csrf_test.TestFailureTokenHash", hash("invalid")},
+		{"batch", "", hash("forged")},
+	}
+
+	if len(failures) != len(tests) {
+		t.Fatalf("got %d failures, want %d", len(failures), len(tests))
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if failures[i].Source != tt.source {
+				t.Errorf("Source = %q, want %q", failures[i].Source, tt.source)
+			}
+
+			if failures[i].TokenHash != tt.hash {
+				t.Errorf("TokenHash = %q, want %q", failures[i].TokenHash, tt.hash)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package csrf
+
+import (
+	"errors"
+	"net/http"
+)
+
+var ErrCrossSiteRequest = errors.New("cross site request")
+
+// FetchMetadataCheck reject unsafe cross site request based on `Sec-Fetch-Site` fetch metadata header,
+// it is lightweight protection without token for browsers which send the header
+type FetchMetadataCheck struct {
+	// AllowSameSite accept request from sibling subdomain (`same-site`), by default only `same-origin`
+	// and user initiated `none` are accepted
+	AllowSameSite bool
+	// Fallback validate request without `Sec-Fetch-Site` header (e.g. old browsers or non browser clients),
+	// such as token validation from [CSRF.MustValidate]. nil accept such request
+	Fallback ValidatorFunc
+}
+
+// Validate return [ErrCrossSiteRequest] for unsafe cross site request
+func (fc FetchMetadataCheck) Validate(r *http.Request) error {
+	if is_safe_method(r) {
+		return nil
+	}
+
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none":
+		return nil
+	case "same-site":
+		if fc.AllowSameSite {
+			return nil
+		}
+	case "":
+		if fc.Fallback == nil {
+			return nil
+		}
+
+		return fc.Fallback(r)
+	}
+
+	return ErrCrossSiteRequest
+}
+
+// Middleware call `next` if [FetchMetadataCheck.Validate] pass, otherwise respond with `handle_err`
+func (fc FetchMetadataCheck) Middleware(handle_err ErrorHandlerFunc) func(next http.Handler) http.Handler {
+	return ValidatorFunc(fc.Validate).Middleware(handle_err)
+}
@@ -0,0 +1,152 @@
+// Package fibercsrf adapt [csrf.CSRF] to fiber (fasthttp).
+// the token is read with fiber native [TokenSourceFunc], the request is converted to [*http.Request]
+// only so the validators configured with [csrf.WithValidators] keep working
+package fibercsrf
+
+import (
+	"net/http"
+
+	"github.com/bokunodev/csrf"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+// TokenSourceFunc is [csrf.TokenSourceFunc] over fiber context
+type TokenSourceFunc func(*fiber.Ctx) string
+
+// HeaderSource return token from header `name`
+func HeaderSource(name string) TokenSourceFunc {
+	return func(ctx *fiber.Ctx) string {
+		return ctx.Get(name)
+	}
+}
+
+// FormSource return token from url encoded or multipart form field `field`
+func FormSource(field string) TokenSourceFunc {
+	return func(ctx *fiber.Ctx) string {
+		return ctx.FormValue(field)
+	}
+}
+
+// CookieSource return token from cookie `name`
+func CookieSource(name string) TokenSourceFunc {
+	return func(ctx *fiber.Ctx) string {
+		return ctx.Cookies(name)
+	}
+}
+
+// QuerySource return token from query parameter `name`
+func QuerySource(name string) TokenSourceFunc {
+	return func(ctx *fiber.Ctx) string {
+		return ctx.Query(name)
+	}
+}
+
+// Config for [Middleware]
+type Config struct {
+	// Next skip the middleware if it return true
+	Next func(*fiber.Ctx) bool
+	// Sources of the token, default to header [csrf.DefaultHeaderName]
+	Sources []TokenSourceFunc
+	// ContextKey of the issued token in [fiber.Ctx.Locals], default to `csrf`
+	ContextKey string
+	// ErrorHandler respond to validation failure, default to [DefaultErrorHandler]
+	ErrorHandler fiber.ErrorHandler
+}
+
+// DefaultErrorHandler respond with 403 Forbidden and `{"error":"csrf_token_invalid","reason":"..."}`
+func DefaultErrorHandler(ctx *fiber.Ctx, err error) error {
+	return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error":  "csrf_token_invalid",
+		"reason": csrf.FailureReason(err),
+	})
+}
+
+// Middleware validate request not skipped by [csrf.CSRF.Skip], then store token for the handler in [fiber.Ctx.Locals],
+// see [Token]. safe request reuse the token the client carry back in [Config.Sources] while it is valid, otherwise
+// new token is issued, see [csrf.CSRF.ReuseToken]. the subject and bindings match the validation,
+// the session id is read from [fiber.Ctx.UserContext], see [csrf.WithSessionID]
+func Middleware(c *csrf.CSRF, cfg Config) fiber.Handler {
+	if len(cfg.Sources) == 0 {
+		cfg.Sources = []TokenSourceFunc{HeaderSource(csrf.DefaultHeaderName)}
+	}
+
+	if cfg.ContextKey == "" {
+		cfg.ContextKey = "csrf"
+	}
+
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = DefaultErrorHandler
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+
+		r, err := request(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !c.Skip(r) {
+			if err := validate(c, ctx, r, cfg.Sources); err != nil {
+				return cfg.ErrorHandler(ctx, err)
+			}
+		}
+
+		token, err := c.ReuseToken(r, client_token(ctx, cfg.Sources))
+		if err != nil {
+			return err
+		}
+
+		ctx.Locals(cfg.ContextKey, token)
+		return ctx.Next()
+	}
+}
+
+// client_token return the first token found in `sources`
+func client_token(ctx *fiber.Ctx, sources []TokenSourceFunc) string {
+	for _, source := range sources {
+		if token := source(ctx); token != "" {
+			return token
+		}
+	}
+
+	return ""
+}
+
+// request convert the fiber request to [*http.Request] with [fiber.Ctx.UserContext]
+func request(ctx *fiber.Ctx) (*http.Request, error) {
+	r, err := adaptor.ConvertRequest(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.WithContext(ctx.UserContext()), nil
+}
+
+// Validate is [csrf.CSRF.Validate] for fiber context
+func Validate(c *csrf.CSRF, ctx *fiber.Ctx, sources ...TokenSourceFunc) error {
+	r, err := request(ctx)
+	if err != nil {
+		return err
+	}
+
+	return validate(c, ctx, r, sources)
+}
+
+func validate(c *csrf.CSRF, ctx *fiber.Ctx, r *http.Request, sources []TokenSourceFunc) error {
+	adapted := make([]csrf.TokenSourceFunc, len(sources))
+	for i, source := range sources {
+		adapted[i] = func(*http.Request) string { return source(ctx) }
+	}
+
+	return c.Validate(r, adapted...)
+}
+
+// Token return the token issued by [Middleware] with default [Config.ContextKey]
+func Token(ctx *fiber.Ctx) string {
+	token, _ := ctx.Locals("csrf").(string)
+	return token
+}
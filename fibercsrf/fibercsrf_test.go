@@ -0,0 +1,80 @@
+package fibercsrf_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/fibercsrf"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestMiddleware(t *testing.T) {
+	tp := csrf.NewDefaultTokenProviderConfig(context.Background(), csrf.Config{})
+	defer tp.Close()
+
+	c := csrf.New(tp,
+		csrf.WithSubject(func(r *http.Request) string { return r.Header.Get("User") }),
+		csrf.WithExcludedPaths("/webhook"),
+	)
+
+	app := fiber.New()
+	app.Use(fibercsrf.Middleware(c, fibercsrf.Config{}))
+	app.All("/*", func(ctx *fiber.Ctx) error { return ctx.SendString(fibercsrf.Token(ctx)) })
+
+	do := func(method, target, user, token string) (int, string) {
+		t.Helper()
+
+		r := httptest.NewRequest(method, target, nil)
+		r.Header.Set("User", user)
+		if token != "" {
+			r.Header.Set(csrf.DefaultHeaderName, token)
+		}
+
+		resp, err := app.Test(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	_, token := do(http.MethodGet, "/", "alice", "")
+	if token == "" {
+		t.Fatal("no token issued")
+	}
+
+	if _, reused := do(http.MethodGet, "/", "alice", token); reused != token {
+		t.Errorf("safe request with valid token got %s, want it reused", reused)
+	}
+
+	if _, other := do(http.MethodGet, "/", "bob", token); other == token || other == "" {
+		t.Errorf("safe request of other subject got %q, want new token", other)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		user   string
+		token  string
+		want   int
+	}{
+		{"other subject", http.MethodPost, "/", "bob", token, fiber.StatusForbidden},
+		{"no token", http.MethodPost, "/", "alice", "", fiber.StatusForbidden},
+		{"excluded path", http.MethodPost, "/webhook", "alice", "", fiber.StatusOK},
+		{"valid", http.MethodPost, "/", "alice", token, fiber.StatusOK},
+		{"consumed", http.MethodPost, "/", "alice", token, fiber.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		if status, _ := do(tt.method, tt.target, tt.user, tt.token); status != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, status, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package csrf
+
+// DefaultMaxTokenLength is the default of [WithMaxTokenLength], it fit every token of this package including masked one
+const DefaultMaxTokenLength = 1024
+
+// WithMaxTokenLength reject token value longer than `n` bytes with [ErrTokenMalformed] before it is unmasked,
+// compared or passed to the [TokenProvider], so huge attacker controlled value can not be used to amplify
+// memory and cpu cost. default to [DefaultMaxTokenLength], zero or negative disable the limit
+func WithMaxTokenLength(n int) Option {
+	return func(c *CSRF) {
+		c.max_token_len = n
+	}
+}
+
+// well_formed report whether token `value` read from a source is within the length limit and consist only of
+// printable ascii, which every token of this package and [MaskToken] produce. empty value is well formed
+func (c *CSRF) well_formed(value string) bool {
+	if c.max_token_len > 0 && len(value) > c.max_token_len {
+		return false
+	}
+
+	for i := 0; i < len(value); i++ {
+		if value[i] < 0x21 || value[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WithTokenFormat reject token for which `valid` return false with [ErrTokenMalformed] before it reach the [TokenProvider],
+// so garbage does not cost a backend round trip. with [WithMasking] the unmasked token is checked
+func WithTokenFormat(valid func(token string) bool) Option {
+	return func(c *CSRF) {
+		c.token_format = valid
+	}
+}
+
+// TokenFormat return validator for [WithTokenFormat] accepting token of `min_len` to `max_len` bytes
+// consisting only of `charset`, empty `charset` accept any byte
+func TokenFormat(min_len, max_len int, charset string) func(string) bool {
+	var allowed [256]bool
+	for i := 0; i < len(charset); i++ {
+		allowed[charset[i]] = true
+	}
+
+	return func(token string) bool {
+		if len(token) < min_len || len(token) > max_len {
+			return false
+		}
+
+		if charset == "" {
+			return true
+		}
+
+		for i := 0; i < len(token); i++ {
+			if !allowed[token[i]] {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+const (
+	// UUIDCharset is the charset of [UUIDTokenGenerator]
+	UUIDCharset = "0123456789abcdef-"
+	// Base64URLCharset is the charset of [Base64TokenGenerator]
+	Base64URLCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+)
+
+// UUIDFormat accept token produced by [UUIDTokenGenerator]
+var UUIDFormat = TokenFormat(36, 36, UUIDCharset)
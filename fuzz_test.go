@@ -0,0 +1,227 @@
+package csrf_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bokunodev/csrf"
+)
+
+// body_request return POST request with `body` of `content_type`
+func body_request(content_type string, body []byte) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	r.Header.Set("Content-Type", content_type)
+	return r
+}
+
+// check_restored fail if the body of `r` no longer read as `body` after a source scanned it
+func check_restored(t *testing.T, r *http.Request, body []byte) {
+	t.Helper()
+
+	got, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, body) {
+		t.Fatalf("body = %q, want %q", got, body)
+	}
+}
+
+func FuzzMaskToken(f *testing.F) {
+	f.Add("0b3d8f9e-3c4a-4f0e-9d5b-7a1c2e6f8a90")
+	f.Add("a")
+	f.Add("\x00\xff")
+
+	f.Fuzz(func(t *testing.T, token string) {
+		if token == "" {
+			return
+		}
+
+		masked, err := csrf.MaskToken(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := csrf.UnmaskToken(masked); got != token {
+			t.Fatalf("UnmaskToken(MaskToken(%q)) = %q", token, got)
+		}
+	})
+}
+
+func FuzzUnmaskToken(f *testing.F) {
+	f.Add("")
+	f.Add("AAAA")
+	f.Add("not base64!")
+	f.Add("YWJj")
+
+	f.Fuzz(func(t *testing.T, masked string) {
+		if token := csrf.UnmaskToken(masked); len(token) > len(masked) {
+			t.Fatalf("UnmaskToken(%q) = %q longer than its input", masked, token)
+		}
+	})
+}
+
+func FuzzHeaderTokenSource(f *testing.F) {
+	f.Add("")
+	f.Add("0b3d8f9e-3c4a-4f0e-9d5b-7a1c2e6f8a90")
+	f.Add(strings.Repeat("a", csrf.DefaultMaxTokenLength+1))
+	f.Add("tok\x00en")
+
+	plain := csrf.New(new_provider(f, csrf.Config{}))
+	masked := csrf.New(new_provider(f, csrf.Config{}), csrf.WithMasking())
+
+	f.Fuzz(func(t *testing.T, token string) {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header[csrf.DefaultHeaderName] = []string{token}
+
+		// no token was issued, nothing the fuzzer send can be valid
+		for _, c := range []*csrf.CSRF{plain, masked} {
+			if err := c.Validate(r, csrf.HeaderTokenSource); !errors.Is(err, csrf.ErrInvalidToken) {
+				t.Fatalf("Validate(%q) = %v, want %v", token, err, csrf.ErrInvalidToken)
+			}
+		}
+	})
+}
+
+func FuzzFormTokenSource(f *testing.F) {
+	f.Add("csrf_token=abc", "abc")
+	f.Add("a=1&csrf_token=x%20y&b=2", "")
+	f.Add("csrf_token=%zz", "")
+	f.Add("&&&=", "")
+
+	stream := csrf.StreamFormTokenSource("csrf_token")
+	form := csrf.FormTokenSource("csrf_token")
+
+	f.Fuzz(func(t *testing.T, body, token string) {
+		r := body_request("application/x-www-form-urlencoded", []byte(body))
+		got := stream(r)
+		check_restored(t, r, []byte(body))
+
+		// the stream source must agree with ParseForm on the first occurrence of the field
+		r = body_request("application/x-www-form-urlencoded", []byte(body))
+		if _, err := url.ParseQuery(body); err == nil && got != form(r) {
+			t.Fatalf("StreamFormTokenSource(%q) = %q, FormTokenSource = %q", body, got, form(r))
+		}
+
+		encoded := url.Values{"csrf_token": {token}}.Encode()
+		if got := stream(body_request("application/x-www-form-urlencoded", []byte(encoded))); got != token {
+			t.Fatalf("StreamFormTokenSource(%q) = %q, want %q", encoded, got, token)
+		}
+	})
+}
+
+func FuzzJSONTokenSource(f *testing.F) {
+	f.Add([]byte(`{"csrf_token":"abc"}`), "abc")
+	f.Add([]byte(`{"csrf_token":1}`), "")
+	f.Add([]byte(`[]`), "")
+	f.Add([]byte(`{`), " ")
+
+	source := csrf.JSONTokenSource("csrf_token")
+
+	f.Fuzz(func(t *testing.T, body []byte, token string) {
+		r := body_request("application/json", body)
+		source(r)
+		check_restored(t, r, body)
+
+		if !utf8.ValidString(token) {
+			return
+		}
+
+		encoded, err := json.Marshal(map[string]string{"csrf_token": token})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := source(body_request("application/json", encoded)); got != token {
+			t.Fatalf("JSONTokenSource(%s) = %q, want %q", encoded, got, token)
+		}
+	})
+}
+
+func FuzzMultipartTokenSource(f *testing.F) {
+	f.Add([]byte("--b\r\nContent-Disposition: form-data; name=\"csrf_token\"\r\n\r\nabc\r\n--b--\r\n"), "abc")
+	f.Add([]byte("--b\r\n\r\n"), "")
+	f.Add([]byte(""), "x")
+
+	source := csrf.MultipartTokenSource("csrf_token", 1<<20)
+
+	f.Fuzz(func(t *testing.T, body []byte, token string) {
+		r := body_request("multipart/form-data; boundary=b", body)
+		source(r)
+		check_restored(t, r, body)
+
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		if err := mw.WriteField("csrf_token", token); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := mw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := source(body_request(mw.FormDataContentType(), buf.Bytes())); got != token {
+			t.Fatalf("MultipartTokenSource = %q, want %q", got, token)
+		}
+	})
+}
+
+func FuzzCookieTokenSource(f *testing.F) {
+	f.Add("csrf_token=abc")
+	f.Add("csrf_token=\"abc\"; other=1")
+	f.Add("csrf_token=abc.0.sig")
+	f.Add(csrf.SignCookieValue("csrf_token", "abc", time.Time{}, []byte("key")))
+	f.Add(";;=;")
+
+	key := []byte("key")
+	sources := []csrf.TokenSourceFunc{
+		csrf.CookieTokenSource("csrf_token"),
+		csrf.Base64CookieTokenSource("csrf_token"),
+		csrf.PairCookieTokenSource("csrf_token", "|", key),
+		csrf.SignedCookieTokenSource("csrf_token", key),
+	}
+
+	f.Fuzz(func(t *testing.T, header string) {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("Cookie", header)
+
+		for _, source := range sources {
+			source(r)
+		}
+
+		// a value can only pass the signed source if it carry a signature made with the key
+		r = httptest.NewRequest(http.MethodPost, "/", nil)
+		r.AddCookie(&http.Cookie{Name: "csrf_token", Value: header})
+		token := sources[3](r)
+		if token == "" {
+			return
+		}
+
+		expiry, _, _ := strings.Cut(strings.TrimPrefix(header, token+"."), ".")
+		expire_at := time.Time{}
+		if expiry != "0" {
+			unix, err := strconv.ParseInt(expiry, 10, 64)
+			if err != nil {
+				t.Fatalf("SignedCookieTokenSource accepted %q with expiry %q", header, expiry)
+			}
+
+			expire_at = time.Unix(unix, 0)
+		}
+
+		if want := csrf.SignCookieValue("csrf_token", token, expire_at, key); header != want {
+			t.Fatalf("SignedCookieTokenSource accepted %q, signed value is %q", header, want)
+		}
+	})
+}
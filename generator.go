@@ -0,0 +1,57 @@
+package csrf
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"time"
+)
+
+// RandomTokenGenerator return [GenerateTokenFunc] producing `size` bytes from crypto/rand encoded by `encode`,
+// e.g. `hex.EncodeToString` or `base64.RawURLEncoding.EncodeToString`. the generator panics if the system entropy source fails,
+// use [GenerateToken] to get the failure as error
+func RandomTokenGenerator(size int, encode func([]byte) string) GenerateTokenFunc {
+	return func() string {
+		buf := make([]byte, size)
+		if _, err := rand.Read(buf); err != nil {
+			panic(entropy_failure{err})
+		}
+
+		return encode(buf)
+	}
+}
+
+// Base64TokenGenerator generate 256-bit random token encoded as unpadded base64url (43 chars)
+func Base64TokenGenerator() string {
+	return base64_token_generator()
+}
+
+var base64_token_generator = RandomTokenGenerator(32, base64.RawURLEncoding.EncodeToString)
+
+const crockford_base32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDTokenGenerator generate ULID, 48-bit millisecond timestamp followed by 80 random bits
+// encoded as 26 chars crockford base32, so tokens sort lexicographically by issuance time
+func ULIDTokenGenerator() string {
+	var id [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	for i := range 6 {
+		id[i] = byte(ms >> (40 - 8*i))
+	}
+
+	if _, err := rand.Read(id[6:]); err != nil {
+		panic(entropy_failure{err})
+	}
+
+	// 128 bits into 26 chars of 5 bits, the first char only carry 3 bits
+	var out [26]byte
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford_base32[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return string(out[:])
+}
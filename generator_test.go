@@ -0,0 +1,78 @@
+package csrf_test
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestRandomTokenGenerator(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		encode func([]byte) string
+		length int
+	}{
+		{"hex", 16, hex.EncodeToString, 32},
+		{"base64url", 32, base64.RawURLEncoding.EncodeToString, 43},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generate := csrf.RandomTokenGenerator(tt.size, tt.encode)
+
+			seen := make(map[string]bool)
+			for range 100 {
+				token := generate()
+				if len(token) != tt.length {
+					t.Fatalf("len(%s) = %d, want %d", token, len(token), tt.length)
+				}
+
+				if seen[token] {
+					t.Fatalf("duplicate token %s", token)
+				}
+				seen[token] = true
+			}
+		})
+	}
+}
+
+func TestULIDTokenGenerator(t *testing.T) {
+	const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+	before := time.Now().UnixMilli()
+	token := csrf.ULIDTokenGenerator()
+	after := time.Now().UnixMilli()
+
+	if len(token) != 26 {
+		t.Fatalf("len(%s) = %d, want 26", token, len(token))
+	}
+
+	// the first char only carry 3 bits
+	if token[0] > '7' {
+		t.Errorf("first char of %s overflow 128 bits", token)
+	}
+
+	// the first 10 chars are the 48-bit millisecond timestamp
+	var ms int64
+	for _, ch := range token[:10] {
+		i := strings.IndexRune(crockford, ch)
+		if i < 0 {
+			t.Fatalf("%s is not crockford base32", token)
+		}
+		ms = ms<<5 | int64(i)
+	}
+
+	if ms < before || ms > after {
+		t.Errorf("timestamp of %s = %d, want within [%d, %d]", token, ms, before, after)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if next := csrf.ULIDTokenGenerator(); next <= token {
+		t.Errorf("%s issued later sort before %s", next, token)
+	}
+}
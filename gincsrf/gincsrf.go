@@ -0,0 +1,82 @@
+// Package gincsrf adapt [csrf.CSRF] to gin
+package gincsrf
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bokunodev/csrf"
+	"github.com/gin-gonic/gin"
+)
+
+// AbortFunc respond to validation failure, it must abort `ctx`
+type AbortFunc func(ctx *gin.Context, err error)
+
+// DefaultAbort abort with 403 Forbidden and `{"error":"csrf_token_invalid","reason":"..."}`,
+// `err` is also attached to `ctx` with [gin.Context.Error]
+func DefaultAbort(ctx *gin.Context, err error) {
+	_ = ctx.Error(err)
+	ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error":  "csrf_token_invalid",
+		"reason": csrf.FailureReason(err),
+	})
+}
+
+// Middleware is [csrf.CSRF.Protect] which call `abort` on failure, default to [DefaultAbort].
+// the token is read from [csrf.CSRF.HeaderSource] if no source is given
+func Middleware(c *csrf.CSRF, abort AbortFunc, sources ...csrf.TokenSourceFunc) gin.HandlerFunc {
+	if abort == nil {
+		abort = DefaultAbort
+	}
+
+	if len(sources) == 0 {
+		sources = []csrf.TokenSourceFunc{c.HeaderSource()}
+	}
+
+	return wrap(c.Protect(func(_ http.ResponseWriter, r *http.Request, err error) bool {
+		abort(gin_context(r), err)
+		return false
+	}, sources...))
+}
+
+// TokenMiddleware is [csrf.CSRF.TokenMiddleware], the token can be read with [Token]
+func TokenMiddleware(c *csrf.CSRF, expose csrf.TokenExposure) gin.HandlerFunc {
+	return wrap(c.TokenMiddleware(expose))
+}
+
+type gin_request_context_key int
+
+// gin_request carry the gin context of the request through net/http middleware
+type gin_request struct {
+	ctx    *gin.Context
+	called bool
+}
+
+// wrap return gin handler running net/http middleware `mw` as part of gin chain, the middleware is built once
+// and the gin context is aborted if `mw` did not call the next handler
+func wrap(mw func(next http.Handler) http.Handler) gin.HandlerFunc {
+	h := mw(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		gr := r.Context().Value(gin_request_context_key(0)).(*gin_request)
+		gr.called = true
+		gr.ctx.Request = r
+		gr.ctx.Next()
+	}))
+
+	return func(ctx *gin.Context) {
+		gr := &gin_request{ctx: ctx}
+		h.ServeHTTP(ctx.Writer, ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), gin_request_context_key(0), gr)))
+		if !gr.called {
+			ctx.Abort()
+		}
+	}
+}
+
+// gin_context return the gin context of request passed through [wrap]
+func gin_context(r *http.Request) *gin.Context {
+	return r.Context().Value(gin_request_context_key(0)).(*gin_request).ctx
+}
+
+// Token return the token stored by [TokenMiddleware] or empty string
+func Token(ctx *gin.Context) string {
+	return csrf.TokenFromContext(ctx.Request.Context())
+}
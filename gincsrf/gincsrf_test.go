@@ -0,0 +1,96 @@
+package gincsrf_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/gincsrf"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tp := csrf.NewDefaultTokenProviderConfig(context.Background(), csrf.Config{})
+	defer tp.Close()
+
+	c := csrf.New(tp)
+	router := gin.New()
+	router.Use(gincsrf.TokenMiddleware(c, 0), gincsrf.Middleware(c, nil))
+	router.Any("/", func(ctx *gin.Context) { ctx.String(http.StatusOK, gincsrf.Token(ctx)) })
+
+	do := func(r *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r)
+		return rec
+	}
+
+	token := do(httptest.NewRequest(http.MethodGet, "/", nil)).Body.String()
+	if token == "" {
+		t.Fatal("no token in context")
+	}
+
+	post := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set(csrf.DefaultHeaderName, token)
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+		reason string
+	}{
+		{"valid", token, http.StatusOK, ""},
+		{"consumed", token, http.StatusForbidden, csrf.FailureReason(csrf.ErrTokenNotFound)},
+		{"missing", "", http.StatusForbidden, csrf.FailureReason(csrf.ErrTokenMissing)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(post(tt.token))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if tt.reason == "" {
+				return
+			}
+
+			var body map[string]string
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+
+			if body["error"] != "csrf_token_invalid" || body["reason"] != tt.reason {
+				t.Errorf("body = %v, want reason %s", body, tt.reason)
+			}
+		})
+	}
+}
+
+func TestMiddlewareAbort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tp := csrf.NewDefaultTokenProviderConfig(context.Background(), csrf.Config{})
+	defer tp.Close()
+
+	var errs []error
+	router := gin.New()
+	router.Use(gincsrf.Middleware(csrf.New(tp), func(ctx *gin.Context, err error) {
+		errs = append(errs, err)
+		ctx.AbortWithStatus(http.StatusTeapot)
+	}))
+	router.POST("/", func(ctx *gin.Context) { t.Error("aborted request reached the handler") })
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusTeapot || len(errs) != 1 {
+		t.Errorf("status = %d, errors = %v, want the abort func called once", rec.Code, errs)
+	}
+}
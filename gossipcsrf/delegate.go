@@ -0,0 +1,72 @@
+package gossipcsrf
+
+import (
+	"encoding/json"
+
+	"github.com/hashicorp/memberlist"
+)
+
+type broadcast []byte
+
+var _ memberlist.Broadcast = broadcast(nil)
+
+// Invalidates is always false, every event is about different token or must be delivered in order
+func (broadcast) Invalidates(memberlist.Broadcast) bool { return false }
+
+func (b broadcast) Message() []byte { return b }
+
+func (broadcast) Finished() {}
+
+func (gtp *TokenProvider) NodeMeta(int) []byte { return nil }
+
+// NotifyMsg apply event gossiped by other member
+func (gtp *TokenProvider) NotifyMsg(msg []byte) {
+	var e event
+	if err := json.Unmarshal(msg, &e); err != nil {
+		return
+	}
+
+	gtp.mu.Lock()
+	gtp.apply_locked(e)
+	gtp.mu.Unlock()
+}
+
+func (gtp *TokenProvider) GetBroadcasts(overhead, limit int) [][]byte {
+	return gtp.broadcast.GetBroadcasts(overhead, limit)
+}
+
+// LocalState return every alive and consumed token for the periodic full state sync
+func (gtp *TokenProvider) LocalState(bool) []byte {
+	gtp.mu.Lock()
+	events := make([]event, 0, len(gtp.alive)+len(gtp.consumed))
+	for token, e := range gtp.alive {
+		events = append(events, event{Op: op_issue, Token: token, Subject: e.subject, IssuedAt: e.issued_at, ExpireAt: e.expire_at})
+	}
+
+	for token, expire_at := range gtp.consumed {
+		events = append(events, event{Op: op_consume, Token: token, ExpireAt: expire_at})
+	}
+	gtp.mu.Unlock()
+
+	state, err := json.Marshal(events)
+	if err != nil {
+		return nil
+	}
+
+	return state
+}
+
+// MergeRemoteState apply the full state of other member, consume win over issue regardless of the order
+func (gtp *TokenProvider) MergeRemoteState(state []byte, _ bool) {
+	var events []event
+	if err := json.Unmarshal(state, &events); err != nil {
+		return
+	}
+
+	gtp.mu.Lock()
+	defer gtp.mu.Unlock()
+
+	for _, e := range events {
+		gtp.apply_locked(e)
+	}
+}
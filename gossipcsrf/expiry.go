@@ -0,0 +1,25 @@
+package gossipcsrf
+
+import "container/heap"
+
+type expiry_item struct {
+	expire_at int64
+	token     string
+}
+
+// expiry_heap is min-heap of token expiry, it may contain items of already removed token which are dropped when popped
+type expiry_heap []expiry_item
+
+var _ heap.Interface = (*expiry_heap)(nil)
+
+func (h expiry_heap) Len() int           { return len(h) }
+func (h expiry_heap) Less(i, j int) bool { return h[i].expire_at < h[j].expire_at }
+func (h expiry_heap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *expiry_heap) Push(x any)        { *h = append(*h, x.(expiry_item)) }
+
+func (h *expiry_heap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
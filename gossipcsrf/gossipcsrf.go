@@ -0,0 +1,408 @@
+// Package gossipcsrf implements [csrf.TokenProvider] replicated between the nodes of small cluster by gossip
+// (hashicorp/memberlist), so 2 or 3 nodes can share tokens without external store.
+//
+// the replication is eventually consistent: token issued on one node is accepted by the others only once
+// the issue event reach them, typically within tens of milliseconds, and nodes which missed the broadcast
+// catch up on the periodic full state sync. consume win over issue, consumed token is remembered until
+// it would have expired so late or repeated issue event never revive it. concurrent consumes of the same token
+// on different nodes within the propagation window both succeed, single use tokens need sticky sessions
+// if that is not acceptable.
+//
+// the gossip must be encrypted and authenticated with [memberlist.Config.SecretKey], otherwise anyone who can reach
+// the gossip port could inject tokens
+package gossipcsrf
+
+import (
+	"container/heap"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/hashicorp/memberlist"
+)
+
+// ErrSecretKeyRequired is returned by [New] if [memberlist.Config.SecretKey] is not set
+var ErrSecretKeyRequired = errors.New("gossipcsrf: memberlist SecretKey is required")
+
+// default of [Config.LeaveTimeout]
+const default_leave_timeout = 5 * time.Second
+
+// number of expired tokens removed by the gc before the lock is released
+const gc_batch_size = 1024
+
+// Config for [New]
+type Config struct {
+	// Memberlist configure the cluster membership, default to [memberlist.DefaultLANConfig].
+	// its Delegate must be nil, it is set to the provider. its SecretKey is required, see [ErrSecretKeyRequired]
+	Memberlist *memberlist.Config
+	// Join is the address of existing members to join, empty start new cluster
+	Join []string
+	// TTL of issued token, default to [csrf.DefaultTTL]
+	TTL time.Duration
+	// GCInterval is the interval of expired token removal, default to [csrf.DefaultGCInterval]
+	GCInterval time.Duration
+	// Generator of new token, default to [csrf.UUIDTokenGenerator]
+	Generator csrf.GenerateTokenFunc
+	// Clock default to [csrf.SystemClock]
+	Clock csrf.Clock
+	// LeaveTimeout bound the graceful leave of the cluster on Close, default to 5 seconds
+	LeaveTimeout time.Duration
+}
+
+type op uint8
+
+const (
+	op_issue op = iota + 1
+	op_consume
+)
+
+// event is the replicated change of single token, it is also the unit of the full state sync
+type event struct {
+	Op       op     `json:"op"`
+	Token    string `json:"token"`
+	Subject  string `json:"subject,omitempty"`
+	IssuedAt int64  `json:"issued_at,omitempty"`
+	ExpireAt int64  `json:"expire_at"`
+}
+
+type entry struct {
+	subject   string
+	issued_at int64
+	expire_at int64
+}
+
+// TokenProvider keep the tokens in memory and broadcast every issue and consume to the other members
+type TokenProvider struct {
+	mu    sync.Mutex
+	alive map[string]entry
+	// consumed hold the expiry of consumed tokens so late issue event does not revive them
+	consumed map[string]int64
+	// expiry hold the expiry of every alive and consumed token, see [TokenProvider.sweep]
+	expiry expiry_heap
+
+	list      *memberlist.Memberlist
+	broadcast *memberlist.TransmitLimitedQueue
+
+	token_ttl time.Duration
+	generate  csrf.GenerateTokenFunc
+
+	leave_timeout time.Duration
+	stop_gc       context.CancelFunc
+	gc_done       chan struct{}
+	clock         csrf.Clock
+}
+
+var (
+	_ csrf.TokenProvider    = (*TokenProvider)(nil)
+	_ csrf.Consumer         = (*TokenProvider)(nil)
+	_ csrf.IssuedAtProvider = (*TokenProvider)(nil)
+	_ csrf.TTLProvider      = (*TokenProvider)(nil)
+	_ csrf.HealthChecker    = (*TokenProvider)(nil)
+	_ csrf.Lister           = (*TokenProvider)(nil)
+	_ memberlist.Delegate   = (*TokenProvider)(nil)
+	_ io.Closer             = (*TokenProvider)(nil)
+)
+
+// New start the memberlist node and join `cfg.Join`, the gc goroutine stops when `ctx` is done or on Close
+func New(ctx context.Context, cfg Config) (*TokenProvider, error) {
+	if cfg.Memberlist == nil {
+		cfg.Memberlist = memberlist.DefaultLANConfig()
+	}
+
+	if cfg.Memberlist.Delegate != nil {
+		return nil, errors.New("memberlist delegate is already set")
+	}
+
+	if len(cfg.Memberlist.SecretKey) == 0 {
+		return nil, ErrSecretKeyRequired
+	}
+
+	if cfg.TTL <= 0 {
+		cfg.TTL = csrf.DefaultTTL
+	}
+
+	if cfg.GCInterval <= 0 {
+		cfg.GCInterval = csrf.DefaultGCInterval
+	}
+
+	if cfg.Generator == nil {
+		cfg.Generator = csrf.UUIDTokenGenerator
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = csrf.SystemClock{}
+	}
+
+	if cfg.LeaveTimeout <= 0 {
+		cfg.LeaveTimeout = default_leave_timeout
+	}
+
+	gtp := &TokenProvider{
+		alive:         make(map[string]entry),
+		consumed:      make(map[string]int64),
+		token_ttl:     cfg.TTL,
+		generate:      cfg.Generator,
+		leave_timeout: cfg.LeaveTimeout,
+		gc_done:       make(chan struct{}),
+		clock:         cfg.Clock,
+	}
+
+	cfg.Memberlist.Delegate = gtp
+	list, err := memberlist.Create(cfg.Memberlist)
+	if err != nil {
+		return nil, err
+	}
+
+	gtp.list = list
+	gtp.broadcast = &memberlist.TransmitLimitedQueue{
+		NumNodes:       list.NumMembers,
+		RetransmitMult: cfg.Memberlist.RetransmitMult,
+	}
+
+	if len(cfg.Join) > 0 {
+		if _, err := list.Join(cfg.Join); err != nil {
+			_ = list.Shutdown()
+			return nil, err
+		}
+	}
+
+	ctx, gtp.stop_gc = context.WithCancel(ctx)
+	go gtp.gc(ctx, cfg.GCInterval)
+	return gtp, nil
+}
+
+func (gtp *TokenProvider) gc(ctx context.Context, interval time.Duration) {
+	defer close(gtp.gc_done)
+
+	timer := gtp.clock.NewTimer(interval)
+	defer timer.Stop()
+
+	ctx_done := ctx.Done()
+	for {
+		select {
+		case <-ctx_done:
+			return
+		case <-timer.C():
+		}
+
+		gtp.sweep(ctx)
+		timer.Reset(interval)
+	}
+}
+
+// sweep remove the expired alive and consumed tokens in O(k log n),
+// the lock is released every [gc_batch_size] tokens so Get and Check are not blocked for long
+func (gtp *TokenProvider) sweep(ctx context.Context) {
+	now := gtp.clock.Now().Unix()
+	for done := false; !done && ctx.Err() == nil; {
+		gtp.mu.Lock()
+		for range gc_batch_size {
+			if gtp.expiry.Len() == 0 || gtp.expiry[0].expire_at > now {
+				done = true
+				break
+			}
+
+			item := heap.Pop(&gtp.expiry).(expiry_item)
+			if e, found := gtp.alive[item.token]; found && e.expire_at <= now {
+				delete(gtp.alive, item.token)
+			}
+
+			if expire_at, found := gtp.consumed[item.token]; found && expire_at <= now {
+				delete(gtp.consumed, item.token)
+			}
+		}
+		gtp.mu.Unlock()
+	}
+}
+
+// apply merge event from local or remote node, it must be called with gtp.mu held.
+// event already applied, e.g. by the full state sync, is ignored
+func (gtp *TokenProvider) apply_locked(e event) {
+	if e.ExpireAt <= gtp.clock.Now().Unix() {
+		return
+	}
+
+	switch e.Op {
+	case op_issue:
+		if _, consumed := gtp.consumed[e.Token]; consumed {
+			return
+		}
+
+		if _, alive := gtp.alive[e.Token]; alive {
+			return
+		}
+
+		gtp.alive[e.Token] = entry{subject: e.Subject, issued_at: e.IssuedAt, expire_at: e.ExpireAt}
+	case op_consume:
+		if _, consumed := gtp.consumed[e.Token]; consumed {
+			return
+		}
+
+		delete(gtp.alive, e.Token)
+		gtp.consumed[e.Token] = e.ExpireAt
+	default:
+		return
+	}
+
+	heap.Push(&gtp.expiry, expiry_item{expire_at: e.ExpireAt, token: e.Token})
+}
+
+// publish queue `e` to be gossiped to the other members
+func (gtp *TokenProvider) publish(e event) {
+	msg, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	gtp.broadcast.QueueBroadcast(broadcast(msg))
+}
+
+// Get issue token bound to the session id from context, see [csrf.WithSessionID]
+func (gtp *TokenProvider) Get(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	token, err := csrf.GenerateToken(gtp.generate)
+	if err != nil {
+		return "", err
+	}
+
+	now := gtp.clock.Now()
+	e := event{
+		Op:       op_issue,
+		Token:    token,
+		Subject:  csrf.SessionIDFromContext(ctx),
+		IssuedAt: now.UnixNano(),
+		ExpireAt: now.Add(csrf.TTLFromContext(ctx, gtp.token_ttl)).Unix(),
+	}
+
+	gtp.mu.Lock()
+	gtp.apply_locked(e)
+	gtp.mu.Unlock()
+
+	gtp.publish(e)
+	return token, nil
+}
+
+// check must be called with gtp.mu held
+func (gtp *TokenProvider) check_locked(ctx context.Context, token string) (entry, error) {
+	if err := ctx.Err(); err != nil {
+		return entry{}, err
+	}
+
+	e, found := gtp.alive[token]
+	if !(found && subtle.ConstantTimeCompare([]byte(e.subject), []byte(csrf.SessionIDFromContext(ctx))) == 1) {
+		return entry{}, csrf.ErrTokenNotFound
+	}
+
+	if gtp.clock.Now().Unix() >= e.expire_at {
+		return entry{}, csrf.ErrTokenExpired
+	}
+
+	return e, nil
+}
+
+func (gtp *TokenProvider) Check(ctx context.Context, token string) error {
+	gtp.mu.Lock()
+	defer gtp.mu.Unlock()
+
+	_, err := gtp.check_locked(ctx, token)
+	return err
+}
+
+// CheckAndConsume delete the token locally and broadcast the consume, see the package doc for the semantics
+// of concurrent consumes on different nodes
+func (gtp *TokenProvider) CheckAndConsume(ctx context.Context, token string) error {
+	gtp.mu.Lock()
+	e, err := gtp.check_locked(ctx, token)
+	consume := event{Op: op_consume, Token: token, ExpireAt: e.expire_at}
+	if err == nil {
+		gtp.apply_locked(consume)
+	}
+	gtp.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	gtp.publish(consume)
+	return nil
+}
+
+func (gtp *TokenProvider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	gtp.mu.Lock()
+	defer gtp.mu.Unlock()
+
+	e, err := gtp.check_locked(ctx, token)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(0, e.issued_at), nil
+}
+
+func (gtp *TokenProvider) TTL() time.Duration { return gtp.token_ttl }
+
+// Ping return error once the node left the cluster
+func (gtp *TokenProvider) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if gtp.list.LocalNode().State != memberlist.StateAlive {
+		return errors.New("gossipcsrf: node is not alive")
+	}
+
+	return nil
+}
+
+// Members return the number of alive members including this node
+func (gtp *TokenProvider) Members() int { return gtp.list.NumMembers() }
+
+// Close stop the gc, leave the cluster within [Config.LeaveTimeout] and shutdown the node
+func (gtp *TokenProvider) Close() error {
+	gtp.stop_gc()
+	<-gtp.gc_done
+
+	return errors.Join(gtp.list.Leave(gtp.leave_timeout), gtp.list.Shutdown())
+}
+
+// List the tokens known to this node ordered by [csrf.TokenID], every page hash every token
+// and sort the ones after the cursor
+func (gtp *TokenProvider) List(ctx context.Context, cursor string, limit int) ([]csrf.TokenInfo, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if limit <= 0 {
+		return nil, "", nil
+	}
+
+	now := gtp.clock.Now().Unix()
+
+	gtp.mu.Lock()
+	defer gtp.mu.Unlock()
+
+	var tokens []csrf.TokenInfo
+	for token, e := range gtp.alive {
+		if e.expire_at <= now {
+			continue
+		}
+
+		if id := csrf.TokenID(token); id > cursor {
+			tokens = append(tokens, csrf.TokenInfo{ID: id, Subject: e.subject, IssuedAt: time.Unix(0, e.issued_at), ExpireAt: time.Unix(e.expire_at, 0)})
+		}
+	}
+
+	slices.SortFunc(tokens, func(a, b csrf.TokenInfo) int { return strings.Compare(a.ID, b.ID) })
+	tokens = tokens[:min(limit, len(tokens))]
+	return tokens, csrf.NextCursor(tokens, limit), nil
+}
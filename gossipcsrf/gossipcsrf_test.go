@@ -0,0 +1,90 @@
+package gossipcsrf_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/gossipcsrf"
+	"github.com/hashicorp/memberlist"
+)
+
+var secret = []byte("0123456789abcdef")
+
+// memberlist_config return config of node `name` listening on free local port
+func memberlist_config(t *testing.T, name string) *memberlist.Config {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	cfg := memberlist.DefaultLocalConfig()
+	cfg.Name = name
+	cfg.BindAddr = "127.0.0.1"
+	cfg.BindPort = l.Addr().(*net.TCPAddr).Port
+	cfg.AdvertisePort = cfg.BindPort
+	cfg.SecretKey = secret
+	cfg.LogOutput = io.Discard
+	return cfg
+}
+
+func new_node(t *testing.T, cfg gossipcsrf.Config) *gossipcsrf.TokenProvider {
+	t.Helper()
+
+	gtp, err := gossipcsrf.New(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { gtp.Close() })
+	return gtp
+}
+
+// eventually fail the test if `cond` does not hold within few seconds
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s did not happen", what)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewSecretKeyRequired(t *testing.T) {
+	for _, cfg := range []*memberlist.Config{nil, memberlist.DefaultLocalConfig()} {
+		if _, err := gossipcsrf.New(context.Background(), gossipcsrf.Config{Memberlist: cfg}); !errors.Is(err, gossipcsrf.ErrSecretKeyRequired) {
+			t.Errorf("New = %v, want %v", err, gossipcsrf.ErrSecretKeyRequired)
+		}
+	}
+}
+
+func TestReplication(t *testing.T) {
+	a_cfg := memberlist_config(t, "a")
+	a := new_node(t, gossipcsrf.Config{Memberlist: a_cfg})
+	b := new_node(t, gossipcsrf.Config{Memberlist: memberlist_config(t, "b"), Join: []string{fmt.Sprintf("127.0.0.1:%d", a_cfg.BindPort)}})
+
+	ctx := csrf.WithSessionID(context.Background(), "alice")
+	token, err := a.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, "issue replication", func() bool { return b.Check(ctx, token) == nil })
+
+	if err := b.CheckAndConsume(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, "consume replication", func() bool { return errors.Is(a.Check(ctx, token), csrf.ErrTokenNotFound) })
+}
@@ -0,0 +1,18 @@
+module github.com/bokunodev/csrf/gqlcsrf
+
+go 1.22rc2
+
+require (
+	github.com/99designs/gqlgen v0.17.49
+	github.com/bokunodev/csrf v0.0.0
+	github.com/vektah/gqlparser/v2 v2.5.16
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/sosodev/duration v1.3.1 // indirect
+	go.opentelemetry.io/otel v1.31.0 // indirect
+	go.opentelemetry.io/otel/trace v1.31.0 // indirect
+)
+
+replace github.com/bokunodev/csrf => ../
@@ -0,0 +1,116 @@
+// Package gqlcsrf adapt [csrf.CSRF] to gqlgen. a single `/graphql` POST endpoint defeat method based skipping,
+// so the token is validated per operation: queries pass, mutations require valid token.
+//
+//	srv := handler.NewDefaultServer(schema)
+//	srv.Use(gqlcsrf.New(c))
+//	http.Handle("/graphql", gqlcsrf.Middleware(srv))
+package gqlcsrf
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/bokunodev/csrf"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ExtensionKey is the key of the token in the request `extensions` map
+const ExtensionKey = "csrfToken"
+
+// extension_header carry the token from the `extensions` map to the operation context
+const extension_header = "X-Gqlcsrf-Extension-Token"
+
+type request_context_key struct{}
+
+// Middleware store the request in its context so [Extension] can validate it, it must wrap the gqlgen handler
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), request_context_key{}, r)))
+	})
+}
+
+// Extension is gqlgen operation middleware validating mutations
+type Extension struct {
+	c       *csrf.CSRF
+	sources []csrf.TokenSourceFunc
+}
+
+var (
+	_ graphql.HandlerExtension          = (*Extension)(nil)
+	_ graphql.OperationParameterMutator = (*Extension)(nil)
+	_ graphql.OperationInterceptor      = (*Extension)(nil)
+)
+
+// New return [Extension] reading the token from `sources`, default to [csrf.CSRF.HeaderSource],
+// then from the `extensions` map under [ExtensionKey]
+func New(c *csrf.CSRF, sources ...csrf.TokenSourceFunc) *Extension {
+	if len(sources) == 0 {
+		sources = []csrf.TokenSourceFunc{c.HeaderSource()}
+	}
+
+	return &Extension{c: c, sources: sources}
+}
+
+func (e *Extension) ExtensionName() string { return "CSRF" }
+
+func (e *Extension) Validate(graphql.ExecutableSchema) error { return nil }
+
+// MutateOperationParameters keep the token from the `extensions` map, the operation type is not known yet
+func (e *Extension) MutateOperationParameters(_ context.Context, params *graphql.RawParams) *gqlerror.Error {
+	token, _ := params.Extensions[ExtensionKey].(string)
+	if token == "" {
+		return nil
+	}
+
+	params.Headers = params.Headers.Clone()
+	if params.Headers == nil {
+		params.Headers = make(http.Header)
+	}
+
+	params.Headers.Set(extension_header, token)
+	return nil
+}
+
+// InterceptOperation validate mutation, operation over transport without [Middleware] (e.g. websocket) is rejected
+func (e *Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation != ast.Mutation {
+		return next(ctx)
+	}
+
+	r, _ := ctx.Value(request_context_key{}).(*http.Request)
+	if r == nil {
+		return reject(ctx, "request_not_in_context")
+	}
+
+	// single source, so token in the extensions does not conflict with missing header and vice versa
+	source := func(r *http.Request) string {
+		for _, source := range e.sources {
+			if token := source(r); token != "" {
+				return token
+			}
+		}
+
+		return oc.Headers.Get(extension_header)
+	}
+
+	if err := e.c.Validate(r, source); err != nil {
+		return reject(ctx, csrf.FailureReason(err))
+	}
+
+	return next(ctx)
+}
+
+func reject(ctx context.Context, reason string) graphql.ResponseHandler {
+	return graphql.OneShot(&graphql.Response{
+		Errors: gqlerror.List{{
+			Message: "invalid csrf token",
+			Extensions: map[string]any{
+				"code":   "CSRF_TOKEN_INVALID",
+				"reason": reason,
+			},
+		}},
+	})
+}
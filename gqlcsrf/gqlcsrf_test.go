@@ -0,0 +1,104 @@
+package gqlcsrf_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/gqlcsrf"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// operation run `ext` for operation of type `op` of request `r` passed through [gqlcsrf.Middleware]
+// and return the reason of the rejection or empty string if the operation proceeded
+func operation(t *testing.T, ext *gqlcsrf.Extension, r *http.Request, op ast.Operation, extensions map[string]any) string {
+	t.Helper()
+
+	params := &graphql.RawParams{Extensions: extensions}
+	if err := ext.MutateOperationParameters(context.Background(), params); err != nil {
+		t.Fatal(err)
+	}
+
+	var reason string
+	gqlcsrf.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := graphql.WithOperationContext(r.Context(), &graphql.OperationContext{
+			Headers:   params.Headers,
+			Operation: &ast.OperationDefinition{Operation: op},
+		})
+
+		resp := ext.InterceptOperation(ctx, func(context.Context) graphql.ResponseHandler {
+			return graphql.OneShot(&graphql.Response{})
+		})(ctx)
+
+		if len(resp.Errors) > 0 {
+			reason, _ = resp.Errors[0].Extensions["reason"].(string)
+		}
+	})).ServeHTTP(httptest.NewRecorder(), r)
+
+	return reason
+}
+
+func TestExtension(t *testing.T) {
+	tp := csrf.NewDefaultTokenProviderConfig(context.Background(), csrf.Config{})
+	defer tp.Close()
+
+	c := csrf.New(tp, csrf.WithTokenMode(csrf.MultiUse))
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ext := gqlcsrf.New(c)
+	with_header := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+		if token != "" {
+			r.Header.Set(csrf.DefaultHeaderName, token)
+		}
+
+		return r
+	}
+
+	tests := []struct {
+		name       string
+		r          *http.Request
+		op         ast.Operation
+		extensions map[string]any
+		want       string
+	}{
+		{"query without token", with_header(""), ast.Query, nil, ""},
+		{"mutation with header", with_header(token), ast.Mutation, nil, ""},
+		{"mutation with extensions", with_header(""), ast.Mutation, map[string]any{gqlcsrf.ExtensionKey: token}, ""},
+		{"header take precedence", with_header(token), ast.Mutation, map[string]any{gqlcsrf.ExtensionKey: "unknown"}, ""},
+		{"mutation without token", with_header(""), ast.Mutation, nil, csrf.FailureReason(csrf.ErrTokenMissing)},
+		{"mutation with unknown token", with_header("unknown"), ast.Mutation, nil, csrf.FailureReason(csrf.ErrTokenNotFound)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := operation(t, ext, tt.r, tt.op, tt.extensions); got != tt.want {
+				t.Errorf("rejected with %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtensionWithoutMiddleware(t *testing.T) {
+	tp := csrf.NewDefaultTokenProviderConfig(context.Background(), csrf.Config{})
+	defer tp.Close()
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Operation: ast.Mutation},
+	})
+
+	resp := gqlcsrf.New(csrf.New(tp)).InterceptOperation(ctx, func(context.Context) graphql.ResponseHandler {
+		t.Fatal("mutation without request proceeded")
+		return nil
+	})(ctx)
+
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["reason"] != "request_not_in_context" {
+		t.Errorf("response = %+v, want request_not_in_context", resp)
+	}
+}
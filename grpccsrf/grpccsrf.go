@@ -0,0 +1,103 @@
+// Package grpccsrf adapt [csrf.CSRF] to grpc server interceptors for browser facing grpc-web or connect endpoints.
+// the incoming metadata is presented as [http.Header] of POST request, so [csrf.CSRF.HeaderSource]
+// and the validators configured with [csrf.WithValidators] (e.g. origin check) work unchanged
+package grpccsrf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/bokunodev/csrf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Config for the interceptors
+type Config struct {
+	// Sources of the token, default to [csrf.CSRF.HeaderSource] which read metadata `x-csrf-token` by default
+	Sources []csrf.TokenSourceFunc
+	// Skip the validation of the full method name, e.g. `/pkg.Service/Method`
+	Skip func(full_method string) bool
+}
+
+func (cfg *Config) with_defaults(c *csrf.CSRF) {
+	if len(cfg.Sources) == 0 {
+		cfg.Sources = []csrf.TokenSourceFunc{c.HeaderSource()}
+	}
+
+	if cfg.Skip == nil {
+		cfg.Skip = func(string) bool { return false }
+	}
+}
+
+// UnaryServerInterceptor validate every unary call not skipped by [Config.Skip]
+func UnaryServerInterceptor(c *csrf.CSRF, cfg Config) grpc.UnaryServerInterceptor {
+	cfg.with_defaults(c)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !cfg.Skip(info.FullMethod) {
+			if err := Validate(ctx, c, info.FullMethod, cfg.Sources...); err != nil {
+				return nil, err
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor validate every stream not skipped by [Config.Skip] once when it is opened
+func StreamServerInterceptor(c *csrf.CSRF, cfg Config) grpc.StreamServerInterceptor {
+	cfg.with_defaults(c)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !cfg.Skip(info.FullMethod) {
+			if err := Validate(ss.Context(), c, info.FullMethod, cfg.Sources...); err != nil {
+				return err
+			}
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// Validate is [csrf.CSRF.Validate] for incoming metadata of `ctx`,
+// the failure is returned as [codes.PermissionDenied] status wrapping the [csrf.FailureReason]
+func Validate(ctx context.Context, c *csrf.CSRF, full_method string, sources ...csrf.TokenSourceFunc) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	h := make(http.Header, len(md))
+	for k, v := range md {
+		h[http.CanonicalHeaderKey(k)] = v
+	}
+
+	r := (&http.Request{
+		Method:     http.MethodPost,
+		URL:        &url.URL{Path: full_method},
+		RequestURI: full_method,
+		Proto:      "HTTP/2.0",
+		ProtoMajor: 2,
+		Header:     h,
+		Body:       http.NoBody,
+		Host:       first(md.Get(":authority")),
+	}).WithContext(ctx)
+
+	err := c.Validate(r, sources...)
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	return status.Error(codes.PermissionDenied, "csrf: "+csrf.FailureReason(err))
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
@@ -0,0 +1,126 @@
+package grpccsrf_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/grpccsrf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func new_csrf(t *testing.T) (*csrf.CSRF, string) {
+	tp := csrf.NewDefaultTokenProviderConfig(context.Background(), csrf.Config{})
+	t.Cleanup(func() { tp.Close() })
+
+	c := csrf.New(tp, csrf.WithTokenMode(csrf.MultiUse))
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c, token
+}
+
+func incoming(token string) context.Context {
+	md := metadata.Pairs(":authority", "example.com")
+	if token != "" {
+		md.Set("x-csrf-token", token)
+	}
+
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestValidate(t *testing.T) {
+	c, token := new_csrf(t)
+
+	canceled, cancel := context.WithCancel(incoming(token))
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code codes.Code
+		msg  string
+	}{
+		{"valid", incoming(token), codes.OK, ""},
+		{"missing", incoming(""), codes.PermissionDenied, "csrf: " + csrf.FailureReason(csrf.ErrTokenMissing)},
+		{"unknown", incoming("unknown"), codes.PermissionDenied, "csrf: " + csrf.FailureReason(csrf.ErrTokenNotFound)},
+		{"no metadata", context.Background(), codes.PermissionDenied, "csrf: " + csrf.FailureReason(csrf.ErrTokenMissing)},
+		{"canceled", canceled, codes.Canceled, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(grpccsrf.Validate(tt.ctx, c, "/pkg.Service/Method", c.HeaderSource()))
+			if st.Code() != tt.code || (tt.msg != "" && st.Message() != tt.msg) {
+				t.Errorf("Validate = %v %q, want %v %q", st.Code(), st.Message(), tt.code, tt.msg)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	c, token := new_csrf(t)
+	interceptor := grpccsrf.UnaryServerInterceptor(c, grpccsrf.Config{
+		Skip: func(full_method string) bool { return full_method == "/pkg.Service/Public" },
+	})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		method  string
+		reached bool
+	}{
+		{"valid", incoming(token), "/pkg.Service/Method", true},
+		{"missing", incoming(""), "/pkg.Service/Method", false},
+		{"skipped", incoming(""), "/pkg.Service/Public", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			_, err := interceptor(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, func(context.Context, any) (any, error) {
+				reached = true
+				return nil, nil
+			})
+
+			if reached != tt.reached || (err == nil) != tt.reached {
+				t.Errorf("reached = %v, err = %v, want reached %v", reached, err, tt.reached)
+			}
+		})
+	}
+}
+
+type server_stream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ss server_stream) Context() context.Context { return ss.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	c, token := new_csrf(t)
+	interceptor := grpccsrf.StreamServerInterceptor(c, grpccsrf.Config{})
+
+	for _, tt := range []struct {
+		token   string
+		reached bool
+		code    codes.Code
+	}{{token, true, codes.OK}, {"", false, codes.PermissionDenied}} {
+		reached := false
+		err := interceptor(nil, server_stream{ctx: incoming(tt.token)}, &grpc.StreamServerInfo{FullMethod: "/pkg.Service/Stream"},
+			func(any, grpc.ServerStream) error {
+				reached = true
+				return nil
+			},
+		)
+
+		if reached != tt.reached || status.Code(err) != tt.code {
+			t.Errorf("token %q: reached = %v, err = %v, want reached %v with %v", tt.token, reached, err, tt.reached, tt.code)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package csrf
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type token_response struct {
+	Token     string `json:"token"`
+	Header    string `json:"header"`
+	ExpiresIn int64  `json:"expires_in,omitempty"`
+}
+
+// TokenHandler return handler which issue new token on GET and respond with
+// `{"token": "...", "header": "X-Csrf-Token", "expires_in": 900}`, `expires_in` is the remaining lifetime of the token
+// as reported by [CSRF.Issue], it is omitted if [CSRF.TTL] is zero.
+// `expose` additionally write the token to the response header or cookie, see [TokenExposure]
+func (c *CSRF) TokenHandler(expose TokenExposure) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		issued, err := c.Issue(c.request_context(r))
+		if err != nil {
+			issue_error(w, err)
+			return
+		}
+
+		token := issued.Value
+		resp := token_response{Token: token, Header: c.header_name}
+		if !issued.ExpireAt.IsZero() {
+			resp.ExpiresIn = int64(issued.ExpireAt.Sub(c.clock.Now()).Round(time.Second).Seconds())
+		}
+
+		if expose&ExposeHeader != 0 {
+			w.Header().Set(c.header_name, token)
+		}
+
+		if expose&ExposeCookie != 0 {
+			http.SetCookie(w, token_cookie(r, c.cookie_name, token, false))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
@@ -0,0 +1,58 @@
+package csrf_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestTokenHandler(t *testing.T) {
+	clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+	c := csrf.New(new_provider(t, csrf.Config{TTL: time.Hour, Clock: clock}),
+		csrf.WithTokenMode(csrf.MultiUse),
+		csrf.WithClock(clock),
+	)
+	h := c.TokenHandler(csrf.ExposeHeader)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/token", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Cache-Control = %s, want no-store", cc)
+	}
+
+	var resp struct {
+		Token     string `json:"token"`
+		Header    string `json:"header"`
+		ExpiresIn int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Header != csrf.DefaultHeaderName || resp.ExpiresIn != 3600 {
+		t.Errorf("response = %+v, want header %s expires_in 3600", resp, csrf.DefaultHeaderName)
+	}
+
+	if exposed := rec.Header().Get(csrf.DefaultHeaderName); exposed != resp.Token {
+		t.Errorf("exposed header = %s, want %s", exposed, resp.Token)
+	}
+
+	if err := c.Validate(post("/", resp.Token), csrf.HeaderTokenSource); err != nil {
+		t.Errorf("Validate of issued token = %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, post("/token", ""))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodGet {
+		t.Errorf("POST status = %d, Allow = %s, want %d GET", rec.Code, rec.Header().Get("Allow"), http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,108 @@
+package csrf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"time"
+)
+
+// HashedTokenProvider is like [DefaultTokenProvider] but only keep the sha256 hash of the token in memory,
+// the plaintext token is only known by the client
+type HashedTokenProvider struct {
+	dtp *DefaultTokenProvider
+}
+
+var (
+	_ TokenProvider         = (*HashedTokenProvider)(nil)
+	_ Consumer              = (*HashedTokenProvider)(nil)
+	_ Exchanger             = (*HashedTokenProvider)(nil)
+	_ TokenProviderWithMeta = (*HashedTokenProvider)(nil)
+	_ MetaConsumer          = (*HashedTokenProvider)(nil)
+	_ IssuedAtProvider      = (*HashedTokenProvider)(nil)
+)
+
+func hash_token(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return string(sum[:])
+}
+
+func (htp *HashedTokenProvider) Get(ctx context.Context) (string, error) {
+	token, err := GenerateToken(htp.dtp.generate)
+	if err != nil {
+		return "", err
+	}
+
+	if err := htp.dtp.store(ctx, hash_token(token)); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (htp *HashedTokenProvider) Check(ctx context.Context, token string) error {
+	if token == "" {
+		return ErrTokenMissing
+	}
+
+	return htp.dtp.Check(ctx, hash_token(token))
+}
+
+func (htp *HashedTokenProvider) CheckAndConsume(ctx context.Context, token string) error {
+	if token == "" {
+		return ErrTokenMissing
+	}
+
+	return htp.dtp.CheckAndConsume(ctx, hash_token(token))
+}
+
+func (htp *HashedTokenProvider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	return htp.dtp.IssuedAt(ctx, hash_token(token))
+}
+
+func NewHashedTokenProvider(ctx context.Context, token_ttl, gc_intrvl time.Duration) *HashedTokenProvider {
+	return NewHashedTokenProviderConfig(ctx, Config{TTL: token_ttl, GCInterval: gc_intrvl})
+}
+
+func NewHashedTokenProviderConfig(ctx context.Context, cfg Config) *HashedTokenProvider {
+	return &HashedTokenProvider{dtp: NewDefaultTokenProviderConfig(ctx, cfg)}
+}
+
+// EncodeCookieToken return base64 encoded token suitable as cookie value for [Base64CookieTokenSource]
+func EncodeCookieToken(token string) string {
+	return base64.URLEncoding.EncodeToString([]byte(token))
+}
+
+// Base64CookieTokenSource return base64 decoded token from cookie or empty string
+func Base64CookieTokenSource(name string) TokenSourceFunc {
+	return func(r *http.Request) string {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+
+		token, err := base64.URLEncoding.DecodeString(cookie.Value)
+		if err != nil {
+			return ""
+		}
+
+		return string(token)
+	}
+}
+
+// HashedCookieValidator validate double submit token through [CSRF.Validate] of `c`, which should be backed by
+// [HashedTokenProvider]: the plaintext token from base64 encoded cookie is compared in constant time against `header`
+// and then looked up by its hash, so the token mode, the failure policy and the hooks of `c` apply.
+// it must not be passed to [WithValidators] of `c` itself
+func HashedCookieValidator(c *CSRF, cookie_name string, header TokenSourceFunc) ValidatorFunc {
+	cookie := Base64CookieTokenSource(cookie_name)
+	return func(r *http.Request) error {
+		// [CSRF.Validate] fall back to the header alone if the first source is empty
+		if cookie(r) == "" {
+			return ErrTokenMissing
+		}
+
+		return c.Validate(r, cookie, header)
+	}
+}
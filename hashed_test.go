@@ -0,0 +1,97 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestHashedCookieValidator(t *testing.T) {
+	htp := csrf.NewHashedTokenProviderConfig(context.Background(), csrf.Config{})
+	defer htp.Close()
+
+	token, err := htp.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validate := csrf.HashedCookieValidator(csrf.New(htp, csrf.WithTokenMode(csrf.MultiUse)), "csrf", csrf.HeaderTokenSource)
+	tests := []struct {
+		name   string
+		cookie string
+		header string
+		want   error
+	}{
+		{"match", csrf.EncodeCookieToken(token), token, nil},
+		{"mismatch", csrf.EncodeCookieToken(token), token + "x", csrf.ErrInconsistentTokenBetweenSources},
+		{"no header", csrf.EncodeCookieToken(token), "", csrf.ErrInconsistentTokenBetweenSources},
+		{"not base64", "!" + token, token, csrf.ErrTokenMissing},
+		{"no cookie", "", token, csrf.ErrTokenMissing},
+		{"unknown", csrf.EncodeCookieToken("unknown"), "unknown", csrf.ErrTokenNotFound},
+		{"replay", csrf.EncodeCookieToken(token), token, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := post("/", tt.header)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "csrf", Value: tt.cookie})
+			}
+
+			if err := validate(r); !errors.Is(err, tt.want) {
+				t.Errorf("validate = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashedTokenProviderStoreHash(t *testing.T) {
+	htp := csrf.NewHashedTokenProviderConfig(context.Background(), csrf.Config{})
+	defer htp.Close()
+
+	token, err := htp.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, _, err := htp.List(context.Background(), "", 10)
+	if err != nil || len(tokens) != 1 {
+		t.Fatalf("List = %v, %v", tokens, err)
+	}
+
+	if tokens[0].ID == token {
+		t.Error("plaintext token is stored")
+	}
+
+	if err := htp.CheckAndConsume(context.Background(), token); err != nil {
+		t.Fatalf("CheckAndConsume = %v", err)
+	}
+
+	if err := htp.Check(context.Background(), token); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("Check of consumed token = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+}
+
+func TestHashedCookieValidatorSingleUse(t *testing.T) {
+	htp := csrf.NewHashedTokenProviderConfig(context.Background(), csrf.Config{})
+	defer htp.Close()
+
+	token, err := htp.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validate := csrf.HashedCookieValidator(csrf.New(htp), "csrf", csrf.HeaderTokenSource)
+	r := post("/", token)
+	r.AddCookie(&http.Cookie{Name: "csrf", Value: csrf.EncodeCookieToken(token)})
+	if err := validate(r); err != nil {
+		t.Fatalf("validate = %v", err)
+	}
+
+	if err := validate(r); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("validate of consumed token = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+}
@@ -0,0 +1,75 @@
+package csrf
+
+import (
+	"context"
+	"net/http"
+)
+
+// HealthChecker is optional interface for [TokenProvider] which can report whether its backend is reachable
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+}
+
+var (
+	_ HealthChecker = (*DefaultTokenProvider)(nil)
+	_ HealthChecker = (*HashedTokenProvider)(nil)
+	_ HealthChecker = (*ShardedTokenProvider)(nil)
+	_ HealthChecker = (*SQLTokenProvider)(nil)
+	_ HealthChecker = (*HMACTokenProvider)(nil)
+	_ HealthChecker = (*EncryptedTokenProvider)(nil)
+	_ HealthChecker = (*ChainProvider)(nil)
+	_ HealthChecker = (*CachingProvider)(nil)
+	_ HealthChecker = (*PooledProvider)(nil)
+	_ HealthChecker = (*HookedProvider)(nil)
+)
+
+// ping the provider if it implements [HealthChecker], provider without backend is always healthy
+func ping(ctx context.Context, tp TokenProvider) error {
+	if hc, ok := tp.(HealthChecker); ok {
+		return hc.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (dtp *DefaultTokenProvider) Ping(context.Context) error { return nil }
+
+func (htp *HashedTokenProvider) Ping(context.Context) error { return nil }
+
+func (stp *ShardedTokenProvider) Ping(context.Context) error { return nil }
+
+func (htp *HMACTokenProvider) Ping(context.Context) error { return nil }
+
+func (etp *EncryptedTokenProvider) Ping(context.Context) error { return nil }
+
+func (stp *SQLTokenProvider) Ping(ctx context.Context) error { return stp.db.PingContext(ctx) }
+
+// Ping report the primary provider, the chain keep working through the secondary but it is degraded
+func (cp *ChainProvider) Ping(ctx context.Context) error { return ping(ctx, cp.primary) }
+
+func (cp *CachingProvider) Ping(ctx context.Context) error { return ping(ctx, cp.TokenProvider) }
+
+func (pp *PooledProvider) Ping(ctx context.Context) error { return ping(ctx, pp.TokenProvider) }
+
+func (hp *HookedProvider) Ping(ctx context.Context) error { return ping(ctx, hp.TokenProvider) }
+
+func (tp ttl_provider) Ping(ctx context.Context) error { return ping(ctx, tp.TokenProvider) }
+
+// Ping the [TokenProvider] if it implements [HealthChecker]
+func (c *CSRF) Ping(ctx context.Context) error {
+	return ping(ctx, c.TokenProvider)
+}
+
+// HealthHandler return handler for readiness probe, it respond with 200 OK if [CSRF.Ping] succeed
+// and 503 Service Unavailable otherwise
+func (c *CSRF) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := c.Ping(r.Context()); err != nil {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("ok"))
+	})
+}
@@ -0,0 +1,62 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+var errDown = errors.New("backend down")
+
+// down_provider is [csrftest.Provider] whose backend is unreachable
+type down_provider struct {
+	*csrftest.Provider
+}
+
+func (down_provider) Ping(context.Context) error { return errDown }
+
+func TestPingDecorators(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		wrap func(csrf.TokenProvider) csrf.TokenProvider
+	}{
+		{"Hooked", func(tp csrf.TokenProvider) csrf.TokenProvider { return csrf.NewHookedProvider(tp, csrf.Hooks{}) }},
+		{"WithTTL", func(tp csrf.TokenProvider) csrf.TokenProvider { return csrf.ProviderWithTTL(tp, time.Minute) }},
+		{"Chain", func(tp csrf.TokenProvider) csrf.TokenProvider {
+			return csrf.NewChainProvider(tp, csrftest.NewProvider(), csrf.FallbackOnError)
+		}},
+		{"Caching", func(tp csrf.TokenProvider) csrf.TokenProvider { return csrf.NewCachingProvider(tp, time.Second, 16) }},
+		{"Pooled", func(tp csrf.TokenProvider) csrf.TokenProvider {
+			pp := csrf.NewPooledProvider(ctx, tp, 1)
+			t.Cleanup(func() { pp.Close() })
+			return pp
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := csrf.New(tt.wrap(down_provider{csrftest.NewProvider()}))
+			if err := c.Ping(ctx); !errors.Is(err, errDown) {
+				t.Fatalf("Ping = %v, want %v", err, errDown)
+			}
+
+			rec := httptest.NewRecorder()
+			c.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if rec.Code != http.StatusServiceUnavailable {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+			}
+
+			if err := csrf.New(tt.wrap(csrftest.NewProvider())).Ping(ctx); err != nil {
+				t.Errorf("Ping of provider without HealthChecker = %v", err)
+			}
+		})
+	}
+}
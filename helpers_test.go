@@ -0,0 +1,40 @@
+package csrf_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+// new_provider return [csrf.DefaultTokenProvider] closed when the test ends
+func new_provider(tb testing.TB, cfg csrf.Config) *csrf.DefaultTokenProvider {
+	tb.Helper()
+
+	tp := csrf.NewDefaultTokenProviderConfig(context.Background(), cfg)
+	tb.Cleanup(func() { tp.Close() })
+	return tp
+}
+
+// post return POST request to `target` carrying `token` in [csrf.DefaultHeaderName]
+func post(target, token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, nil)
+	if token != "" {
+		r.Header.Set(csrf.DefaultHeaderName, token)
+	}
+
+	return r
+}
+
+// cookie return the cookie `name` set by `rec` or nil
+func cookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package csrf
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+type csrf_session_context_key int
+
+// WithSessionID return copy of `ctx` carrying the session identifier the token is bound to,
+// used by stateless providers such as [HMACTokenProvider]
+func WithSessionID(ctx context.Context, session_id string) context.Context {
+	return context.WithValue(ctx, csrf_session_context_key(0), session_id)
+}
+
+// SessionIDFromContext return session identifier set by [WithSessionID] or empty string
+func SessionIDFromContext(ctx context.Context) string {
+	session_id, _ := ctx.Value(csrf_session_context_key(0)).(string)
+	return session_id
+}
+
+// ErrEmptySecret is returned by [NewHMACTokenProvider] and [HMACTokenProvider.RotateKey] for nil or empty secret
+var ErrEmptySecret = errors.New("empty secret")
+
+const (
+	hmac_timestamp_size = 8
+	hmac_nonce_size     = 16
+	hmac_payload_size   = hmac_timestamp_size + hmac_nonce_size
+	hmac_token_size     = key_id_size + hmac_payload_size + sha256.Size
+)
+
+// HMACTokenProvider is stateless [TokenProvider], the token is `key id || timestamp || nonce || HMAC(secret, key id || timestamp || nonce || session id)`
+// and it is validated purely by its signature and expiry, so nothing is stored on the server.
+// the session id is taken from the context, see [WithSessionID].
+// since nothing is stored the token can not be consumed and stay valid until expired
+type HMACTokenProvider struct {
+	secrets   keyring[[]byte]
+	token_ttl time.Duration
+	clock     Clock
+}
+
+// HMACConfig for [NewHMACTokenProviderConfig]
+type HMACConfig struct {
+	// Secret sign new tokens
+	Secret []byte
+	// Previous secrets are only used to verify outstanding tokens
+	Previous [][]byte
+	// TTL of issued token, default to [DefaultTTL]
+	TTL time.Duration
+	// Clock default to [SystemClock]
+	Clock Clock
+}
+
+var (
+	_ TokenProvider    = (*HMACTokenProvider)(nil)
+	_ IssuedAtProvider = (*HMACTokenProvider)(nil)
+	_ TTLProvider      = (*HMACTokenProvider)(nil)
+)
+
+// NewHMACTokenProvider return [HMACTokenProvider] signing new tokens with `secret`,
+// the `previous` secrets are only used to verify outstanding tokens, see also [HMACTokenProvider.RotateKey].
+// it return [ErrEmptySecret] if any of the secrets is empty
+func NewHMACTokenProvider(secret []byte, token_ttl time.Duration, previous ...[]byte) (*HMACTokenProvider, error) {
+	return NewHMACTokenProviderConfig(HMACConfig{Secret: secret, Previous: previous, TTL: token_ttl})
+}
+
+// NewHMACTokenProviderConfig return [HMACTokenProvider] configured by `cfg`, see [NewHMACTokenProvider]
+func NewHMACTokenProviderConfig(cfg HMACConfig) (*HMACTokenProvider, error) {
+	secrets := append([][]byte{cfg.Secret}, cfg.Previous...)
+	for _, secret := range secrets {
+		if len(secret) == 0 {
+			return nil, ErrEmptySecret
+		}
+	}
+
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock{}
+	}
+
+	htp := &HMACTokenProvider{token_ttl: cfg.TTL, clock: cfg.Clock}
+	for _, secret := range secrets {
+		htp.secrets.keys = append(htp.secrets.keys, keyring_entry[[]byte]{id: derive_key_id(secret), key: secret})
+	}
+
+	return htp, nil
+}
+
+// RotateKey sign new tokens with `secret`, it return [ErrEmptySecret] for empty secret
+func (htp *HMACTokenProvider) RotateKey(secret []byte) error {
+	if len(secret) == 0 {
+		return ErrEmptySecret
+	}
+
+	htp.secrets.rotate(keyring_entry[[]byte]{id: derive_key_id(secret), key: secret})
+	return nil
+}
+
+func hmac_sign(secret, payload []byte, session_id string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	mac.Write([]byte(session_id))
+	return mac.Sum(nil)
+}
+
+func (htp *HMACTokenProvider) Get(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	current := htp.secrets.current()
+
+	buf := make([]byte, key_id_size+hmac_payload_size, hmac_token_size)
+	copy(buf, current.id[:])
+	binary.BigEndian.PutUint64(buf[key_id_size:], uint64(htp.clock.Now().Unix()))
+	if _, err := rand.Read(buf[key_id_size+hmac_timestamp_size:]); err != nil {
+		return "", entropy_error(err)
+	}
+
+	buf = append(buf, hmac_sign(current.key, buf, SessionIDFromContext(ctx))...)
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// verify return the issuance time of valid token
+func (htp *HMACTokenProvider) verify(ctx context.Context, token string) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, ErrInvalidToken
+	}
+
+	if len(buf) != hmac_token_size {
+		return time.Time{}, ErrInvalidToken
+	}
+
+	secret, ok := htp.secrets.lookup(buf[:key_id_size])
+	if !ok {
+		return time.Time{}, ErrInvalidToken
+	}
+
+	signed, signature := buf[:len(buf)-sha256.Size], buf[len(buf)-sha256.Size:]
+	if !hmac.Equal(signature, hmac_sign(secret, signed, SessionIDFromContext(ctx))) {
+		return time.Time{}, ErrInvalidToken
+	}
+
+	return time.Unix(int64(binary.BigEndian.Uint64(signed[key_id_size:])), 0), nil
+}
+
+func (htp *HMACTokenProvider) Check(ctx context.Context, token string) error {
+	issued_at, err := htp.verify(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if !htp.clock.Now().Before(issued_at.Add(htp.token_ttl)) {
+		return ErrTokenExpired
+	}
+
+	return nil
+}
+
+func (htp *HMACTokenProvider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	return htp.verify(ctx, token)
+}
+
+func (htp *HMACTokenProvider) TTL() time.Duration { return htp.token_ttl }
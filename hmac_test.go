@@ -0,0 +1,122 @@
+package csrf_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+var (
+	hmac_secret  = []byte("0123456789abcdef0123456789abcdef")
+	hmac_secret2 = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func new_hmac_provider(tb testing.TB, cfg csrf.HMACConfig) *csrf.HMACTokenProvider {
+	tb.Helper()
+
+	htp, err := csrf.NewHMACTokenProviderConfig(cfg)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return htp
+}
+
+func TestHMACTokenProvider(t *testing.T) {
+	clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+	htp := new_hmac_provider(t, csrf.HMACConfig{Secret: hmac_secret, TTL: time.Minute, Clock: clock})
+	alice := csrf.WithSessionID(context.Background(), "alice")
+	token := must_get(t, htp, alice)
+
+	if issued_at, err := htp.IssuedAt(alice, token); err != nil || !issued_at.Equal(clock.Now()) {
+		t.Errorf("IssuedAt = %v, %v, want %v", issued_at, err, clock.Now())
+	}
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		ctx     context.Context
+		err     error
+	}{
+		{"valid", 0, alice, nil},
+		{"other subject", 0, context.Background(), csrf.ErrInvalidToken},
+		{"before expiry", 59 * time.Second, alice, nil},
+		{"expired", time.Second, alice, csrf.ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		if err := htp.Check(tt.ctx, token); !errors.Is(err, tt.err) {
+			t.Errorf("Check %s = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestHMACTokenProviderKeys(t *testing.T) {
+	ctx := context.Background()
+	htp := new_hmac_provider(t, csrf.HMACConfig{Secret: hmac_secret})
+	if htp.TTL() != csrf.DefaultTTL {
+		t.Errorf("TTL = %v, want %v", htp.TTL(), csrf.DefaultTTL)
+	}
+
+	old := must_get(t, htp, ctx)
+	if err := htp.RotateKey(hmac_secret2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := htp.Check(ctx, old); err != nil {
+		t.Errorf("Check of token of previous secret = %v", err)
+	}
+
+	if err := htp.Check(ctx, must_get(t, htp, ctx)); err != nil {
+		t.Errorf("Check of token of current secret = %v", err)
+	}
+
+	// `timestamp || nonce || HMAC` without key id, as issued before key rotation was supported
+	payload := binary.BigEndian.AppendUint64(nil, uint64(time.Now().Unix()))
+	payload = append(payload, make([]byte, 16)...)
+	mac := hmac.New(sha256.New, hmac_secret)
+	mac.Write(payload)
+	legacy := base64.RawURLEncoding.EncodeToString(mac.Sum(payload))
+
+	for _, token := range []string{legacy, "", "AAAA", "not base64!"} {
+		if err := htp.Check(ctx, token); !errors.Is(err, csrf.ErrInvalidToken) {
+			t.Errorf("Check(%q) = %v, want %v", token, err, csrf.ErrInvalidToken)
+		}
+	}
+}
+
+func TestHMACTokenProviderEmptySecret(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  csrf.HMACConfig
+	}{
+		{"nil", csrf.HMACConfig{}},
+		{"empty", csrf.HMACConfig{Secret: []byte{}}},
+		{"empty previous", csrf.HMACConfig{Secret: hmac_secret, Previous: [][]byte{nil}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := csrf.NewHMACTokenProviderConfig(tt.cfg); !errors.Is(err, csrf.ErrEmptySecret) {
+				t.Errorf("NewHMACTokenProviderConfig = %v, want %v", err, csrf.ErrEmptySecret)
+			}
+		})
+	}
+
+	if _, err := csrf.NewHMACTokenProvider(nil, 0); !errors.Is(err, csrf.ErrEmptySecret) {
+		t.Errorf("NewHMACTokenProvider = %v, want %v", err, csrf.ErrEmptySecret)
+	}
+
+	if err := new_hmac_provider(t, csrf.HMACConfig{Secret: hmac_secret}).RotateKey(nil); !errors.Is(err, csrf.ErrEmptySecret) {
+		t.Errorf("RotateKey = %v, want %v", err, csrf.ErrEmptySecret)
+	}
+}
@@ -0,0 +1,87 @@
+package csrf
+
+import (
+	"context"
+	"time"
+)
+
+// TokenEvent describe token passed to [Hooks]
+type TokenEvent struct {
+	// Token as stored by the provider, it is the sha256 digest for [HashedTokenProvider]
+	Token string
+	// Subject is the session id the token is bound to, see [WithSessionID]
+	Subject  string
+	IssuedAt time.Time
+	ExpireAt time.Time
+}
+
+// Hooks are called on token lifecycle events, e.g. to emit audit events or to sync state with external system.
+// [DefaultTokenProvider] call them after releasing its lock so they may call back into the provider,
+// but they are called synchronously and slow hook delay the request or the gc
+type Hooks struct {
+	// OnIssue is called once new token is stored
+	OnIssue func(TokenEvent)
+	// OnConsume is called once single use token is consumed by CheckAndConsume or exchanged by Exchange
+	OnConsume func(TokenEvent)
+	// OnExpire is called for every expired token removed by the gc
+	OnExpire func(TokenEvent)
+}
+
+func call_hook(hook func(TokenEvent), events ...TokenEvent) {
+	if hook == nil {
+		return
+	}
+
+	for _, e := range events {
+		hook(e)
+	}
+}
+
+func (entry token_entry) event(token string) TokenEvent {
+	return TokenEvent{
+		Token:    token,
+		Subject:  entry.subject,
+		IssuedAt: time.Unix(0, entry.issued_at),
+		ExpireAt: time.Unix(entry.expire_at, 0),
+	}
+}
+
+// HookedProvider call [Hooks] around any [TokenProvider], the provider does not report expiry
+// so only OnIssue and OnConsume are called, with Token and Subject set
+type HookedProvider struct {
+	TokenProvider
+	hooks Hooks
+}
+
+var (
+	_ TokenProvider = (*HookedProvider)(nil)
+	_ Consumer      = (*HookedProvider)(nil)
+)
+
+// NewHookedProvider return [HookedProvider] calling `hooks` on tokens of `tp`
+func NewHookedProvider(tp TokenProvider, hooks Hooks) *HookedProvider {
+	return &HookedProvider{TokenProvider: tp, hooks: hooks}
+}
+
+func (hp *HookedProvider) Get(ctx context.Context) (string, error) {
+	token, err := hp.TokenProvider.Get(ctx)
+	if err == nil {
+		call_hook(hp.hooks.OnIssue, TokenEvent{Token: token, Subject: SessionIDFromContext(ctx)})
+	}
+
+	return token, err
+}
+
+func (hp *HookedProvider) CheckAndConsume(ctx context.Context, token string) error {
+	c, ok := hp.TokenProvider.(Consumer)
+	if !ok {
+		return hp.TokenProvider.Check(ctx, token)
+	}
+
+	err := c.CheckAndConsume(ctx, token)
+	if err == nil {
+		call_hook(hp.hooks.OnConsume, TokenEvent{Token: token, Subject: SessionIDFromContext(ctx)})
+	}
+
+	return err
+}
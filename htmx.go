@@ -0,0 +1,39 @@
+package csrf
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+)
+
+// HTMXSource return token from the header named by [WithHeaderName] only for htmx requests,
+// which carry `HX-Request: true`
+func (c *CSRF) HTMXSource() TokenSourceFunc {
+	name := c.header_name
+	return func(r *http.Request) string {
+		if r.Header.Get("HX-Request") != "true" {
+			return ""
+		}
+
+		return r.Header.Get(name)
+	}
+}
+
+// HTMXHeaders return `hx-headers` attribute sending `token` in the header named by [WithHeaderName]
+// with every htmx request of the element and its descendants, e.g. `<body {{ .CSRFHeaders }}>`
+func (c *CSRF) HTMXHeaders(token string) template.HTMLAttr {
+	b, _ := json.Marshal(map[string]string{c.header_name: token})
+	return template.HTMLAttr(`hx-headers="` + template.HTMLEscapeString(string(b)) + `"`)
+}
+
+// HTMXMiddleware is [CSRF.TokenMiddleware] which also write the token to the response header named by [WithHeaderName]
+// for htmx requests, so partial updates can pick up fresh token after each swap
+func (c *CSRF) HTMXMiddleware(next http.Handler) http.Handler {
+	return c.TokenMiddleware(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("HX-Request") == "true" {
+			w.Header().Set(c.header_name, TokenFromContext(r.Context()))
+		}
+
+		next.ServeHTTP(w, r)
+	}))
+}
@@ -0,0 +1,96 @@
+package csrf_test
+
+import (
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestHTMXSource(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}))
+	source := c.HTMXSource()
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"htmx", "true", "token"},
+		{"not htmx", "", ""},
+		{"htmx false", "false", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := post("/", "token")
+			if tt.header != "" {
+				r.Header.Set("HX-Request", tt.header)
+			}
+
+			if got := source(r); got != tt.want {
+				t.Errorf("HTMXSource = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMXHeaders(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithHeaderName("X-Token"))
+
+	got := string(c.HTMXHeaders(`a"b`))
+	if want := `hx-headers="` + html.EscapeString(`{"X-Token":"a\"b"}`) + `"`; got != want {
+		t.Errorf("HTMXHeaders = %s, want %s", got, want)
+	}
+}
+
+func TestHTMXMiddleware(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}))
+
+	tests := []struct {
+		name    string
+		htmx    bool
+		exposed bool
+	}{
+		{"htmx", true, true},
+		{"not htmx", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token string
+			h := c.HTMXMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				token = csrf.TokenFromContext(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.htmx {
+				r.Header.Set("HX-Request", "true")
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+			if token == "" {
+				t.Fatal("no token in context")
+			}
+
+			if got := rec.Header().Get(csrf.DefaultHeaderName); (got == token) != tt.exposed {
+				t.Errorf("response header = %q, token %q, want exposed %v", got, token, tt.exposed)
+			}
+		})
+	}
+
+	// the exposed token validate the next htmx request
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("HX-Request", "true")
+	_, rec := csrftest.Serve(c.HTMXMiddleware, r)
+
+	next := post("/", rec.Header().Get(csrf.DefaultHeaderName))
+	next.Header.Set("HX-Request", "true")
+	if err := c.Validate(next, c.HTMXSource()); err != nil {
+		t.Errorf("Validate of exposed token = %v", err)
+	}
+}
@@ -0,0 +1,328 @@
+package csrf
+
+import (
+	"bytes"
+	"html"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// max_pending is the longest incomplete tag held back between writes before it is passed through as is
+const max_pending = 64 << 10
+
+// InjectFormField is opt-in middleware which add hidden input named by [WithFormField]
+// into every `<form method="post">` of `text/html` response, so existing templates need no change.
+// the response is rewritten while streaming, only incomplete tag at the end of each write is held back.
+// the token is taken from [TokenFromContext] or issued on the first form, in [SingleUse] mode every other form
+// get its own token as the first submit consume it. forms posting to other host, and markup inside comments
+// and raw text elements like `<script>` are left as is. if the token can not be issued, Write return the error and
+// the rest of the response is dropped so no form is served without token. compressed responses are not rewritten
+func (c *CSRF) InjectFormField(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		iw := &inject_writer{ResponseWriter: w, c: c, r: r, token: TokenFromContext(r.Context())}
+		defer iw.flush_pending()
+
+		next.ServeHTTP(iw, r)
+	})
+}
+
+type inject_writer struct {
+	http.ResponseWriter
+	c     *CSRF
+	r     *http.Request
+	token string
+
+	wrote_header bool
+	rewrite      bool
+	pending      []byte
+	err          error
+	// raw_end is the end of the comment or raw text element being written, e.g. `</script`
+	raw_end string
+}
+
+func (iw *inject_writer) Unwrap() http.ResponseWriter { return iw.ResponseWriter }
+
+func (iw *inject_writer) WriteHeader(code int) {
+	if iw.wrote_header {
+		return
+	}
+
+	iw.wrote_header = true
+	h := iw.Header()
+	media_type, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	if media_type == "text/html" && h.Get("Content-Encoding") == "" {
+		iw.rewrite = true
+		h.Del("Content-Length")
+	}
+
+	iw.ResponseWriter.WriteHeader(code)
+}
+
+func (iw *inject_writer) Write(p []byte) (int, error) {
+	if !iw.wrote_header {
+		if iw.Header().Get("Content-Type") == "" {
+			iw.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+
+		iw.WriteHeader(http.StatusOK)
+	}
+
+	if !iw.rewrite {
+		return iw.ResponseWriter.Write(p)
+	}
+
+	if iw.err != nil {
+		return 0, iw.err
+	}
+
+	iw.pending = append(iw.pending, p...)
+	out, rest, err := iw.inject(iw.pending)
+	if err != nil {
+		iw.err, iw.pending = err, nil
+		return 0, err
+	}
+
+	if _, err := iw.ResponseWriter.Write(out); err != nil {
+		return 0, err
+	}
+
+	iw.pending = append(iw.pending[:0], rest...)
+	return len(p), nil
+}
+
+// Flush write the processed output, incomplete tag is still held back
+func (iw *inject_writer) Flush() {
+	if f, ok := iw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (iw *inject_writer) flush_pending() {
+	if iw.err == nil && len(iw.pending) > 0 {
+		_, _ = iw.ResponseWriter.Write(iw.pending)
+		iw.pending = nil
+	}
+}
+
+// inject return the rewritten complete part of `buf` and the incomplete tag at its end
+func (iw *inject_writer) inject(buf []byte) (out, rest []byte, err error) {
+	last := 0
+	for i := 0; i < len(buf); {
+		if iw.raw_end != "" {
+			j := index_fold(buf[i:], iw.raw_end)
+			if j < 0 {
+				// the end may be split between writes
+				cut := max(i, len(buf)-len(iw.raw_end)+1)
+				return append(out, buf[last:cut]...), buf[cut:], nil
+			}
+
+			i += j + len(iw.raw_end)
+			iw.raw_end = ""
+			continue
+		}
+
+		j := bytes.IndexByte(buf[i:], '<')
+		if j < 0 {
+			break
+		}
+
+		start := i + j
+		end := -1
+		switch {
+		case bytes.HasPrefix(buf[start:], comment_start):
+			iw.raw_end, i = "-->", start+len(comment_start)
+			continue
+		case bytes.HasPrefix(comment_start, buf[start:]):
+			// incomplete comment start
+		case !is_ascii_letter(buf[start+1]):
+			i = start + 1
+			continue
+		default:
+			end = tag_end(buf[start:])
+		}
+
+		if end < 0 {
+			if len(buf)-start <= max_pending {
+				return append(out, buf[last:start]...), buf[start:], nil
+			}
+
+			// too long to be held back, passed through as is
+			break
+		}
+
+		i = start + end
+		name, attrs := split_tag(string(buf[start:i]))
+		if is_raw_text(name) {
+			iw.raw_end = "</" + name
+			continue
+		}
+
+		if !is_post_form(name, attrs) || !iw.same_host(attrs) {
+			continue
+		}
+
+		token, err := iw.form_token()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		out = append(out, buf[last:i]...)
+		out = append(out, hidden_field(iw.c.form_field, token)...)
+		last = i
+	}
+
+	return append(out, buf[last:]...), nil, nil
+}
+
+var comment_start = []byte("<!--")
+
+// same_host report whether the form with `attrs` is submitted to the host of the request,
+// relative and missing action are
+func (iw *inject_writer) same_host(attrs string) bool {
+	action, _ := tag_attribute(attrs, "action")
+	u, err := url.Parse(strings.TrimSpace(html.UnescapeString(action)))
+	if err != nil {
+		return false
+	}
+
+	return u.Host == "" || strings.EqualFold(u.Host, iw.r.Host)
+}
+
+// form_token return the token of the next form
+func (iw *inject_writer) form_token() (string, error) {
+	token := iw.token
+	if token == "" {
+		var err error
+		if token, err = iw.c.GetToken(iw.c.request_context(iw.r)); err != nil {
+			return "", err
+		}
+	}
+
+	// single use token is consumed by the first submit, so it is not shared with the other forms
+	iw.token = ""
+	if !iw.c.consumes() {
+		iw.token = token
+	}
+
+	return token, nil
+}
+
+func is_ascii_letter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// tag_end return the length of the tag at the start of `b` including the closing `>`, or -1 if it is incomplete.
+// `>` inside quoted attribute value does not end the tag
+func tag_end(b []byte) int {
+	var quote, prev byte
+	for i := 1; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && prev == '=':
+			quote = c
+		case c == '>':
+			return i + 1
+		}
+
+		if !is_html_space(c) {
+			prev = c
+		}
+	}
+
+	return -1
+}
+
+func is_html_space(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// split_tag return the lower case name and the attributes part of complete `tag`
+func split_tag(tag string) (name, attrs string) {
+	// drop `<` and `>`
+	tag = tag[1 : len(tag)-1]
+	name_end := strings.IndexAny(tag, " \t\n\f\r/")
+	if name_end < 0 {
+		name_end = len(tag)
+	}
+
+	return strings.ToLower(tag[:name_end]), tag[name_end:]
+}
+
+// is_raw_text report whether the content of element `name` is not parsed as markup
+func is_raw_text(name string) bool {
+	switch name {
+	case "script", "style", "textarea", "title":
+		return true
+	}
+
+	return false
+}
+
+// is_post_form report whether tag `name` with `attrs` is `<form>` with method attribute `post`
+func is_post_form(name, attrs string) bool {
+	if name != "form" {
+		return false
+	}
+
+	method, _ := tag_attribute(attrs, "method")
+	return strings.EqualFold(method, "post")
+}
+
+// index_fold is [bytes.Index] of ascii `sep` ignoring case
+func index_fold(b []byte, sep string) int {
+	needle := []byte(sep)
+	for i := 0; i+len(needle) <= len(b); i++ {
+		if bytes.EqualFold(b[i:i+len(needle)], needle) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// tag_attribute return the value of attribute `name` from the attributes part of a tag
+func tag_attribute(attrs, name string) (string, bool) {
+	for attrs != "" {
+		before := len(attrs)
+		attrs = strings.TrimLeft(attrs, " \t\n\f\r/")
+
+		key_end := strings.IndexAny(attrs, " \t\n\f\r/=")
+		if key_end < 0 {
+			key_end = len(attrs)
+		}
+
+		key := attrs[:key_end]
+		attrs = strings.TrimLeft(attrs[key_end:], " \t\n\f\r")
+
+		value := ""
+		if strings.HasPrefix(attrs, "=") {
+			attrs = strings.TrimLeft(attrs[1:], " \t\n\f\r")
+			if attrs != "" && (attrs[0] == '"' || attrs[0] == '\'') {
+				value, attrs, _ = strings.Cut(attrs[1:], attrs[:1])
+			} else {
+				value_end := strings.IndexAny(attrs, " \t\n\f\r")
+				if value_end < 0 {
+					value_end = len(attrs)
+				}
+
+				value, attrs = attrs[:value_end], attrs[value_end:]
+			}
+		}
+
+		if key != "" && strings.EqualFold(key, name) {
+			return value, true
+		}
+
+		if len(attrs) == before {
+			attrs = attrs[1:]
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,119 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+var injected_field = regexp.MustCompile(`<input type="hidden" name="` + csrf.DefaultFormField + `" value="([^"]*)">`)
+
+// get_down_provider is [csrf.DefaultTokenProvider] which can not issue token
+type get_down_provider struct {
+	*csrf.DefaultTokenProvider
+}
+
+func (get_down_provider) Get(context.Context) (string, error) { return "", errDown }
+
+// serve_html return the body of `chunks` written one by one through [csrf.CSRF.InjectFormField]
+// along with the first write error
+func serve_html(c *csrf.CSRF, chunks ...string) (string, error) {
+	var write_err error
+	handler := c.InjectFormField(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		for _, chunk := range chunks {
+			if _, err := w.Write([]byte(chunk)); err != nil && write_err == nil {
+				write_err = err
+			}
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec.Body.String(), write_err
+}
+
+func TestInjectFormField(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		forms  int
+	}{
+		{"post form", []string{`<form method="post"></form>`}, 1},
+		{"get form", []string{`<form method="get"></form><form></form>`}, 0},
+		{"case insensitive", []string{`<FORM METHOD=POST></FORM>`}, 1},
+		{"quoted gt", []string{`<form action="/a?x=>" method="post"></form>`}, 1},
+		{"method in other attribute", []string{`<form data-x=" method=post" method="get"></form>`}, 0},
+		{"form in attribute", []string{`<a title="<form method=post>">x</a>`}, 0},
+		{"not a tag", []string{`1 < 2 <form method=post>`}, 1},
+		{"split tag", []string{`<p>a</p><fo`, `rm meth`, `od="post" action=">">`, `</form>`}, 1},
+		{"multiple forms", []string{`<form method=post></form><form method='post'></form>`}, 2},
+		{"same host action", []string{`<form method=post action="https://EXAMPLE.com/a"></form>`}, 1},
+		{"other host action", []string{`<form method=post action="https://evil.test/a"></form>`}, 0},
+		{"protocol relative action", []string{`<form method=post action=" //evil.test/a"></form>`}, 0},
+		{"escaped action", []string{`<form method=post action="&#47;&#47;evil.test/a"></form>`}, 0},
+		{"comment", []string{`<!-- <form method=post> --><form method=post>`}, 1},
+		{"split comment", []string{`<!`, `-- <form method=post> -`, `-><form method=post>`}, 1},
+		{"script", []string{`<script>let s = "<form method=post>"</script><form method=post>`}, 1},
+		{"split script end", []string{`<SCRIPT type=module>"<form method=post>"</scr`, `ipt><form method=post>`}, 1},
+		{"textarea", []string{`<textarea><form method=post></textarea>`}, 0},
+		{"style", []string{`<style>/* <form method=post> */</style>`}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := csrf.New(new_provider(t, csrf.Config{}))
+			body, err := serve_html(c, tt.chunks...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			matches := injected_field.FindAllStringSubmatch(body, -1)
+			if len(matches) != tt.forms {
+				t.Fatalf("injected %d fields, want %d in %s", len(matches), tt.forms, body)
+			}
+
+			if got, want := injected_field.ReplaceAllString(body, ""), strings.Join(tt.chunks, ""); got != want {
+				t.Errorf("body without injected fields = %s, want %s", got, want)
+			}
+
+			// every form can be submitted in single use mode
+			for _, m := range matches {
+				if err := c.Validate(post("/", m[1]), csrf.HeaderTokenSource); err != nil {
+					t.Errorf("Validate of injected token = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestInjectFormFieldMultiUse(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithTokenMode(csrf.MultiUse))
+	body, err := serve_html(c, `<form method=post></form><form method=post></form>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches := injected_field.FindAllStringSubmatch(body, -1)
+	if !(len(matches) == 2 && matches[0][1] == matches[1][1]) {
+		t.Fatalf("injected fields = %v, want the same token twice", matches)
+	}
+}
+
+func TestInjectFormFieldIssueError(t *testing.T) {
+	c := csrf.New(get_down_provider{new_provider(t, csrf.Config{})})
+	body, err := serve_html(c, `<p>a</p><form method=post>`, `</form>`)
+	if !errors.Is(err, errDown) {
+		t.Fatalf("Write = %v, want %v", err, errDown)
+	}
+
+	if strings.Contains(body, "<form") {
+		t.Errorf("form served without token: %s", body)
+	}
+}
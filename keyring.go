@@ -0,0 +1,84 @@
+package csrf
+
+import (
+	"crypto/sha256"
+	"errors"
+	"sync"
+)
+
+var ErrKeyRotationNotSupported = errors.New("token provider does not support key rotation")
+
+// KeyRotator is optional interface for [TokenProvider] which sign or encrypt the token with secret key
+type KeyRotator interface {
+	// RotateKey make `key` the current key for new tokens, the previous current key stay valid
+	// to verify outstanding tokens and any older key is dropped, so it must not be called more often than the token ttl
+	RotateKey(key []byte) error
+}
+
+var (
+	_ KeyRotator = (*HMACTokenProvider)(nil)
+	_ KeyRotator = (*EncryptedTokenProvider)(nil)
+)
+
+const key_id_size = 4
+
+type key_id = [key_id_size]byte
+
+// derive_key_id return identifier of `key` embedded in the token to pick the verifying key
+func derive_key_id(key []byte) key_id {
+	sum := sha256.Sum256(append([]byte("csrf key id\x00"), key...))
+	return key_id(sum[:key_id_size])
+}
+
+type keyring_entry[T any] struct {
+	id  key_id
+	key T
+}
+
+// keyring hold the keys of signing and encrypting providers, keys[0] is the current key
+type keyring[T any] struct {
+	mu   sync.RWMutex
+	keys []keyring_entry[T]
+}
+
+// current return the key for new tokens
+func (kr *keyring[T]) current() keyring_entry[T] {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	return kr.keys[0]
+}
+
+// lookup return the key with identifier `id`
+func (kr *keyring[T]) lookup(id []byte) (T, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	for _, e := range kr.keys {
+		if string(e.id[:]) == string(id) {
+			return e.key, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+// rotate make `e` the current key and keep only the previous current key
+func (kr *keyring[T]) rotate(e keyring_entry[T]) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	kr.keys = []keyring_entry[T]{e, kr.keys[0]}
+}
+
+// RotateKey rotate the key of the [TokenProvider] if it implements [KeyRotator],
+// otherwise [ErrKeyRotationNotSupported] is returned
+func (c *CSRF) RotateKey(key []byte) error {
+	kr, ok := c.TokenProvider.(KeyRotator)
+	if !ok {
+		return ErrKeyRotationNotSupported
+	}
+
+	return kr.RotateKey(key)
+}
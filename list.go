@@ -0,0 +1,163 @@
+package csrf
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrListNotSupported = errors.New("token provider does not support listing")
+
+// TokenInfo describe stored token returned by [Lister]
+type TokenInfo struct {
+	// ID is the fingerprint of the token, see [TokenID], so the listing never expose token which could be submitted.
+	// it is accepted by [IDRevoker]
+	ID string
+	// Subject is the session id the token is bound to, see [WithSessionID]
+	Subject  string
+	IssuedAt time.Time
+	ExpireAt time.Time
+}
+
+// Lister is optional interface for [TokenProvider] which can iterate its outstanding tokens,
+// e.g. for ops dashboard which inspect and bulk revoke them through [Revoker]
+type Lister interface {
+	// List return up to `limit` valid tokens after `cursor` in stable order, ordered by ID unless documented otherwise.
+	// empty cursor start from the first token and `next` is the cursor of the next page, empty once there is no more token
+	List(ctx context.Context, cursor string, limit int) (tokens []TokenInfo, next string, err error)
+}
+
+var (
+	_ Lister = (*DefaultTokenProvider)(nil)
+	_ Lister = (*HashedTokenProvider)(nil)
+	_ Lister = (*ShardedTokenProvider)(nil)
+	_ Lister = (*SQLTokenProvider)(nil)
+)
+
+// TokenID return the fingerprint of `token` used as [TokenInfo.ID], it is hex encoded sha256 digest
+func TokenID(token string) string {
+	return hex.EncodeToString([]byte(hash_token(token)))
+}
+
+// NextCursor return the cursor of the page after `tokens` fetched with `limit`, empty if it is the last page
+func NextCursor(tokens []TokenInfo, limit int) string {
+	if len(tokens) == 0 || len(tokens) < limit {
+		return ""
+	}
+
+	return tokens[len(tokens)-1].ID
+}
+
+// list return up to `limit` valid tokens of the current generation with ID after `cursor`, ordered by ID.
+// `id` return the ID of the stored key. the keys are not kept in ID order, so every page hash every token
+// and sort the ones after the cursor, it cost O(n log n) per page and is meant for ops tooling, not hot path
+func (dtp *DefaultTokenProvider) list(ctx context.Context, cursor string, limit int, id func(key string) string) ([]TokenInfo, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	now := dtp.clock.Now().Unix() - dtp.grace_seconds()
+	generation := dtp.generation.Load()
+
+	dtp.mu.RLock()
+	defer dtp.mu.RUnlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var tokens []TokenInfo
+	for key, entry := range dtp.tokens {
+		if entry.expire_at <= now || entry.generation != generation {
+			continue
+		}
+
+		if key_id := id(key); key_id > cursor {
+			tokens = append(tokens, TokenInfo{
+				ID:       key_id,
+				Subject:  entry.subject,
+				IssuedAt: time.Unix(0, entry.issued_at),
+				ExpireAt: time.Unix(entry.expire_at, 0),
+			})
+		}
+	}
+
+	slices.SortFunc(tokens, func(a, b TokenInfo) int { return strings.Compare(a.ID, b.ID) })
+	return tokens[:min(limit, len(tokens))], nil
+}
+
+// List see [DefaultTokenProvider.list] for its cost
+func (dtp *DefaultTokenProvider) List(ctx context.Context, cursor string, limit int) ([]TokenInfo, string, error) {
+	tokens, err := dtp.list(ctx, cursor, limit, TokenID)
+	return tokens, NextCursor(tokens, limit), err
+}
+
+// List the hex encoded keys, which are the [TokenID] of the tokens
+func (htp *HashedTokenProvider) List(ctx context.Context, cursor string, limit int) ([]TokenInfo, string, error) {
+	tokens, err := htp.dtp.list(ctx, cursor, limit, func(key string) string { return hex.EncodeToString([]byte(key)) })
+	return tokens, NextCursor(tokens, limit), err
+}
+
+// List merge the pages of every shard
+func (stp *ShardedTokenProvider) List(ctx context.Context, cursor string, limit int) ([]TokenInfo, string, error) {
+	var tokens []TokenInfo
+	for _, shard := range stp.shards {
+		page, err := shard.list(ctx, cursor, limit, TokenID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		tokens = append(tokens, page...)
+	}
+
+	slices.SortFunc(tokens, func(a, b TokenInfo) int { return strings.Compare(a.ID, b.ID) })
+	tokens = tokens[:min(limit, len(tokens))]
+	return tokens, NextCursor(tokens, limit), nil
+}
+
+func (stp *SQLTokenProvider) List(ctx context.Context, cursor string, limit int) ([]TokenInfo, string, error) {
+	if limit <= 0 {
+		return nil, "", nil
+	}
+
+	rows, err := stp.db.QueryContext(ctx, stp.list_query, cursor, stp.valid_after(), limit)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var tokens []TokenInfo
+	for rows.Next() {
+		var (
+			info                 TokenInfo
+			expire_at, issued_at int64
+		)
+		if err := rows.Scan(&info.ID, &info.Subject, &expire_at, &issued_at); err != nil {
+			return nil, "", err
+		}
+
+		info.Subject = sql_unsubject.Replace(info.Subject)
+		info.ExpireAt, info.IssuedAt = time.Unix(expire_at, 0), time.Unix(0, issued_at)
+		tokens = append(tokens, info)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	return tokens, NextCursor(tokens, limit), nil
+}
+
+// List the outstanding tokens if the [TokenProvider] implements [Lister],
+// otherwise [ErrListNotSupported] is returned
+func (c *CSRF) List(ctx context.Context, cursor string, limit int) ([]TokenInfo, string, error) {
+	lister, ok := c.TokenProvider.(Lister)
+	if !ok {
+		return nil, "", ErrListNotSupported
+	}
+
+	return lister.List(ctx, cursor, limit)
+}
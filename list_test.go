@@ -0,0 +1,97 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+type listing_provider interface {
+	csrf.TokenProvider
+	csrf.Lister
+	csrf.IDRevoker
+}
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		name string
+		tp   func(t *testing.T) listing_provider
+	}{
+		{"default", func(t *testing.T) listing_provider { return new_provider(t, csrf.Config{}) }},
+		{"hashed", func(t *testing.T) listing_provider {
+			htp := csrf.NewHashedTokenProviderConfig(context.Background(), csrf.Config{})
+			t.Cleanup(func() { htp.Close() })
+			return htp
+		}},
+		{"sharded", func(t *testing.T) listing_provider { return new_sharded_provider(t, csrf.Config{Shards: 3}) }},
+		{"sql", func(t *testing.T) listing_provider {
+			stp, _ := new_sql_provider(t)
+			return stp
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := tt.tp(t)
+			ctx := csrf.WithSessionID(context.Background(), "alice")
+
+			var tokens, want []string
+			for range 5 {
+				token := must_get(t, tp, ctx)
+				tokens = append(tokens, token)
+				want = append(want, csrf.TokenID(token))
+			}
+			slices.Sort(want)
+
+			var got []string
+			for cursor := ""; ; {
+				page, next, err := tp.List(context.Background(), cursor, 2)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, info := range page {
+					if info.Subject != "alice" {
+						t.Errorf("Subject = %q, want alice", info.Subject)
+					}
+					got = append(got, info.ID)
+				}
+
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+
+			if !slices.Equal(got, want) {
+				t.Fatalf("listed %v, want %v", got, want)
+			}
+
+			if err := tp.RevokeID(context.Background(), csrf.TokenID(tokens[0])); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tp.Check(ctx, tokens[0]); !errors.Is(err, csrf.ErrTokenNotFound) {
+				t.Errorf("Check of revoked token = %v, want %v", err, csrf.ErrTokenNotFound)
+			}
+
+			if err := tp.Check(ctx, tokens[1]); err != nil {
+				t.Errorf("Check of other token = %v", err)
+			}
+
+			if err := tp.RevokeID(context.Background(), "unknown"); err != nil {
+				t.Errorf("RevokeID of unknown id = %v", err)
+			}
+		})
+	}
+}
+
+func TestCSRFRevokeIDNotSupported(t *testing.T) {
+	c := csrf.New(new_hmac_provider(t, csrf.HMACConfig{Secret: hmac_secret}))
+	if err := c.RevokeID(context.Background(), "id"); !errors.Is(err, csrf.ErrRevokeNotSupported) {
+		t.Fatalf("RevokeID = %v, want %v", err, csrf.ErrRevokeNotSupported)
+	}
+}
@@ -0,0 +1,45 @@
+package csrf
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// WithMasking make [CSRF.GetToken] return [MaskToken] encoding of the token and [CSRF.Validate] unmask
+// the token from every source before checking it, so the same token never appear twice in responses (BREACH mitigation)
+func WithMasking() Option {
+	return func(c *CSRF) {
+		c.masking = true
+	}
+}
+
+// MaskToken return one-time encoding `base64url(pad || token XOR pad)` of the token with random pad
+func MaskToken(token string) (string, error) {
+	buf := make([]byte, 2*len(token))
+	pad := buf[:len(token)]
+	if _, err := rand.Read(pad); err != nil {
+		return "", entropy_error(err)
+	}
+
+	for i := range len(token) {
+		buf[len(token)+i] = token[i] ^ pad[i]
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// UnmaskToken reverse [MaskToken] or return empty string if `masked` is malformed
+func UnmaskToken(masked string) string {
+	buf, err := base64.RawURLEncoding.DecodeString(masked)
+	if err != nil || len(buf) == 0 || len(buf)%2 != 0 {
+		return ""
+	}
+
+	n := len(buf) / 2
+	token := make([]byte, n)
+	for i := range n {
+		token[i] = buf[i] ^ buf[n+i]
+	}
+
+	return string(token)
+}
@@ -0,0 +1,24 @@
+package csrf
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// MetaTag return html meta tag `<meta name="csrf-token" content="...">` for the given token
+func MetaTag(token string) template.HTML {
+	return template.HTML(`<meta name="csrf-token" content="` + template.HTMLEscapeString(token) + `">`)
+}
+
+// GetTokenWithMeta return new token and its [MetaTag], the same token is also set as cookie
+// named `cookie_name` so both SSR template and JS framework see the same token
+func (c *CSRF) GetTokenWithMeta(w http.ResponseWriter, r *http.Request, cookie_name string) (string, template.HTML, error) {
+	token, err := c.GetToken(c.request_context(r))
+	if err != nil {
+		return "", "", err
+	}
+
+	http.SetCookie(w, token_cookie(r, cookie_name, token, false))
+
+	return token, MetaTag(token), nil
+}
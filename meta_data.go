@@ -0,0 +1,182 @@
+package csrf
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"time"
+)
+
+var ErrMetaNotSupported = errors.New("token provider does not support metadata")
+
+// TokenProviderWithMeta is optional interface for [TokenProvider] which can store metadata alongside the token,
+// e.g. intended form action or user id for per action scoping and audit logs
+type TokenProviderWithMeta interface {
+	GetWithMeta(ctx context.Context, meta map[string]string) (string, error)
+	// CheckWithMeta is Check which also return the metadata of valid token, it must not delete the token
+	CheckWithMeta(ctx context.Context, token string) (map[string]string, error)
+}
+
+// MetaConsumer is optional interface for [TokenProviderWithMeta] which can consume token and return its metadata
+// in single call, [CSRF.ValidateWithMeta] require it in [SingleUse] mode if the provider implements [Consumer]
+type MetaConsumer interface {
+	CheckAndConsumeWithMeta(ctx context.Context, token string) (map[string]string, error)
+}
+
+func (dtp *DefaultTokenProvider) GetWithMeta(ctx context.Context, meta map[string]string) (string, error) {
+	token, err := GenerateToken(dtp.generate)
+	if err != nil {
+		return "", err
+	}
+
+	if err := dtp.store_meta(ctx, token, meta); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (dtp *DefaultTokenProvider) store_meta(ctx context.Context, token string, meta map[string]string) error {
+	dtp.mu.Lock()
+	err := dtp.store_locked(ctx, token)
+	entry := dtp.tokens[token]
+	if err == nil {
+		entry.meta = maps.Clone(meta)
+		dtp.tokens[token] = entry
+	}
+	dtp.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	call_hook(dtp.hooks.OnIssue, entry.event(token))
+	return nil
+}
+
+func (dtp *DefaultTokenProvider) CheckWithMeta(ctx context.Context, token string) (map[string]string, error) {
+	defer dtp.lock_check()()
+
+	if err := dtp.check(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return maps.Clone(dtp.tokens[token].meta), nil
+}
+
+func (dtp *DefaultTokenProvider) CheckAndConsumeWithMeta(ctx context.Context, token string) (map[string]string, error) {
+	dtp.mu.Lock()
+	err := dtp.check(ctx, token)
+	consumed := dtp.tokens[token]
+	if err == nil {
+		dtp.delete_locked(token)
+	}
+	dtp.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	call_hook(dtp.hooks.OnConsume, consumed.event(token))
+	// the entry is gone, its metadata is no longer shared
+	return consumed.meta, nil
+}
+
+func (htp *HashedTokenProvider) GetWithMeta(ctx context.Context, meta map[string]string) (string, error) {
+	token, err := GenerateToken(htp.dtp.generate)
+	if err != nil {
+		return "", err
+	}
+
+	if err := htp.dtp.store_meta(ctx, hash_token(token), meta); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (htp *HashedTokenProvider) CheckWithMeta(ctx context.Context, token string) (map[string]string, error) {
+	if token == "" {
+		return nil, ErrTokenMissing
+	}
+
+	return htp.dtp.CheckWithMeta(ctx, hash_token(token))
+}
+
+func (htp *HashedTokenProvider) CheckAndConsumeWithMeta(ctx context.Context, token string) (map[string]string, error) {
+	if token == "" {
+		return nil, ErrTokenMissing
+	}
+
+	return htp.dtp.CheckAndConsumeWithMeta(ctx, hash_token(token))
+}
+
+// IssueWithMeta is [CSRF.Issue] storing `meta` alongside the token,
+// the [TokenProvider] must implement [TokenProviderWithMeta] otherwise [ErrMetaNotSupported] is returned
+func (c *CSRF) IssueWithMeta(ctx context.Context, meta map[string]string) (token Token, err error) {
+	ctx, span := c.tracer.Start(ctx, "csrf.IssueWithMeta")
+	defer func() { end_span(span, err) }()
+
+	tpm, ok := c.TokenProvider.(TokenProviderWithMeta)
+	if !ok {
+		return Token{}, ErrMetaNotSupported
+	}
+
+	now := c.clock.Now()
+	value, err := tpm.GetWithMeta(ctx, meta)
+	if err != nil {
+		return Token{}, err
+	}
+
+	if c.masking {
+		if value, err = MaskToken(value); err != nil {
+			return Token{}, err
+		}
+	}
+
+	token = c.new_token(ctx, value, now)
+	token.Meta = maps.Clone(meta)
+	return token, nil
+}
+
+// ValidateWithMeta is [CSRF.Validate] which also return the metadata of the token, it is read by the same provider call
+// which check (or consume in [SingleUse] mode) the token. the [TokenProvider] must implement [TokenProviderWithMeta],
+// and [MetaConsumer] too if it implements [Consumer] in [SingleUse] mode, otherwise [ErrMetaNotSupported] is returned
+func (c *CSRF) ValidateWithMeta(r *http.Request, sources ...TokenSourceFunc) (meta map[string]string, err error) {
+	ctx, span := c.tracer.Start(c.with_scope(c.request_context(r), r), "csrf.Validate")
+	var supplied supplied_token
+	defer func() {
+		end_span(span, err)
+		err = c.report(r, supplied, err)
+	}()
+
+	if _, ok := c.TokenProvider.(TokenProviderWithMeta); !ok {
+		return nil, ErrMetaNotSupported
+	}
+
+	if _, ok := c.TokenProvider.(MetaConsumer); c.consumes() && !ok {
+		return nil, ErrMetaNotSupported
+	}
+
+	supplied, err = c.validate(ctx, r, sources, func(ctx context.Context, token string) (err error) {
+		meta, err = c.check_meta(ctx, token)
+		return err
+	})
+	return meta, err
+}
+
+// check_meta is [CSRF.check] which also return the metadata of the token
+func (c *CSRF) check_meta(ctx context.Context, token string) (map[string]string, error) {
+	defer func(start time.Time) { c.metrics.ProviderLatency("check", time.Since(start)) }(time.Now())
+
+	if mc, ok := c.TokenProvider.(MetaConsumer); ok && c.consumes() {
+		return provider_span(ctx, c, "CheckAndConsumeWithMeta", func(ctx context.Context) (map[string]string, error) {
+			return mc.CheckAndConsumeWithMeta(ctx, token)
+		})
+	}
+
+	return provider_span(ctx, c, "CheckWithMeta", func(ctx context.Context) (map[string]string, error) {
+		return c.TokenProvider.(TokenProviderWithMeta).CheckWithMeta(ctx, token)
+	})
+}
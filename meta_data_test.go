@@ -0,0 +1,104 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+// down_meta_provider is [csrf.DefaultTokenProvider] which fail to consume the token with its metadata
+type down_meta_provider struct {
+	*csrf.DefaultTokenProvider
+}
+
+func (down_meta_provider) CheckAndConsumeWithMeta(context.Context, string) (map[string]string, error) {
+	return nil, errDown
+}
+
+func TestValidateWithMeta(t *testing.T) {
+	meta := map[string]string{"action": "/account/delete"}
+
+	tests := []struct {
+		name   string
+		tp     func(t *testing.T) csrf.TokenProvider
+		opts   []csrf.Option
+		meta   map[string]string
+		err    error
+		reused error
+	}{
+		{
+			name:   "single use",
+			tp:     func(t *testing.T) csrf.TokenProvider { return new_provider(t, csrf.Config{}) },
+			meta:   meta,
+			reused: csrf.ErrTokenNotFound,
+		},
+		{
+			name: "multi use",
+			tp:   func(t *testing.T) csrf.TokenProvider { return new_provider(t, csrf.Config{}) },
+			opts: []csrf.Option{csrf.WithTokenMode(csrf.MultiUse)},
+			meta: meta,
+		},
+		{
+			name: "hashed",
+			tp: func(t *testing.T) csrf.TokenProvider {
+				htp := csrf.NewHashedTokenProviderConfig(context.Background(), csrf.Config{})
+				t.Cleanup(func() { htp.Close() })
+				return htp
+			},
+			meta:   meta,
+			reused: csrf.ErrTokenNotFound,
+		},
+		{
+			name: "fail open",
+			tp: func(t *testing.T) csrf.TokenProvider {
+				return down_meta_provider{new_provider(t, csrf.Config{})}
+			},
+			opts:   []csrf.Option{csrf.WithFailurePolicy(csrf.FailOpen)},
+			reused: nil,
+		},
+		{
+			name: "fail closed",
+			tp: func(t *testing.T) csrf.TokenProvider {
+				return down_meta_provider{new_provider(t, csrf.Config{})}
+			},
+			err:    errDown,
+			reused: errDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := csrf.New(tt.tp(t), tt.opts...)
+			token, err := c.IssueWithMeta(context.Background(), meta)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := c.ValidateWithMeta(post("/", token.Value), csrf.HeaderTokenSource)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ValidateWithMeta = %v, want %v", err, tt.err)
+			}
+
+			if !maps.Equal(got, tt.meta) {
+				t.Errorf("meta = %v, want %v", got, tt.meta)
+			}
+
+			if _, err := c.ValidateWithMeta(post("/", token.Value), csrf.HeaderTokenSource); !errors.Is(err, tt.reused) {
+				t.Errorf("ValidateWithMeta of reused token = %v, want %v", err, tt.reused)
+			}
+		})
+	}
+}
+
+func TestValidateWithMetaNotSupported(t *testing.T) {
+	hmac_provider := new_hmac_provider(t, csrf.HMACConfig{Secret: hmac_secret})
+	_, err := csrf.New(hmac_provider).ValidateWithMeta(httptest.NewRequest(http.MethodPost, "/", nil), csrf.HeaderTokenSource)
+	if !errors.Is(err, csrf.ErrMetaNotSupported) {
+		t.Fatalf("ValidateWithMeta = %v, want %v", err, csrf.ErrMetaNotSupported)
+	}
+}
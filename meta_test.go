@@ -0,0 +1,55 @@
+package csrf_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestGetTokenWithMeta(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}))
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	token, tag, err := c.GetTokenWithMeta(rec, r, "csrf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := csrf.MetaTag(token); tag != want {
+		t.Errorf("meta tag = %s, want %s", tag, want)
+	}
+
+	if !strings.Contains(string(tag), `content="`+token+`"`) {
+		t.Errorf("meta tag %s does not carry token %q", tag, token)
+	}
+
+	set := cookie(rec, "csrf")
+	if set == nil || set.Value != token {
+		t.Fatalf("cookie = %v, want value %q", set, token)
+	}
+
+	if err := c.Validate(post("/", token), csrf.HeaderTokenSource); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+}
+
+func TestMetaTag(t *testing.T) {
+	tests := []struct {
+		token string
+		want  template.HTML
+	}{
+		{"abc", `<meta name="csrf-token" content="abc">`},
+		{`"><script>`, `<meta name="csrf-token" content="&#34;&gt;&lt;script&gt;">`},
+	}
+
+	for _, tt := range tests {
+		if got := csrf.MetaTag(tt.token); got != tt.want {
+			t.Errorf("MetaTag(%q) = %s, want %s", tt.token, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package csrf
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Collector receive metrics from [CSRF], see [WithMetrics]
+type Collector interface {
+	TokenIssued()
+	ValidationPassed()
+	// ValidationFailed is called with [FailureReason] of the error
+	ValidationFailed(reason string)
+	// TokenAge is the age of the token at validation, only reported with [WithTokenAgeMetric]
+	// if the [TokenProvider] implements [IssuedAtProvider]
+	TokenAge(age time.Duration)
+	// ProviderLatency is the duration of [TokenProvider] call, `op` is `get` or `check`
+	ProviderLatency(op string, latency time.Duration)
+}
+
+type nop_collector struct{}
+
+func (nop_collector) TokenIssued()                          {}
+func (nop_collector) ValidationPassed()                     {}
+func (nop_collector) ValidationFailed(string)               {}
+func (nop_collector) TokenAge(time.Duration)                {}
+func (nop_collector) ProviderLatency(string, time.Duration) {}
+
+// WithMetrics report token issuance, validation result and provider latency to `collector`
+func WithMetrics(collector Collector) Option {
+	return func(c *CSRF) {
+		c.metrics = collector
+	}
+}
+
+// FailureReason return short label of validation error suitable for metrics and logs
+func FailureReason(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrInconsistentTokenBetweenSources):
+		return "inconsistent_sources"
+	case errors.Is(err, ErrTokenMissing):
+		return "token_missing"
+	case errors.Is(err, ErrTokenExpired):
+		return "token_expired"
+	case errors.Is(err, ErrTokenNotFound):
+		return "token_not_found"
+	case errors.Is(err, ErrTokenMalformed):
+		return "token_malformed"
+	case errors.Is(err, ErrInvalidToken):
+		return "invalid_token"
+	case errors.Is(err, ErrContentTypeNotAllowed):
+		return "content_type_not_allowed"
+	case errors.Is(err, ErrCustomHeaderMissing):
+		return "custom_header_missing"
+	case errors.Is(err, ErrOriginNotAllowed):
+		return "origin_not_allowed"
+	case errors.Is(err, ErrCrossSiteRequest):
+		return "cross_site_request"
+	case errors.Is(err, ErrNoSources):
+		return "no_sources"
+	case errors.Is(err, ErrEntropy):
+		return "entropy"
+	case errors.Is(err, ErrMetaNotSupported):
+		return "meta_not_supported"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "context"
+	}
+
+	return "provider_error"
+}
+
+// WithTokenAgeMetric report the age of validated token to [Collector.TokenAge], it cost extra
+// [IssuedAtProvider.IssuedAt] call to the [TokenProvider] on every validation so it is off by default
+func WithTokenAgeMetric() Option {
+	return func(c *CSRF) {
+		c.token_age = true
+	}
+}
+
+func (c *CSRF) observe_token_age(ctx context.Context, token string) {
+	if !c.token_age {
+		return
+	}
+
+	iap, ok := c.TokenProvider.(IssuedAtProvider)
+	if !ok {
+		return
+	}
+
+	if issued_at, err := iap.IssuedAt(ctx, token); err == nil {
+		c.metrics.TokenAge(c.clock.Now().Sub(issued_at))
+	}
+}
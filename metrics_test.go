@@ -0,0 +1,59 @@
+package csrf_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+// age_collector record the token ages reported to [csrf.Collector]
+type age_collector struct {
+	ages []time.Duration
+}
+
+func (*age_collector) TokenIssued()                          {}
+func (*age_collector) ValidationPassed()                     {}
+func (*age_collector) ValidationFailed(string)               {}
+func (*age_collector) ProviderLatency(string, time.Duration) {}
+func (ac *age_collector) TokenAge(age time.Duration)         { ac.ages = append(ac.ages, age) }
+
+func TestTokenAgeMetric(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []csrf.Option
+		ages int
+	}{
+		{"off by default", nil, 0},
+		{"enabled", []csrf.Option{csrf.WithTokenAgeMetric()}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+			collector := &age_collector{}
+			c := csrf.New(new_provider(t, csrf.Config{Clock: clock}),
+				append(tt.opts, csrf.WithMetrics(collector), csrf.WithClock(clock))...)
+
+			token, err := c.GetToken(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			clock.Advance(time.Minute)
+			if err := c.Validate(post("/", token), csrf.HeaderTokenSource); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(collector.ages) != tt.ages {
+				t.Fatalf("reported %d ages, want %d", len(collector.ages), tt.ages)
+			}
+
+			if tt.ages > 0 && collector.ages[0] != time.Minute {
+				t.Errorf("age = %v, want %v", collector.ages[0], time.Minute)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package csrf
+
+import (
+	"context"
+	"time"
+)
+
+// TokenMode select whether token can be used once or multiple times, see [WithTokenMode]
+type TokenMode uint8
+
+const (
+	// SingleUse consume the token on successful validation, this is the default.
+	// provider which does not implement [Consumer] (e.g. stateless provider) behave as [MultiUse]
+	SingleUse TokenMode = iota
+	// MultiUse keep the token valid until expired, e.g. per session token
+	MultiUse
+)
+
+// WithTokenMode set the token mode used by [CSRF.Validate], default to [SingleUse]
+func WithTokenMode(mode TokenMode) Option {
+	return func(c *CSRF) {
+		c.token_mode = mode
+	}
+}
+
+// consumes report whether [CSRF.check] consume the token
+func (c *CSRF) consumes() bool {
+	_, ok := c.TokenProvider.(Consumer)
+	return ok && c.token_mode == SingleUse
+}
+
+func (c *CSRF) check(ctx context.Context, token string) error {
+	defer func(start time.Time) { c.metrics.ProviderLatency("check", time.Since(start)) }(time.Now())
+
+	if consumer, ok := c.TokenProvider.(Consumer); ok && c.token_mode == SingleUse {
+		_, err := provider_span(ctx, c, "CheckAndConsume", func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, consumer.CheckAndConsume(ctx, token)
+		})
+		return err
+	}
+
+	_, err := provider_span(ctx, c, "Check", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.TokenProvider.Check(ctx, token)
+	})
+	return err
+}
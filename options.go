@@ -0,0 +1,109 @@
+package csrf
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+const (
+	DefaultHeaderName = "X-Csrf-Token"
+	DefaultFormField  = "csrf_token"
+	DefaultCookieName = "csrf_token"
+)
+
+// DefaultSafeMethods are the safe methods per RFC 7231 which are not validated by [CSRF.ValidateMiddleware], see also [WithSkipper]
+var DefaultSafeMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace}
+
+// ErrorHandlerFunc handle the result of validation in [CSRF.ValidateMiddleware] or respond to failure in [CSRF.Middleware]
+type ErrorHandlerFunc func(http.ResponseWriter, *http.Request, error)
+
+// stop return [FailureHandlerFunc] which respond with `handle_err` and stop the request
+func (handle_err ErrorHandlerFunc) stop() FailureHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, err error) bool {
+		handle_err(w, r, err)
+		return false
+	}
+}
+
+// WithHeaderName set the header read by [CSRF.HeaderSource], default to [DefaultHeaderName]
+func WithHeaderName(name string) Option {
+	return func(c *CSRF) {
+		c.header_name = name
+	}
+}
+
+// WithFormField set the form field read by [CSRF.FormSource], default to [DefaultFormField]
+func WithFormField(field string) Option {
+	return func(c *CSRF) {
+		c.form_field = field
+	}
+}
+
+// WithCookieName set the cookie read by [CSRF.CookieSource], default to [DefaultCookieName]
+func WithCookieName(name string) Option {
+	return func(c *CSRF) {
+		c.cookie_name = name
+	}
+}
+
+// WithErrorHandler set the error handler used by [CSRF.Middleware], default to [DefaultErrorHandler]
+func WithErrorHandler(handle_err ErrorHandlerFunc) Option {
+	return func(c *CSRF) {
+		c.error_handler = handle_err
+	}
+}
+
+// WithSafeMethods set the methods which are not validated by [CSRF.ValidateMiddleware] nor by the validators
+// of [WithValidators], default to [DefaultSafeMethods]. no method means every request is validated
+func WithSafeMethods(methods ...string) Option {
+	return func(c *CSRF) {
+		c.safe_methods = slices.Clone(methods)
+	}
+}
+
+type csrf_safe_methods_context_key int
+
+// with_safe_methods return `r` carrying the safe methods of `c` for the validators, see [is_safe_method]
+func (c *CSRF) with_safe_methods(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), csrf_safe_methods_context_key(0), c.safe_methods))
+}
+
+// is_safe_method report whether the method of `r` is safe by [WithSafeMethods] of the [CSRF] running the validator,
+// or by [DefaultSafeMethods] if the validator is used on its own
+func is_safe_method(r *http.Request) bool {
+	methods, ok := r.Context().Value(csrf_safe_methods_context_key(0)).([]string)
+	if !ok {
+		methods = DefaultSafeMethods
+	}
+
+	return slices.Contains(methods, r.Method)
+}
+
+// HeaderSource return token from the configured header or empty string
+func (c *CSRF) HeaderSource() TokenSourceFunc {
+	name := c.header_name
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// FormSource return token from the configured form field, see [FormTokenSource]
+func (c *CSRF) FormSource() TokenSourceFunc {
+	return FormTokenSource(c.form_field)
+}
+
+// CookieSource return token from the configured cookie or empty string
+func (c *CSRF) CookieSource() TokenSourceFunc {
+	return CookieTokenSource(c.cookie_name)
+}
+
+// Middleware is [CSRF.Protect] which respond with the configured error handler on failure,
+// the token is read from [CSRF.HeaderSource] if no source is given
+func (c *CSRF) Middleware(sources ...TokenSourceFunc) func(next http.Handler) http.Handler {
+	if len(sources) == 0 {
+		sources = []TokenSourceFunc{c.HeaderSource()}
+	}
+
+	return c.Protect(c.error_handler.stop(), sources...)
+}
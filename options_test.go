@@ -0,0 +1,84 @@
+package csrf_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestWithHeaderName(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithTokenMode(csrf.MultiUse), csrf.WithHeaderName("X-Token"))
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		reached bool
+	}{
+		{"configured header", "X-Token", true},
+		{"default header", csrf.DefaultHeaderName, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.Header.Set(tt.header, token)
+			if reached, rec := csrftest.Serve(c.Middleware(), r); reached != tt.reached {
+				t.Errorf("reached = %v, want %v (status %d)", reached, tt.reached, rec.Code)
+			}
+		})
+	}
+
+	_, rec := csrftest.Serve(c.TokenMiddleware(csrf.ExposeHeader), httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Header().Get("X-Token") == "" {
+		t.Error("token not exposed in the configured header")
+	}
+}
+
+func TestDefaultErrorHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	csrf.DefaultErrorHandler(rec, post("/", ""), csrf.ErrTokenExpired)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %s, want text/html", ct)
+	}
+
+	if body := rec.Body.String(); !strings.Contains(body, csrf.FailureMessage(csrf.ErrTokenExpired)) {
+		t.Errorf("body = %s, want the failure message", body)
+	}
+
+	rec = httptest.NewRecorder()
+	csrf.DefaultErrorHandler(rec, post("/", ""), nil)
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("nil error wrote %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	var handled error
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		handled = err
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	reached, rec := csrftest.Serve(c.Middleware(), post("/", ""))
+	if reached || rec.Code != http.StatusTeapot {
+		t.Fatalf("reached = %v, status = %d, want the configured handler to stop the request", reached, rec.Code)
+	}
+
+	if handled != csrf.ErrTokenMissing {
+		t.Errorf("handled %v, want %v", handled, csrf.ErrTokenMissing)
+	}
+}
@@ -0,0 +1,109 @@
+package csrf
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrOriginNotAllowed = errors.New("origin not allowed")
+
+type origin_pattern struct {
+	scheme string
+	// host with optional port, leading `*.` match any subdomain
+	host string
+}
+
+func (p origin_pattern) match(u *url.URL) bool {
+	if !strings.EqualFold(u.Scheme, p.scheme) {
+		return false
+	}
+
+	host := strings.ToLower(u.Host)
+	if suffix, found := strings.CutPrefix(p.host, "*"); found {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+
+	return host == p.host
+}
+
+// OriginCheck verify the `Origin` header, or `Referer` header if there is no origin, of unsafe request
+// against allowlist of origins. the opaque origin `null` is always rejected
+type OriginCheck struct {
+	allowed []origin_pattern
+	// AllowMissing accept request without both `Origin` and `Referer` header
+	AllowMissing bool
+}
+
+// NewOriginCheck return [OriginCheck] allowing the given origins in the form of `scheme://host[:port]`,
+// host may start with `*.` to allow any subdomain (e.g. `https://*.example.com`), the scheme must match exactly
+func NewOriginCheck(allowed ...string) (*OriginCheck, error) {
+	oc := &OriginCheck{}
+	for _, origin := range allowed {
+		u, err := url.Parse(origin)
+		if err != nil {
+			return nil, err
+		}
+
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid origin %q", origin)
+		}
+
+		oc.allowed = append(oc.allowed, origin_pattern{scheme: strings.ToLower(u.Scheme), host: strings.ToLower(u.Host)})
+	}
+
+	return oc, nil
+}
+
+// Validate return [ErrOriginNotAllowed] if unsafe request come from origin not in the allowlist
+func (oc *OriginCheck) Validate(r *http.Request) error {
+	if is_safe_method(r) {
+		return nil
+	}
+
+	// opaque origin (sandboxed iframe, data: url, ...) is sent as `null`, it is never allowed
+	origin := r.Header.Get("Origin")
+	if origin == "null" {
+		return ErrOriginNotAllowed
+	}
+
+	if origin == "" {
+		origin = r.Header.Get("Referer")
+	}
+
+	if origin == "" {
+		if oc.AllowMissing {
+			return nil
+		}
+
+		return ErrOriginNotAllowed
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return ErrOriginNotAllowed
+	}
+
+	for _, p := range oc.allowed {
+		if p.match(u) {
+			return nil
+		}
+	}
+
+	return ErrOriginNotAllowed
+}
+
+// Middleware call `next` if [OriginCheck.Validate] pass, otherwise respond with `handle_err`
+func (oc *OriginCheck) Middleware(handle_err ErrorHandlerFunc) func(next http.Handler) http.Handler {
+	return ValidatorFunc(oc.Validate).Middleware(handle_err)
+}
+
+// WithValidators run `validators` before the token is checked in [CSRF.Validate],
+// e.g. [OriginCheck.Validate] or [ContentTypeCheck]
+func WithValidators(validators ...ValidatorFunc) Option {
+	return func(c *CSRF) {
+		c.validators = append(c.validators, validators...)
+	}
+}
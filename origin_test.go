@@ -0,0 +1,54 @@
+package csrf_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestOriginCheck(t *testing.T) {
+	oc, err := csrf.NewOriginCheck("https://example.com", "https://*.example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oc.AllowMissing = true
+
+	tests := []struct {
+		name    string
+		method  string
+		origin  string
+		referer string
+		want    error
+	}{
+		{"safe method", http.MethodGet, "https://evil.com", "", nil},
+		{"allowed", http.MethodPost, "https://example.com", "", nil},
+		{"wildcard", http.MethodPost, "https://a.example.org", "", nil},
+		{"wildcard apex", http.MethodPost, "https://example.org", "", csrf.ErrOriginNotAllowed},
+		{"scheme", http.MethodPost, "http://example.com", "", csrf.ErrOriginNotAllowed},
+		{"other", http.MethodPost, "https://evil.com", "", csrf.ErrOriginNotAllowed},
+		{"referer", http.MethodPost, "", "https://example.com/form", nil},
+		{"bad referer", http.MethodPost, "", "https://evil.com/form", csrf.ErrOriginNotAllowed},
+		{"missing", http.MethodPost, "", "", nil},
+		{"null", http.MethodPost, "null", "", csrf.ErrOriginNotAllowed},
+		{"null with referer", http.MethodPost, "null", "https://example.com/form", csrf.ErrOriginNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				r.Header.Set("Referer", tt.referer)
+			}
+
+			if err := oc.Validate(r); !errors.Is(err, tt.want) {
+				t.Errorf("Validate = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package csrf
+
+import "net/http"
+
+// Peek is [CSRF.Validate] which never consume the token regardless of [WithTokenMode],
+// e.g. for speculative check before the actual submission. failure hooks are not called
+func (c *CSRF) Peek(r *http.Request, sources ...TokenSourceFunc) (err error) {
+	ctx, span := c.tracer.Start(c.with_scope(c.request_context(r), r), "csrf.Peek")
+	defer func() { end_span(span, err) }()
+
+	token, _, err := c.extract(ctx, r, sources)
+	if err != nil {
+		return err
+	}
+
+	return c.TokenProvider.Check(ctx, token)
+}
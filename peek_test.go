@@ -0,0 +1,50 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestPeek(t *testing.T) {
+	var alerts int
+	c := csrf.New(new_provider(t, csrf.Config{}),
+		csrf.WithTokenMode(csrf.SingleUse),
+		csrf.WithFailureAlert(0, time.Minute, nil, func(ip string, count int) { alerts++ }),
+	)
+
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 2 {
+		if err := c.Peek(post("/", token), csrf.HeaderTokenSource); err != nil {
+			t.Fatalf("Peek #%d = %v", i, err)
+		}
+	}
+
+	if err := c.Peek(post("/", "unknown"), csrf.HeaderTokenSource); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("Peek of unknown token = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+
+	if err := c.Peek(post("/", ""), csrf.HeaderTokenSource); !errors.Is(err, csrf.ErrTokenMissing) {
+		t.Errorf("Peek without token = %v, want %v", err, csrf.ErrTokenMissing)
+	}
+
+	if alerts != 0 {
+		t.Errorf("Peek failures alerted %d times", alerts)
+	}
+
+	// the token is still there for the actual submission
+	if err := c.Validate(post("/", token), csrf.HeaderTokenSource); err != nil {
+		t.Fatalf("Validate after Peek = %v", err)
+	}
+
+	if err := c.Peek(post("/", token), csrf.HeaderTokenSource); err == nil {
+		t.Error("Peek of consumed token passed")
+	}
+}
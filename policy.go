@@ -0,0 +1,60 @@
+package csrf
+
+import "net/http"
+
+// Policy is the protection of request matching [Policy.Pattern], see [CSRF.PolicyMiddleware]
+type Policy struct {
+	// Pattern in [http.ServeMux] syntax, e.g. `/api/` or `POST /admin/{id}`
+	Pattern string
+	// Sources of the token, default to [CSRF.HeaderSource]
+	Sources []TokenSourceFunc
+	// HandleFailure default to the error handler set by [WithErrorHandler]
+	HandleFailure FailureHandlerFunc
+	// Disabled skip the validation, e.g. for webhooks
+	Disabled bool
+}
+
+type policy_handler struct{ http.Handler }
+
+// PolicyMiddleware protect `next` (typically [http.ServeMux]) with the policy whose pattern match the request,
+// using the same precedence as [http.ServeMux]. request matching no policy is protected by [CSRF.Middleware].
+// it panics on invalid or conflicting patterns like [http.ServeMux.Handle]
+//
+//	c.PolicyMiddleware(
+//		csrf.Policy{Pattern: "/api/", Sources: []csrf.TokenSourceFunc{c.HeaderSource()}},
+//		csrf.Policy{Pattern: "/admin/", Sources: []csrf.TokenSourceFunc{c.FormSource()}},
+//		csrf.Policy{Pattern: "POST /webhooks/", Disabled: true},
+//	)(mux)
+func (c *CSRF) PolicyMiddleware(policies ...Policy) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		matcher := http.NewServeMux()
+		for _, policy := range policies {
+			if policy.Disabled {
+				matcher.Handle(policy.Pattern, policy_handler{next})
+				continue
+			}
+
+			if len(policy.Sources) == 0 {
+				policy.Sources = []TokenSourceFunc{c.HeaderSource()}
+			}
+
+			if policy.HandleFailure == nil {
+				policy.HandleFailure = c.error_handler.stop()
+			}
+
+			matcher.Handle(policy.Pattern, policy_handler{c.Protect(policy.HandleFailure, policy.Sources...)(next)})
+		}
+
+		fallback := c.Middleware()(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if h, _ := matcher.Handler(r); h != nil {
+				if ph, ok := h.(policy_handler); ok {
+					ph.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			fallback.ServeHTTP(w, r)
+		})
+	}
+}
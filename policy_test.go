@@ -0,0 +1,77 @@
+package csrf_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestPolicyMiddleware(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithTokenMode(csrf.MultiUse))
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var failures int
+	mw := c.PolicyMiddleware(
+		csrf.Policy{Pattern: "/api/"},
+		csrf.Policy{Pattern: "/admin/", Sources: []csrf.TokenSourceFunc{c.FormSource()}},
+		csrf.Policy{Pattern: "POST /webhooks/", Disabled: true},
+		csrf.Policy{Pattern: "/report/", HandleFailure: func(http.ResponseWriter, *http.Request, error) bool {
+			failures++
+			return true
+		}},
+	)
+
+	form := func(target string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(url.Values{csrf.DefaultFormField: {token}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		r       *http.Request
+		reached bool
+	}{
+		{"default source", post("/api/items", token), true},
+		{"default source without token", post("/api/items", ""), false},
+		{"form source", form("/admin/users"), true},
+		{"form source ignore header", post("/admin/users", token), false},
+		{"disabled", post("/webhooks/github", ""), true},
+		{"disabled only for POST", httptest.NewRequest(http.MethodPut, "/webhooks/github", nil), false},
+		{"failure handler", post("/report/", ""), true},
+		{"no policy", post("/other", token), true},
+		{"no policy without token", post("/other", ""), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if reached, rec := csrftest.Serve(mw, tt.r); reached != tt.reached {
+				t.Errorf("reached = %v, want %v (status %d)", reached, tt.reached, rec.Code)
+			}
+		})
+	}
+
+	if failures != 1 {
+		t.Errorf("HandleFailure called %d times, want 1", failures)
+	}
+}
+
+func TestPolicyMiddlewareConflict(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("conflicting patterns did not panic")
+		}
+	}()
+
+	c := csrf.New(new_provider(t, csrf.Config{}))
+	c.PolicyMiddleware(csrf.Policy{Pattern: "/api/"}, csrf.Policy{Pattern: "/api/", Disabled: true})(http.NotFoundHandler())
+}
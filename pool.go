@@ -0,0 +1,151 @@
+package csrf
+
+import (
+	"context"
+	"time"
+)
+
+type pooled_token struct {
+	token     string
+	issued_at time.Time
+}
+
+// PooledProvider pre-generate tokens of the wrapped [TokenProvider] in the background,
+// so Get does not wait for slow backend under burst load.
+// pooled tokens are issued without session id and with the provider ttl, Get with [WithSessionID]
+// or [WithTokenTTL] context bypass the pool. the middlewares and [CSRF.TokenHandler] set the session id
+// once [WithSubject], [WithScope] or any binding (e.g. [WithClientIPBinding]) is configured,
+// so the pool only help anonymous tokens.
+// pooled token older than half of the provider [TTLProvider.TTL] is discarded, [CSRF.Issue] report
+// the time the token was actually issued so its expiry is not overstated
+type PooledProvider struct {
+	TokenProvider
+	pool    chan pooled_token
+	max_age time.Duration
+	clock   Clock
+
+	stop_fill context.CancelFunc
+	fill_done chan struct{}
+}
+
+var (
+	_ TokenProvider    = (*PooledProvider)(nil)
+	_ Consumer         = (*PooledProvider)(nil)
+	_ IssuedAtProvider = (*PooledProvider)(nil)
+	_ TTLProvider      = (*PooledProvider)(nil)
+)
+
+// NewPooledProvider keep up to `size` tokens of `tp` ready, the refill goroutine stops when `ctx` is done or on Close
+func NewPooledProvider(ctx context.Context, tp TokenProvider, size int) *PooledProvider {
+	pp := &PooledProvider{
+		TokenProvider: tp,
+		pool:          make(chan pooled_token, size),
+		clock:         SystemClock{},
+		fill_done:     make(chan struct{}),
+	}
+
+	if ttlp, ok := tp.(TTLProvider); ok {
+		pp.max_age = ttlp.TTL() / 2
+	}
+
+	ctx, pp.stop_fill = context.WithCancel(ctx)
+	go pp.fill(ctx)
+	return pp
+}
+
+func (pp *PooledProvider) fill(ctx context.Context) {
+	defer close(pp.fill_done)
+
+	const max_backoff = 10 * time.Second
+	backoff := 100 * time.Millisecond
+
+	ctx_done := ctx.Done()
+	for {
+		token, err := pp.TokenProvider.Get(ctx)
+		if err != nil {
+			timer := pp.clock.NewTimer(backoff)
+			select {
+			case <-ctx_done:
+				timer.Stop()
+				return
+			case <-timer.C():
+			}
+
+			backoff = min(backoff*2, max_backoff)
+			continue
+		}
+
+		backoff = 100 * time.Millisecond
+		select {
+		case <-ctx_done:
+			return
+		case pp.pool <- pooled_token{token: token, issued_at: pp.clock.Now()}:
+		}
+	}
+}
+
+// Get return pooled token if available, otherwise it call the wrapped provider directly
+func (pp *PooledProvider) Get(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if SessionIDFromContext(ctx) != "" || TTLFromContext(ctx, 0) > 0 {
+		return pp.TokenProvider.Get(ctx)
+	}
+
+	for {
+		select {
+		case pt := <-pp.pool:
+			if pp.max_age > 0 && pp.clock.Now().Sub(pt.issued_at) > pp.max_age {
+				continue
+			}
+
+			report_issued_at(ctx, pt.issued_at)
+			return pt.token, nil
+		default:
+			return pp.TokenProvider.Get(ctx)
+		}
+	}
+}
+
+type csrf_issued_at_context_key int
+
+// with_issued_at return context through which [PooledProvider] report the issue time of pooled token into `issued_at`
+func with_issued_at(ctx context.Context, issued_at *time.Time) context.Context {
+	return context.WithValue(ctx, csrf_issued_at_context_key(0), issued_at)
+}
+
+func report_issued_at(ctx context.Context, issued_at time.Time) {
+	if p, ok := ctx.Value(csrf_issued_at_context_key(0)).(*time.Time); ok {
+		*p = issued_at
+	}
+}
+
+func (pp *PooledProvider) CheckAndConsume(ctx context.Context, token string) error {
+	return consume(ctx, pp.TokenProvider, token)
+}
+
+func (pp *PooledProvider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	iap, ok := pp.TokenProvider.(IssuedAtProvider)
+	if !ok {
+		return time.Time{}, errIssuedAtNotTracked
+	}
+
+	return iap.IssuedAt(ctx, token)
+}
+
+func (pp *PooledProvider) TTL() time.Duration {
+	if tp, ok := pp.TokenProvider.(TTLProvider); ok {
+		return tp.TTL()
+	}
+
+	return 0
+}
+
+// Close stop the refill goroutine and wait for it to exit, the wrapped provider is not closed
+func (pp *PooledProvider) Close() error {
+	pp.stop_fill()
+	<-pp.fill_done
+	return nil
+}
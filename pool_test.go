@@ -0,0 +1,85 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestPooledProviderBypass(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		ttl  time.Duration
+	}{
+		{"pooled", context.Background(), time.Hour},
+		{"ttl override", csrf.WithTokenTTL(context.Background(), time.Minute), time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+			tp := new_provider(t, csrf.Config{TTL: time.Hour, Clock: clock})
+			pp := csrf.NewPooledProvider(context.Background(), tp, 1)
+			t.Cleanup(func() { pp.Close() })
+
+			// one token is pooled and the next one is waiting for room
+			deadline := time.Now().Add(time.Second)
+			for tp.Stats().Issued < 2 {
+				if time.Now().After(deadline) {
+					t.Fatal("pool was not filled")
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			token, err := pp.Get(tt.ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			clock.Advance(tt.ttl - time.Second)
+			if err := tp.Check(context.Background(), token); err != nil {
+				t.Errorf("Check before ttl = %v", err)
+			}
+
+			clock.Advance(time.Second)
+			if err := tp.Check(context.Background(), token); !errors.Is(err, csrf.ErrInvalidToken) {
+				t.Errorf("Check after ttl = %v, want %v", err, csrf.ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestPooledProviderIssue(t *testing.T) {
+	tp := new_provider(t, csrf.Config{TTL: time.Hour})
+	pp := csrf.NewPooledProvider(context.Background(), tp, 1)
+	t.Cleanup(func() { pp.Close() })
+
+	deadline := time.Now().Add(time.Second)
+	for tp.Stats().Issued < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("pool was not filled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	filled := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	token, err := csrf.New(pp).Issue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !token.IssuedAt.Before(filled) {
+		t.Errorf("IssuedAt = %v, want the time it was pooled before %v", token.IssuedAt, filled)
+	}
+
+	if want := token.IssuedAt.Add(time.Hour); !token.ExpireAt.Equal(want) {
+		t.Errorf("ExpireAt = %v, want %v", token.ExpireAt, want)
+	}
+}
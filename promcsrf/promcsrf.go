@@ -0,0 +1,94 @@
+// Package promcsrf implements [csrf.Collector] exporting prometheus metrics
+package promcsrf
+
+import (
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is [csrf.Collector] and [prometheus.Collector]
+type Collector struct {
+	issued           prometheus.Counter
+	validations      *prometheus.CounterVec
+	fail_open        *prometheus.CounterVec
+	token_age        prometheus.Histogram
+	provider_latency *prometheus.HistogramVec
+}
+
+var (
+	_ csrf.Collector         = (*Collector)(nil)
+	_ csrf.FailOpenCollector = (*Collector)(nil)
+	_ prometheus.Collector   = (*Collector)(nil)
+)
+
+// New return [Collector] with metrics prefixed by `namespace`, it must be registered to [prometheus.Registerer]
+func New(namespace string) *Collector {
+	return &Collector{
+		issued: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "csrf",
+			Name:      "tokens_issued_total",
+			Help:      "Number of issued csrf tokens.",
+		}),
+		validations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "csrf",
+			Name:      "validations_total",
+			Help:      "Number of csrf validations by result and failure reason.",
+		}, []string{"result", "reason"}),
+		fail_open: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "csrf",
+			Name:      "fail_open_total",
+			Help:      "Number of requests allowed by fail open policy despite provider error.",
+		}, []string{"reason"}),
+		token_age: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "csrf",
+			Name:      "token_age_seconds",
+			Help:      "Age of csrf token at validation.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+		}),
+		provider_latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "csrf",
+			Name:      "provider_latency_seconds",
+			Help:      "Latency of csrf token provider calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"op"}),
+	}
+}
+
+func (c *Collector) TokenIssued() { c.issued.Inc() }
+
+func (c *Collector) ValidationPassed() { c.validations.WithLabelValues("passed", "").Inc() }
+
+func (c *Collector) ValidationFailed(reason string) {
+	c.validations.WithLabelValues("failed", reason).Inc()
+}
+
+func (c *Collector) FailOpen(reason string) { c.fail_open.WithLabelValues(reason).Inc() }
+
+func (c *Collector) TokenAge(age time.Duration) { c.token_age.Observe(age.Seconds()) }
+
+func (c *Collector) ProviderLatency(op string, latency time.Duration) {
+	c.provider_latency.WithLabelValues(op).Observe(latency.Seconds())
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.issued.Describe(ch)
+	c.validations.Describe(ch)
+	c.fail_open.Describe(ch)
+	c.token_age.Describe(ch)
+	c.provider_latency.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.issued.Collect(ch)
+	c.validations.Collect(ch)
+	c.fail_open.Collect(ch)
+	c.token_age.Collect(ch)
+	c.provider_latency.Collect(ch)
+}
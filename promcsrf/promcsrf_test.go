@@ -0,0 +1,55 @@
+package promcsrf_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/promcsrf"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	collector := promcsrf.New("app")
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(collector)
+
+	tp := csrf.NewDefaultTokenProviderConfig(context.Background(), csrf.Config{})
+	defer tp.Close()
+
+	c := csrf.New(tp, csrf.WithMetrics(collector))
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{token, token, ""} {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set(csrf.DefaultHeaderName, token)
+		c.Validate(r, c.HeaderSource())
+	}
+	collector.FailOpen("provider_error")
+
+	want := `
+# HELP app_csrf_fail_open_total Number of requests allowed by fail open policy despite provider error.
+# TYPE app_csrf_fail_open_total counter
+app_csrf_fail_open_total{reason="provider_error"} 1
+# HELP app_csrf_tokens_issued_total Number of issued csrf tokens.
+# TYPE app_csrf_tokens_issued_total counter
+app_csrf_tokens_issued_total 1
+# HELP app_csrf_validations_total Number of csrf validations by result and failure reason.
+# TYPE app_csrf_validations_total counter
+app_csrf_validations_total{reason="",result="passed"} 1
+app_csrf_validations_total{reason="token_missing",result="failed"} 1
+app_csrf_validations_total{reason="token_not_found",result="failed"} 1
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"app_csrf_fail_open_total", "app_csrf_tokens_issued_total", "app_csrf_validations_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
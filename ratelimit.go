@@ -0,0 +1,141 @@
+package csrf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited returned by [CSRF.GetToken] when the client exceeded the limit set by [WithIssueRateLimit]
+var ErrRateLimited = errors.New("token issuance rate limited")
+
+// RateLimiter decide whether the client identified by `key` may be issued another token,
+// implement it over shared store (e.g. redis) to limit across instances
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (bool, error)
+}
+
+type csrf_rate_key_context_key int
+
+// WithIssueRateLimit limit token issuance of request originated [CSRF.GetToken] (middlewares, [CSRF.TokenHandler])
+// per client identified by `key`, default to the client ip from [http.Request.RemoteAddr].
+// direct call of [CSRF.GetToken] with context not derived from the middlewares is not limited.
+// the middlewares respond with 429 Too Many Requests when the limit is exceeded
+func WithIssueRateLimit(limiter RateLimiter, key func(*http.Request) string) Option {
+	if key == nil {
+		key = client_ip
+	}
+
+	return func(c *CSRF) {
+		c.issue_limiter = limiter
+		c.rate_key = key
+	}
+}
+
+func (c *CSRF) allow_issue(ctx context.Context) error {
+	if c.issue_limiter == nil {
+		return nil
+	}
+
+	key, _ := ctx.Value(csrf_rate_key_context_key(0)).(string)
+	if key == "" {
+		return nil
+	}
+
+	ok, err := c.issue_limiter.Allow(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrRateLimited
+	}
+
+	return nil
+}
+
+// issue_error respond to failed token issuance in the middlewares
+func issue_error(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrRateLimited) {
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// TokenBucketLimiter is in memory [RateLimiter] allowing `burst` tokens refilled at `rate` per second for each key
+type TokenBucketLimiter struct {
+	rate  float64
+	burst float64
+	clock Clock
+
+	mu      sync.Mutex
+	buckets map[string]bucket
+}
+
+var _ RateLimiter = (*TokenBucketLimiter)(nil)
+
+func NewTokenBucketLimiter(rate float64, burst int) *TokenBucketLimiter {
+	return &TokenBucketLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		clock:   SystemClock{},
+		buckets: make(map[string]bucket),
+	}
+}
+
+const (
+	// prune_threshold is the number of buckets above which full buckets are removed
+	prune_threshold = 1 << 14
+	// prune_batch is the number of buckets visited by each Allow once prune_threshold is reached,
+	// map iteration start at random bucket so every bucket is visited eventually
+	prune_batch = 16
+)
+
+func (tbl *TokenBucketLimiter) Allow(_ context.Context, key string) (bool, error) {
+	now := tbl.clock.Now()
+
+	tbl.mu.Lock()
+	defer tbl.mu.Unlock()
+
+	if len(tbl.buckets) >= prune_threshold {
+		tbl.prune_locked(now)
+	}
+
+	b, ok := tbl.buckets[key]
+	if !ok {
+		b = bucket{tokens: tbl.burst, last: now}
+	}
+
+	b.tokens = min(tbl.burst, b.tokens+now.Sub(b.last).Seconds()*tbl.rate)
+	b.last = now
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	tbl.buckets[key] = b
+	return allowed, nil
+}
+
+// prune_locked remove the full buckets among prune_batch buckets, it must be called with tbl.mu held
+func (tbl *TokenBucketLimiter) prune_locked(now time.Time) {
+	visited := 0
+	for k, b := range tbl.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*tbl.rate >= tbl.burst {
+			delete(tbl.buckets, k)
+		}
+
+		if visited++; visited == prune_batch {
+			return
+		}
+	}
+}
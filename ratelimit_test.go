@@ -0,0 +1,84 @@
+package csrf_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestTokenBucketLimiter(t *testing.T) {
+	// no refill, so every bucket is empty after its first token and must not be pruned
+	tbl := csrf.NewTokenBucketLimiter(0, 1)
+	ctx := context.Background()
+
+	const keys = 20_000
+	for i := range keys {
+		if ok, err := tbl.Allow(ctx, fmt.Sprint(i)); !ok || err != nil {
+			t.Fatalf("Allow(%d) = %v, %v, want true", i, ok, err)
+		}
+	}
+
+	for i := range keys {
+		if ok, err := tbl.Allow(ctx, fmt.Sprint(i)); ok || err != nil {
+			t.Fatalf("Allow(%d) of empty bucket = %v, %v, want false", i, ok, err)
+		}
+	}
+}
+
+func TestWithIssueRateLimit(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithIssueRateLimit(csrf.NewTokenBucketLimiter(0, 2), nil))
+	h := c.TokenHandler(0)
+
+	issue := func(ip string) int {
+		r := httptest.NewRequest(http.MethodGet, "/token", nil)
+		r.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	want := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for i, code := range want {
+		if got := issue("192.0.2.1"); got != code {
+			t.Fatalf("request #%d status = %d, want %d", i, got, code)
+		}
+	}
+
+	if got := issue("192.0.2.2"); got != http.StatusOK {
+		t.Errorf("other client status = %d, want %d", got, http.StatusOK)
+	}
+
+	// not derived from the middlewares
+	if _, err := c.GetToken(context.Background()); err != nil {
+		t.Errorf("direct GetToken = %v", err)
+	}
+}
+
+func TestWithIssueRateLimitKey(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithIssueRateLimit(csrf.NewTokenBucketLimiter(0, 1),
+		func(r *http.Request) string { return r.Header.Get("User") },
+	))
+	mw := c.TokenMiddleware(0)
+
+	tests := []struct {
+		user    string
+		reached bool
+	}{
+		{"alice", true},
+		{"alice", false},
+		{"bob", true},
+	}
+
+	for i, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("User", tt.user)
+		if reached, rec := csrftest.Serve(mw, r); reached != tt.reached {
+			t.Errorf("request #%d of %s reached = %v, want %v (status %d)", i, tt.user, reached, tt.reached, rec.Code)
+		}
+	}
+}
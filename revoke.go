@@ -0,0 +1,166 @@
+package csrf
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+var ErrRevokeNotSupported = errors.New("token provider does not support revocation")
+
+// Revoker is optional interface for [TokenProvider] which can invalidate every token bound to a subject,
+// e.g. on logout or password change
+type Revoker interface {
+	// RevokeAll must invalidate every token whose session id match `subject`, see [MatchSubject]
+	RevokeAll(ctx context.Context, subject string) error
+}
+
+// IDRevoker is optional interface for [Lister] which can invalidate single listed token by its [TokenInfo.ID],
+// e.g. from ops dashboard
+type IDRevoker interface {
+	// RevokeID must invalidate the token with [TokenID] `id`, unknown id is not an error
+	RevokeID(ctx context.Context, id string) error
+}
+
+// MatchSubject report whether token bound to `session_id` belong to `subject`, the session id is either the subject
+// itself or the subject with the bindings (see [WithClientIPBinding]) or the scope (see [WithScope]) appended
+func MatchSubject(session_id, subject string) bool {
+	rest, found := strings.CutPrefix(session_id, subject)
+	return found && (rest == "" || rest[0] == 0 || rest[0] == 1)
+}
+
+// SubjectOf return the subject of `session_id` without the bindings and the scope, see [MatchSubject].
+// providers can index their tokens by it to implement [Revoker]
+func SubjectOf(session_id string) string {
+	if i := strings.IndexAny(session_id, "\x00\x01"); i >= 0 {
+		return session_id[:i]
+	}
+
+	return session_id
+}
+
+var (
+	_ Revoker = (*DefaultTokenProvider)(nil)
+	_ Revoker = (*HashedTokenProvider)(nil)
+	_ Revoker = (*SQLTokenProvider)(nil)
+
+	_ IDRevoker = (*DefaultTokenProvider)(nil)
+	_ IDRevoker = (*HashedTokenProvider)(nil)
+	_ IDRevoker = (*ShardedTokenProvider)(nil)
+	_ IDRevoker = (*SQLTokenProvider)(nil)
+)
+
+// RevokeAll delete the tokens of `subject` found through the subject index,
+// only revoking the tokens without subject scan every token
+func (dtp *DefaultTokenProvider) RevokeAll(ctx context.Context, subject string) error {
+	dtp.mu.Lock()
+	defer dtp.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// tokens without subject are not indexed
+	if SubjectOf(subject) == "" {
+		for token, entry := range dtp.tokens {
+			if MatchSubject(entry.subject, subject) {
+				dtp.delete_locked(token)
+			}
+		}
+
+		return nil
+	}
+
+	for token := range dtp.by_subject[SubjectOf(subject)] {
+		if MatchSubject(dtp.tokens[token].subject, subject) {
+			dtp.delete_locked(token)
+		}
+	}
+
+	return nil
+}
+
+func (htp *HashedTokenProvider) RevokeAll(ctx context.Context, subject string) error {
+	return htp.dtp.RevokeAll(ctx, subject)
+}
+
+func (stp *SQLTokenProvider) RevokeAll(ctx context.Context, subject string) error {
+	// the subject with the bindings or the scope appended, see [MatchSubject] and sql_subject
+	subject = sql_subject.Replace(subject)
+	prefix := like_escaper.Replace(subject)
+	_, err := stp.db.ExecContext(ctx, stp.revoke_query, subject, prefix+"~0%", prefix+"~1%")
+	return err
+}
+
+// RevokeID hash every token to find `id`, like [DefaultTokenProvider.List]
+func (dtp *DefaultTokenProvider) RevokeID(ctx context.Context, id string) error {
+	dtp.mu.Lock()
+	defer dtp.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for token := range dtp.tokens {
+		if TokenID(token) == id {
+			dtp.delete_locked(token)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// RevokeID delete the token directly since its key is the decoded `id`
+func (htp *HashedTokenProvider) RevokeID(ctx context.Context, id string) error {
+	key, err := hex.DecodeString(id)
+	if err != nil {
+		return nil
+	}
+
+	htp.dtp.mu.Lock()
+	htp.dtp.delete_locked(string(key))
+	htp.dtp.mu.Unlock()
+	return nil
+}
+
+func (stp *ShardedTokenProvider) RevokeID(ctx context.Context, id string) error {
+	for _, shard := range stp.shards {
+		if err := shard.RevokeID(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (stp *SQLTokenProvider) RevokeID(ctx context.Context, id string) error {
+	_, err := stp.db.ExecContext(ctx, stp.revoke_id_query, id)
+	return err
+}
+
+// like_escaper escape the wildcards of LIKE pattern with `!`
+var like_escaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// RevokeID invalidate the token listed by [CSRF.List] with `id`,
+// it return [ErrRevokeNotSupported] if the [TokenProvider] does not implement [IDRevoker]
+func (c *CSRF) RevokeID(ctx context.Context, id string) error {
+	revoker, ok := c.TokenProvider.(IDRevoker)
+	if !ok {
+		return ErrRevokeNotSupported
+	}
+
+	return revoker.RevokeID(ctx, id)
+}
+
+// RevokeAll invalidate every token bound to `subject`,
+// it return [ErrRevokeNotSupported] if the [TokenProvider] does not implement [Revoker]
+func (c *CSRF) RevokeAll(ctx context.Context, subject string) error {
+	revoker, ok := c.TokenProvider.(Revoker)
+	if !ok {
+		return ErrRevokeNotSupported
+	}
+
+	return revoker.RevokeAll(ctx, subject)
+}
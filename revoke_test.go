@@ -0,0 +1,115 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestMatchSubject(t *testing.T) {
+	tests := []struct {
+		session_id string
+		subject    string
+		match      bool
+	}{
+		{"alice", "alice", true},
+		{"alice\x00192.0.2.1", "alice", true},
+		{"alice\x01/delete", "alice", true},
+		{"alice\x00192.0.2.1\x01/delete", "alice", true},
+		{"alice\x00192.0.2.1", "alice\x00192.0.2.1", true},
+		{"alice\x00192.0.2.1", "alice\x00192.0.2.2", false},
+		{"alice2", "alice", false},
+		{"alic", "alice", false},
+		{"bob\x00alice", "alice", false},
+	}
+
+	for _, tt := range tests {
+		if got := csrf.MatchSubject(tt.session_id, tt.subject); got != tt.match {
+			t.Errorf("MatchSubject(%q, %q) = %v, want %v", tt.session_id, tt.subject, got, tt.match)
+		}
+	}
+}
+
+func TestRevokeAllWithBindings(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}),
+		csrf.WithSubject(func(r *http.Request) string { return r.Header.Get("X-User") }),
+		csrf.WithClientIPBinding(nil),
+		csrf.WithUserAgentBinding(),
+		csrf.WithScope(csrf.PathScope("/delete")),
+	)
+
+	request := func(target, user string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, target, nil)
+		r.Header.Set("X-User", user)
+		return r
+	}
+
+	issue := func(user, scope string) string {
+		t.Helper()
+
+		token, err := c.ScopedToken(request("/", user), scope)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	}
+
+	alice, alice_scoped := issue("alice", ""), issue("alice", "/delete")
+	bob, bob_scoped := issue("bob", ""), issue("bob", "/delete")
+
+	if err := c.RevokeAll(context.Background(), "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		user   string
+		token  string
+		err    error
+	}{
+		{"revoked", "/", "alice", alice, csrf.ErrTokenNotFound},
+		{"revoked scoped", "/delete", "alice", alice_scoped, csrf.ErrTokenNotFound},
+		{"other subject", "/", "bob", bob, nil},
+		{"other subject scoped", "/delete", "bob", bob_scoped, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := request(tt.target, tt.user)
+			r.Header.Set(csrf.DefaultHeaderName, tt.token)
+			if err := c.Validate(r, csrf.HeaderTokenSource); !errors.Is(err, tt.err) {
+				t.Fatalf("Validate = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRevokeAllAnonymous(t *testing.T) {
+	tp := new_provider(t, csrf.Config{})
+	ctx := context.Background()
+	anonymous, bound := must_get(t, tp, ctx), must_get(t, tp, csrf.WithSessionID(ctx, "\x00192.0.2.1"))
+	alice := csrf.WithSessionID(ctx, "alice")
+	kept := must_get(t, tp, alice)
+
+	if err := tp.RevokeAll(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tp.Check(ctx, anonymous); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("Check of anonymous = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+
+	if err := tp.Check(csrf.WithSessionID(ctx, "\x00192.0.2.1"), bound); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("Check of anonymous bound = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+
+	if err := tp.Check(alice, kept); err != nil {
+		t.Errorf("Check of subject = %v", err)
+	}
+}
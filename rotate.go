@@ -0,0 +1,104 @@
+package csrf
+
+import (
+	"context"
+	"net/http"
+)
+
+// Exchanger is optional interface for [TokenProvider] which can atomically consume token and issue its replacement
+type Exchanger interface {
+	// Exchange must return error [ErrInvalidToken] without issuing new token if `token` is not valid
+	Exchange(ctx context.Context, token string) (string, error)
+}
+
+func (dtp *DefaultTokenProvider) Exchange(ctx context.Context, token string) (string, error) {
+	new_token, err := GenerateToken(dtp.generate)
+	if err != nil {
+		return "", err
+	}
+
+	if err := dtp.exchange(ctx, token, new_token); err != nil {
+		return "", err
+	}
+
+	return new_token, nil
+}
+
+// exchange replace `old_key` with `new_key` under single lock
+func (dtp *DefaultTokenProvider) exchange(ctx context.Context, old_key, new_key string) error {
+	dtp.mu.Lock()
+	consumed, issued, err := dtp.exchange_locked(ctx, old_key, new_key)
+	dtp.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	call_hook(dtp.hooks.OnConsume, consumed.event(old_key))
+	call_hook(dtp.hooks.OnIssue, issued.event(new_key))
+	return nil
+}
+
+func (dtp *DefaultTokenProvider) exchange_locked(ctx context.Context, old_key, new_key string) (consumed, issued token_entry, err error) {
+	if err := dtp.check(ctx, old_key); err != nil {
+		return consumed, issued, err
+	}
+
+	// the replacement is stored first so the old token stay valid if it can not be issued, e.g. [ErrTooManyTokens]
+	consumed = dtp.tokens[old_key]
+	if err := dtp.store_locked(ctx, new_key); err != nil {
+		return consumed, issued, err
+	}
+
+	dtp.delete_locked(old_key)
+	return consumed, dtp.tokens[new_key], nil
+}
+
+func (htp *HashedTokenProvider) Exchange(ctx context.Context, token string) (string, error) {
+	if token == "" {
+		return "", ErrTokenMissing
+	}
+
+	new_token, err := GenerateToken(htp.dtp.generate)
+	if err != nil {
+		return "", err
+	}
+
+	if err := htp.dtp.exchange(ctx, hash_token(token), hash_token(new_token)); err != nil {
+		return "", err
+	}
+
+	return new_token, nil
+}
+
+// Rotate consume `old_token` and return its replacement, it is atomic if the [TokenProvider] implements [Exchanger],
+// otherwise the token is checked (and consumed in [SingleUse] mode) before new token is issued.
+// the subject and scope are taken from `r` like in [CSRF.Validate], so the replacement is bound the same way
+func (c *CSRF) Rotate(ctx context.Context, r *http.Request, old_token string) (new_token string, err error) {
+	ctx, span := c.tracer.Start(c.with_scope(c.with_subject(ctx, r), r), "csrf.Rotate")
+	defer func() { end_span(span, err) }()
+
+	if c.masking {
+		old_token = UnmaskToken(old_token)
+	}
+
+	if old_token == "" {
+		return "", ErrTokenMissing
+	}
+
+	if err := c.precheck(ctx, old_token); err != nil {
+		return "", err
+	}
+
+	if exchanger, ok := c.TokenProvider.(Exchanger); ok {
+		new_token, err = exchanger.Exchange(ctx, old_token)
+	} else if err = c.check(ctx, old_token); err == nil {
+		new_token, err = c.TokenProvider.Get(ctx)
+	}
+
+	if err != nil || !c.masking {
+		return new_token, err
+	}
+
+	return MaskToken(new_token)
+}
@@ -0,0 +1,75 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestRotate(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}),
+		csrf.WithSubject(func(r *http.Request) string { return r.Header.Get("X-User") }),
+		csrf.WithScope(csrf.PathScope("/delete")),
+	)
+
+	request := func(target, user, token string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, target, nil)
+		r.Header.Set("X-User", user)
+		r.Header.Set(csrf.DefaultHeaderName, token)
+		return r
+	}
+
+	old_token, err := c.ScopedToken(request("/", "alice", ""), "/delete")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Rotate(context.Background(), request("/delete", "bob", ""), old_token); !errors.Is(err, csrf.ErrInvalidToken) {
+		t.Fatalf("Rotate of other subject = %v, want %v", err, csrf.ErrInvalidToken)
+	}
+
+	new_token, err := c.Rotate(context.Background(), request("/delete", "alice", ""), old_token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		user   string
+		token  string
+		err    error
+	}{
+		{"consumed", "/delete", "alice", old_token, csrf.ErrInvalidToken},
+		{"other scope", "/", "alice", new_token, csrf.ErrInvalidToken},
+		{"other subject", "/delete", "bob", new_token, csrf.ErrInvalidToken},
+		{"replacement", "/delete", "alice", new_token, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.Validate(request(tt.target, tt.user, tt.token), csrf.HeaderTokenSource)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Validate = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestExchangeTooManyTokens(t *testing.T) {
+	tp := new_provider(t, csrf.Config{MaxTokens: 1})
+	ctx := context.Background()
+	token := must_get(t, tp, ctx)
+
+	if _, err := tp.Exchange(ctx, token); !errors.Is(err, csrf.ErrTooManyTokens) {
+		t.Fatalf("Exchange = %v, want %v", err, csrf.ErrTooManyTokens)
+	}
+
+	if err := tp.Check(ctx, token); err != nil {
+		t.Fatalf("Check of the old token = %v", err)
+	}
+}
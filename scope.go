@@ -0,0 +1,63 @@
+package csrf
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+// WithScope require token scoped by [CSRF.GetScopedToken] to the scope returned by `scope` for the request,
+// e.g. [PathScope] of high value endpoints, so token leaked from low value form can not be replayed there.
+// request with empty scope accept only unscoped token, and scoped token is accepted nowhere without this option
+func WithScope(scope func(*http.Request) string) Option {
+	return func(c *CSRF) {
+		c.scope = scope
+	}
+}
+
+// PathScope return scope for [WithScope] which is the request path if it is one of `paths`, otherwise empty
+func PathScope(paths ...string) func(*http.Request) string {
+	paths = slices.Clone(paths)
+	return func(r *http.Request) string {
+		if slices.Contains(paths, r.URL.Path) {
+			return r.URL.Path
+		}
+
+		return ""
+	}
+}
+
+// scoped append `scope` to `subject`, the separator differ from the one of the bindings
+func scoped(subject, scope string) string {
+	if scope == "" {
+		return subject
+	}
+
+	return subject + "\x01" + scope
+}
+
+// with_scope return `ctx` with the session id scoped to the request scope, see [WithScope]
+func (c *CSRF) with_scope(ctx context.Context, r *http.Request) context.Context {
+	if c.scope == nil {
+		return ctx
+	}
+
+	scope := c.scope(r)
+	if scope == "" {
+		return ctx
+	}
+
+	return WithSessionID(ctx, scoped(SessionIDFromContext(ctx), scope))
+}
+
+// GetScopedToken is [CSRF.GetToken] with token scoped to `scope`, e.g. the form action `/account/delete`,
+// it is only accepted by request of the same scope, see [WithScope]
+func (c *CSRF) GetScopedToken(ctx context.Context, scope string) (string, error) {
+	return c.GetToken(WithSessionID(ctx, scoped(SessionIDFromContext(ctx), scope)))
+}
+
+// ScopedToken is [CSRF.GetScopedToken] for request `r` with the subject and bindings applied like the middlewares do,
+// e.g. to render form of high value action from page handler
+func (c *CSRF) ScopedToken(r *http.Request, scope string) (string, error) {
+	return c.GetScopedToken(c.request_context(r), scope)
+}
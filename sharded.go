@@ -0,0 +1,156 @@
+package csrf
+
+import (
+	"context"
+	"hash/fnv"
+	"runtime"
+	"time"
+)
+
+// ShardedTokenProvider spread tokens over multiple [DefaultTokenProvider] by token hash,
+// so concurrent Get and Check do not contend on single mutex
+type ShardedTokenProvider struct {
+	shards []*DefaultTokenProvider
+}
+
+var (
+	_ TokenProvider         = (*ShardedTokenProvider)(nil)
+	_ Consumer              = (*ShardedTokenProvider)(nil)
+	_ Exchanger             = (*ShardedTokenProvider)(nil)
+	_ IssuedAtProvider      = (*ShardedTokenProvider)(nil)
+	_ TTLProvider           = (*ShardedTokenProvider)(nil)
+	_ TokenProviderWithMeta = (*ShardedTokenProvider)(nil)
+	_ MetaConsumer          = (*ShardedTokenProvider)(nil)
+	_ Revoker               = (*ShardedTokenProvider)(nil)
+)
+
+// NewShardedTokenProvider return [ShardedTokenProvider] with [Config.Shards] shards, default to [runtime.GOMAXPROCS].
+// [Config.MaxTokens] is split evenly between the shards
+func NewShardedTokenProvider(ctx context.Context, cfg Config) *ShardedTokenProvider {
+	if cfg.Shards <= 0 {
+		cfg.Shards = runtime.GOMAXPROCS(0)
+	}
+
+	if cfg.MaxTokens > 0 {
+		cfg.MaxTokens = (cfg.MaxTokens + cfg.Shards - 1) / cfg.Shards
+	}
+
+	stp := &ShardedTokenProvider{shards: make([]*DefaultTokenProvider, cfg.Shards)}
+	for i := range stp.shards {
+		stp.shards[i] = NewDefaultTokenProviderConfig(ctx, cfg)
+	}
+
+	return stp
+}
+
+func (stp *ShardedTokenProvider) shard(token string) *DefaultTokenProvider {
+	h := fnv.New32a()
+	h.Write([]byte(token))
+	return stp.shards[h.Sum32()%uint32(len(stp.shards))]
+}
+
+func (stp *ShardedTokenProvider) Get(ctx context.Context) (string, error) {
+	token, err := GenerateToken(stp.shards[0].generate)
+	if err != nil {
+		return "", err
+	}
+
+	if err := stp.shard(token).store(ctx, token); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (stp *ShardedTokenProvider) Check(ctx context.Context, token string) error {
+	return stp.shard(token).Check(ctx, token)
+}
+
+func (stp *ShardedTokenProvider) CheckAndConsume(ctx context.Context, token string) error {
+	return stp.shard(token).CheckAndConsume(ctx, token)
+}
+
+// Exchange issue the replacement before consuming the token, so the old token stay valid if the replacement
+// can not be issued, e.g. [ErrTooManyTokens]. it is atomic if both tokens land in the same shard,
+// otherwise the replacement is removed again if the token was consumed concurrently
+func (stp *ShardedTokenProvider) Exchange(ctx context.Context, token string) (string, error) {
+	if err := stp.Check(ctx, token); err != nil {
+		return "", err
+	}
+
+	new_token, err := GenerateToken(stp.shards[0].generate)
+	if err != nil {
+		return "", err
+	}
+
+	old_shard, new_shard := stp.shard(token), stp.shard(new_token)
+	if old_shard == new_shard {
+		if err := old_shard.exchange(ctx, token, new_token); err != nil {
+			return "", err
+		}
+
+		return new_token, nil
+	}
+
+	if err := new_shard.store(ctx, new_token); err != nil {
+		return "", err
+	}
+
+	if err := old_shard.CheckAndConsume(ctx, token); err != nil {
+		new_shard.discard(new_token)
+		return "", err
+	}
+
+	return new_token, nil
+}
+
+// discard delete `token` without calling the hooks, it roll back token which was never handed out
+func (dtp *DefaultTokenProvider) discard(token string) {
+	dtp.mu.Lock()
+	dtp.delete_locked(token)
+	dtp.mu.Unlock()
+}
+
+func (stp *ShardedTokenProvider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	return stp.shard(token).IssuedAt(ctx, token)
+}
+
+func (stp *ShardedTokenProvider) TTL() time.Duration { return stp.shards[0].TTL() }
+
+func (stp *ShardedTokenProvider) GetWithMeta(ctx context.Context, meta map[string]string) (string, error) {
+	token, err := GenerateToken(stp.shards[0].generate)
+	if err != nil {
+		return "", err
+	}
+
+	if err := stp.shard(token).store_meta(ctx, token, meta); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (stp *ShardedTokenProvider) CheckWithMeta(ctx context.Context, token string) (map[string]string, error) {
+	return stp.shard(token).CheckWithMeta(ctx, token)
+}
+
+func (stp *ShardedTokenProvider) CheckAndConsumeWithMeta(ctx context.Context, token string) (map[string]string, error) {
+	return stp.shard(token).CheckAndConsumeWithMeta(ctx, token)
+}
+
+func (stp *ShardedTokenProvider) RevokeAll(ctx context.Context, subject string) error {
+	for _, shard := range stp.shards {
+		if err := shard.RevokeAll(ctx, subject); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rotate is [DefaultTokenProvider.Rotate] on every shard
+func (stp *ShardedTokenProvider) Rotate() {
+	for _, shard := range stp.shards {
+		shard.Rotate()
+	}
+}
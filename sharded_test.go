@@ -0,0 +1,168 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+// shard_tokens return `n` tokens of shard `shard` out of `shards`, the same way [csrf.ShardedTokenProvider] pick the shard
+func shard_tokens(shard, shards, n int) []string {
+	var tokens []string
+	for i := 0; len(tokens) < n; i++ {
+		token := fmt.Sprintf("token-%d", i)
+		h := fnv.New32a()
+		h.Write([]byte(token))
+		if int(h.Sum32()%uint32(shards)) == shard {
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens
+}
+
+// generate_tokens return [csrf.GenerateTokenFunc] which generate `tokens` in order
+func generate_tokens(tokens ...string) csrf.GenerateTokenFunc {
+	return func() string {
+		token := tokens[0]
+		tokens = tokens[1:]
+		return token
+	}
+}
+
+func new_sharded_provider(t *testing.T, cfg csrf.Config) *csrf.ShardedTokenProvider {
+	stp := csrf.NewShardedTokenProvider(context.Background(), cfg)
+	t.Cleanup(func() { stp.Close() })
+	return stp
+}
+
+func TestNewShardedTokenProvider(t *testing.T) {
+	first, second := shard_tokens(0, 2, 3), shard_tokens(1, 2, 1)
+
+	// MaxTokens of 3 is 2 per shard
+	stp := new_sharded_provider(t, csrf.Config{
+		Shards:    2,
+		MaxTokens: 3,
+		Generator: generate_tokens(first[0], first[1], first[2], second[0]),
+	})
+
+	ctx := context.Background()
+	want := []error{nil, nil, csrf.ErrTooManyTokens, nil}
+	for i, err := range want {
+		if _, got := stp.Get(ctx); !errors.Is(got, err) {
+			t.Fatalf("Get #%d = %v, want %v", i, got, err)
+		}
+	}
+
+	for _, token := range []string{first[0], first[1], second[0]} {
+		if err := stp.Check(ctx, token); err != nil {
+			t.Errorf("Check(%s) = %v", token, err)
+		}
+	}
+
+	if err := stp.RevokeAll(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stp.Check(ctx, second[0]); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("Check of revoked token = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+}
+
+func TestShardedTokenProviderExchange(t *testing.T) {
+	first, second := shard_tokens(0, 2, 3), shard_tokens(1, 2, 3)
+
+	tests := []struct {
+		name string
+		// tokens generated in order, the first is exchanged for the last
+		tokens []string
+		err    error
+	}{
+		{"same shard", []string{first[0], first[1]}, nil},
+		{"other shard", []string{first[0], second[0]}, nil},
+		{"other shard full", []string{first[0], second[0], second[1], second[2]}, csrf.ErrTooManyTokens},
+		{"same shard full", []string{first[0], first[1], first[2]}, csrf.ErrTooManyTokens},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stp := new_sharded_provider(t, csrf.Config{
+				Shards:    2,
+				MaxTokens: 4,
+				Generator: generate_tokens(tt.tokens...),
+			})
+
+			ctx := context.Background()
+			for range tt.tokens[:len(tt.tokens)-1] {
+				must_get(t, stp, ctx)
+			}
+
+			old_token, new_token := tt.tokens[0], tt.tokens[len(tt.tokens)-1]
+			got, err := stp.Exchange(ctx, old_token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Exchange = %v, want %v", err, tt.err)
+			}
+
+			if err != nil {
+				if err := stp.Check(ctx, old_token); err != nil {
+					t.Errorf("Check of the old token = %v", err)
+				}
+				return
+			}
+
+			if got != new_token {
+				t.Errorf("Exchange = %s, want %s", got, new_token)
+			}
+
+			if err := stp.Check(ctx, old_token); !errors.Is(err, csrf.ErrTokenNotFound) {
+				t.Errorf("Check of the old token = %v, want %v", err, csrf.ErrTokenNotFound)
+			}
+
+			if err := stp.Check(ctx, new_token); err != nil {
+				t.Errorf("Check of the new token = %v", err)
+			}
+		})
+	}
+}
+
+func TestShardedTokenProviderExchangeInvalid(t *testing.T) {
+	stp := new_sharded_provider(t, csrf.Config{Shards: 2, Generator: generate_tokens()})
+
+	// the generator panics if the replacement is generated
+	if _, err := stp.Exchange(context.Background(), "unknown"); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Fatalf("Exchange = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+}
+
+func TestShardedTokenProviderExchangeRollback(t *testing.T) {
+	old_token, new_token := shard_tokens(0, 2, 1)[0], shard_tokens(1, 2, 1)[0]
+
+	var stp *csrf.ShardedTokenProvider
+	stp = new_sharded_provider(t, csrf.Config{
+		Shards:    2,
+		Generator: generate_tokens(old_token, new_token),
+		Hooks: csrf.Hooks{
+			// the old token is consumed concurrently once the replacement is issued
+			OnIssue: func(e csrf.TokenEvent) {
+				if e.Token == new_token {
+					stp.CheckAndConsume(context.Background(), old_token)
+				}
+			},
+		},
+	})
+
+	ctx := context.Background()
+	must_get(t, stp, ctx)
+
+	if _, err := stp.Exchange(ctx, old_token); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Fatalf("Exchange = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+
+	if err := stp.Check(ctx, new_token); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Errorf("Check of the rolled back token = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+}
@@ -0,0 +1,113 @@
+package csrf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func sign_token(token string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignTokenPair return `token + sep + signature` where signature is base64 encoded HMAC-SHA256 of the token
+func SignTokenPair(token, sep string, key []byte) string {
+	return token + sep + sign_token(token, key)
+}
+
+// PairCookieTokenSource return token from cookie with value created by [SignTokenPair],
+// or empty string if the cookie is missing, malformed or the signature does not match
+func PairCookieTokenSource(name, sep string, key []byte) TokenSourceFunc {
+	return func(r *http.Request) string {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+
+		// token may contain the separator, signature never does unless sep is base64url alphabet
+		i := strings.LastIndex(cookie.Value, sep)
+		if !(sep != "" && i > 0) {
+			return ""
+		}
+
+		token, signature := cookie.Value[:i], cookie.Value[i+len(sep):]
+
+		if !hmac.Equal([]byte(signature), []byte(sign_token(token, key))) {
+			return ""
+		}
+
+		return token
+	}
+}
+
+// signed_cookie_mac return base64 encoded HMAC-SHA256 of `name`, `token` and `expiry` separated by NUL
+func signed_cookie_mac(name, token, expiry string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(token))
+	mac.Write([]byte{0})
+	mac.Write([]byte(expiry))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignCookieValue return value of cookie `name` for [SignedCookieTokenSource] in the form of `token.expiry.signature`,
+// expiry is unix seconds and the signature is HMAC-SHA256 over the cookie name, token and expiry.
+// zero `expire_at` never expire
+func SignCookieValue(name, token string, expire_at time.Time, key []byte) string {
+	expiry := "0"
+	if !expire_at.IsZero() {
+		expiry = strconv.FormatInt(expire_at.Unix(), 10)
+	}
+
+	return token + "." + expiry + "." + signed_cookie_mac(name, token, expiry, key)
+}
+
+// SignedCookieTokenSource return token from cookie created by [SignCookieValue], or empty string if the cookie is missing,
+// malformed, expired or the signature does not match. the signature cover the cookie name, so cookie injected
+// by sibling subdomain or app must be signed with the same key and can not be copied from another cookie
+func SignedCookieTokenSource(name string, key []byte) TokenSourceFunc {
+	return func(r *http.Request) string {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+
+		// token may contain dot, expiry and signature never do
+		rest, signature, found := cut_last(cookie.Value, ".")
+		if !found {
+			return ""
+		}
+
+		token, expiry, found := cut_last(rest, ".")
+		if !(found && token != "") {
+			return ""
+		}
+
+		if !hmac.Equal([]byte(signature), []byte(signed_cookie_mac(name, token, expiry, key))) {
+			return ""
+		}
+
+		expire_at, err := strconv.ParseInt(expiry, 10, 64)
+		if err != nil || (expire_at != 0 && time.Now().Unix() >= expire_at) {
+			return ""
+		}
+
+		return token
+	}
+}
+
+// cut_last slice `s` around the last instance of `sep`
+func cut_last(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
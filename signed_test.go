@@ -0,0 +1,79 @@
+package csrf_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+)
+
+func with_cookie(name, value string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	if value != "" {
+		r.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+
+	return r
+}
+
+func TestPairCookieTokenSource(t *testing.T) {
+	key := []byte("key")
+	pair := csrf.SignTokenPair("token", "|", key)
+
+	tests := []struct {
+		name  string
+		sep   string
+		value string
+		want  string
+	}{
+		{"valid", "|", pair, "token"},
+		{"separator in token", "|", csrf.SignTokenPair("to|ken", "|", key), "to|ken"},
+		{"multi byte separator", "--", csrf.SignTokenPair("token", "--", key), "token"},
+		{"tampered signature", "|", pair[:len(pair)-1] + "A", ""},
+		{"tampered token", "|", "other" + pair[len("token"):], ""},
+		{"wrong key", "|", csrf.SignTokenPair("token", "|", []byte("other")), ""},
+		{"missing separator", "|", "token", ""},
+		{"empty token", "|", pair[len("token"):], ""},
+		{"empty separator", "", pair, ""},
+		{"no cookie", "|", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := csrf.PairCookieTokenSource("csrf", tt.sep, key)
+			if got := source(with_cookie("csrf", tt.value)); got != tt.want {
+				t.Errorf("source = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignedCookieTokenSource(t *testing.T) {
+	key := []byte("key")
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		cookie string
+		value  string
+		want   string
+	}{
+		{"valid", "csrf", csrf.SignCookieValue("csrf", "token", future, key), "token"},
+		{"never expire", "csrf", csrf.SignCookieValue("csrf", "to.ken", time.Time{}, key), "to.ken"},
+		{"expired", "csrf", csrf.SignCookieValue("csrf", "token", time.Now().Add(-time.Second), key), ""},
+		{"other cookie name", "csrf", csrf.SignCookieValue("session", "token", future, key), ""},
+		{"wrong key", "csrf", csrf.SignCookieValue("csrf", "token", future, []byte("other")), ""},
+		{"malformed", "csrf", "token", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := csrf.SignedCookieTokenSource("csrf", key)
+			if got := source(with_cookie(tt.cookie, tt.value)); got != tt.want {
+				t.Errorf("source = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package csrf
+
+import (
+	"net/http"
+	"path"
+	"slices"
+)
+
+// WithSkipper skip the validation in [CSRF.Protect] and [CSRF.ValidateMiddleware] if `skip` return true,
+// e.g. for webhooks authenticated by signature. it can be given multiple times
+func WithSkipper(skip func(*http.Request) bool) Option {
+	return func(c *CSRF) {
+		c.skippers = append(c.skippers, skip)
+	}
+}
+
+// WithExcludedPaths skip the validation of request whose url path match any of `patterns`, see [path.Match],
+// e.g. `/webhooks/*` or `/healthz`
+func WithExcludedPaths(patterns ...string) Option {
+	patterns = slices.Clone(patterns)
+	return WithSkipper(func(r *http.Request) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, r.URL.Path); ok {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// Skip report whether `r` is not validated by the middlewares, either safe method (see [WithSafeMethods])
+// or skipped by [WithSkipper], for adapters of other frameworks
+func (c *CSRF) Skip(r *http.Request) bool {
+	if slices.Contains(c.safe_methods, r.Method) {
+		return true
+	}
+
+	for _, skip := range c.skippers {
+		if skip(r) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,45 @@
+package csrf_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestSkip(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}),
+		csrf.WithExcludedPaths("/webhooks/*", "/healthz"),
+		csrf.WithSkipper(func(r *http.Request) bool { return r.Header.Get("X-Signature") != "" }),
+	)
+
+	signed := post("/", "")
+	signed.Header.Set("X-Signature", "sig")
+
+	tests := []struct {
+		name string
+		r    *http.Request
+		want bool
+	}{
+		{"safe method", httptest.NewRequest(http.MethodGet, "/", nil), true},
+		{"excluded path", post("/healthz", ""), true},
+		{"excluded pattern", post("/webhooks/github", ""), true},
+		{"pattern does not cross segments", post("/webhooks/github/push", ""), false},
+		{"skipper", signed, true},
+		{"protected", post("/", ""), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Skip(tt.r); got != tt.want {
+				t.Fatalf("Skip = %v, want %v", got, tt.want)
+			}
+
+			if reached, _ := csrftest.Serve(c.Middleware(), tt.r); reached != tt.want {
+				t.Errorf("Middleware reached = %v, want %v", reached, tt.want)
+			}
+		})
+	}
+}
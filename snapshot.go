@@ -0,0 +1,146 @@
+package csrf
+
+import (
+	"container/heap"
+	"encoding/gob"
+	"fmt"
+	"io"
+)
+
+const snapshot_version = 1
+
+type snapshot struct {
+	Version int
+	Tokens  []snapshot_token
+}
+
+type snapshot_token struct {
+	Token    string
+	Subject  string
+	ExpireAt int64
+	IssuedAt int64
+	Meta     map[string]string
+}
+
+// snapshot return the valid tokens of the current generation
+func (dtp *DefaultTokenProvider) snapshot() []snapshot_token {
+	now := dtp.clock.Now().Unix()
+	generation := dtp.generation.Load()
+
+	dtp.mu.RLock()
+	defer dtp.mu.RUnlock()
+
+	tokens := make([]snapshot_token, 0, len(dtp.tokens))
+	for token, entry := range dtp.tokens {
+		if entry.expire_at <= now || entry.generation != generation {
+			continue
+		}
+
+		tokens = append(tokens, snapshot_token{
+			Token:    token,
+			Subject:  entry.subject,
+			ExpireAt: entry.expire_at,
+			IssuedAt: entry.issued_at,
+			Meta:     entry.meta,
+		})
+	}
+
+	return tokens
+}
+
+// restore add the unexpired tokens to the current generation and the subject index, tokens already stored are kept.
+// [Config.MaxTokens] is not enforced
+func (dtp *DefaultTokenProvider) restore(tokens []snapshot_token) {
+	now := dtp.clock.Now().Unix()
+	generation := dtp.generation.Load()
+
+	dtp.mu.Lock()
+	defer dtp.mu.Unlock()
+
+	for _, t := range tokens {
+		if _, found := dtp.tokens[t.Token]; found || t.ExpireAt <= now {
+			continue
+		}
+
+		heap.Push(&dtp.expiry, expiry_item{expire_at: t.ExpireAt, token: t.Token})
+		dtp.tokens[t.Token] = token_entry{
+			expire_at:  t.ExpireAt,
+			issued_at:  t.IssuedAt,
+			generation: generation,
+			subject:    t.Subject,
+			meta:       t.Meta,
+		}
+		dtp.index_locked(t.Token, t.Subject)
+	}
+}
+
+func write_snapshot(w io.Writer, tokens []snapshot_token) error {
+	return gob.NewEncoder(w).Encode(snapshot{Version: snapshot_version, Tokens: tokens})
+}
+
+func read_snapshot(r io.Reader) ([]snapshot_token, error) {
+	var s snapshot
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+
+	if s.Version != snapshot_version {
+		return nil, fmt.Errorf("unsupported snapshot version %d", s.Version)
+	}
+
+	return s.Tokens, nil
+}
+
+// Save write the outstanding tokens to `w`, so they survive restart when loaded back with [DefaultTokenProvider.Load]
+func (dtp *DefaultTokenProvider) Save(w io.Writer) error {
+	return write_snapshot(w, dtp.snapshot())
+}
+
+// Load add the unexpired tokens written by [DefaultTokenProvider.Save] to the provider
+func (dtp *DefaultTokenProvider) Load(r io.Reader) error {
+	tokens, err := read_snapshot(r)
+	if err != nil {
+		return err
+	}
+
+	dtp.restore(tokens)
+	return nil
+}
+
+// Save is [DefaultTokenProvider.Save], only the token hashes are written
+func (htp *HashedTokenProvider) Save(w io.Writer) error {
+	return htp.dtp.Save(w)
+}
+
+func (htp *HashedTokenProvider) Load(r io.Reader) error {
+	return htp.dtp.Load(r)
+}
+
+// Save write the tokens of every shard, it can be loaded with different number of shards
+func (stp *ShardedTokenProvider) Save(w io.Writer) error {
+	var tokens []snapshot_token
+	for _, shard := range stp.shards {
+		tokens = append(tokens, shard.snapshot()...)
+	}
+
+	return write_snapshot(w, tokens)
+}
+
+func (stp *ShardedTokenProvider) Load(r io.Reader) error {
+	tokens, err := read_snapshot(r)
+	if err != nil {
+		return err
+	}
+
+	shards := make(map[*DefaultTokenProvider][]snapshot_token)
+	for _, t := range tokens {
+		shard := stp.shard(t.Token)
+		shards[shard] = append(shards[shard], t)
+	}
+
+	for shard, tokens := range shards {
+		shard.restore(tokens)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package csrf_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestLoad(t *testing.T) {
+	alice := csrf.WithSessionID(context.Background(), "alice")
+	clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+	saved := new_provider(t, csrf.Config{Clock: clock})
+	first := must_get(t, saved, alice)
+	clock.Advance(time.Second)
+	second := must_get(t, saved, alice)
+
+	var buf bytes.Buffer
+	if err := saved.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	tp := new_provider(t, csrf.Config{MaxTokensPerSubject: 2, Clock: clock})
+	if err := tp.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{first, second} {
+		if err := tp.Check(alice, token); err != nil {
+			t.Fatalf("Check of loaded token = %v", err)
+		}
+	}
+
+	// the loaded tokens count against the limit, the oldest is evicted first
+	third := must_get(t, tp, alice)
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"oldest", first, csrf.ErrTokenNotFound},
+		{"loaded", second, nil},
+		{"issued", third, nil},
+	}
+
+	for _, tt := range tests {
+		if err := tp.Check(alice, tt.token); !errors.Is(err, tt.err) {
+			t.Errorf("Check of %s = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
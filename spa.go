@@ -0,0 +1,65 @@
+package csrf
+
+import (
+	"net/http"
+	"slices"
+)
+
+// SPA is [CSRF] preset for single page application using cookie and header flow,
+// the token is issued as cookie readable by javascript which must echo it in the header named by [WithHeaderName]
+type SPA struct {
+	*CSRF
+}
+
+// NewSPA return [SPA] with [WithMasking], [MultiUse] token and [JSONErrorHandler],
+// `opts` are applied after the preset and may override it
+func NewSPA(tp TokenProvider, opts ...Option) *SPA {
+	preset := []Option{
+		WithMasking(),
+		WithTokenMode(MultiUse),
+		WithErrorHandler(JSONErrorHandler),
+	}
+
+	return &SPA{New(tp, append(preset, opts...)...)}
+}
+
+// Middleware issue token cookie on safe request if the cookie is missing or no longer valid,
+// other requests must carry the same token in both the cookie and the header
+func (s *SPA) Middleware(next http.Handler) http.Handler {
+	// cookie first, empty source after the first token is mismatch so the header can not be omitted
+	protect := s.Protect(s.error_handler.stop(), s.CookieSource(), s.HeaderSource())(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(s.safe_methods, r.Method) {
+			protect.ServeHTTP(w, r)
+			return
+		}
+
+		token := s.CookieSource()(r)
+		if !s.valid_token(r, token) {
+			var err error
+			token, err = s.GetToken(s.request_context(r))
+			if err != nil {
+				issue_error(w, err)
+				return
+			}
+
+			http.SetCookie(w, token_cookie(r, s.cookie_name, token, false))
+		}
+
+		next.ServeHTTP(w, r.WithContext(with_token(r.Context(), token)))
+	})
+}
+
+// valid_token report whether `token` issued to the client is still valid in the [TokenProvider],
+// it is not consumed so the middlewares can keep handing out token until it is used or expired
+func (c *CSRF) valid_token(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	if c.masking {
+		token = UnmaskToken(token)
+	}
+
+	return c.TokenProvider.Check(c.request_context(r), token) == nil
+}
@@ -0,0 +1,94 @@
+package csrf_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestSPA(t *testing.T) {
+	spa := csrf.NewSPA(new_provider(t, csrf.Config{}))
+	h := spa.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(r *http.Request, cookie_value string) *httptest.ResponseRecorder {
+		if cookie_value != "" {
+			r.AddCookie(&http.Cookie{Name: csrf.DefaultCookieName, Value: cookie_value})
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+
+	issued := cookie(do(httptest.NewRequest(http.MethodGet, "/", nil), ""), csrf.DefaultCookieName)
+	if issued == nil || issued.Value == "" {
+		t.Fatal("no token cookie issued")
+	}
+
+	if issued.HttpOnly {
+		t.Error("token cookie is not readable by javascript")
+	}
+
+	if again := cookie(do(httptest.NewRequest(http.MethodGet, "/", nil), issued.Value), csrf.DefaultCookieName); again != nil {
+		t.Errorf("valid cookie was replaced by %s", again.Value)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"no header", "", http.StatusForbidden},
+		{"other header", "unknown", http.StatusForbidden},
+		{"same token", issued.Value, http.StatusOK},
+		// multi use by default
+		{"reused", issued.Value, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(post("/", tt.header), issued.Value)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+
+			if tt.want == http.StatusForbidden && rec.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("Content-Type = %s, want the JSON preset", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestJSONErrorHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	csrf.JSONErrorHandler(rec, post("/", ""), csrf.ErrTokenMissing)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	var body map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"error":   "csrf_token_invalid",
+		"reason":  csrf.FailureReason(csrf.ErrTokenMissing),
+		"message": csrf.FailureMessage(csrf.ErrTokenMissing),
+	}
+	for k, v := range want {
+		if body[k] != v {
+			t.Errorf("%s = %q, want %q", k, body[k], v)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	csrf.JSONErrorHandler(rec, post("/", ""), nil)
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("nil error wrote %d %q", rec.Code, rec.Body.String())
+	}
+}
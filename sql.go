@@ -0,0 +1,216 @@
+package csrf
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SQLConfig for [NewSQLTokenProvider]
+type SQLConfig struct {
+	// Table name, default to `csrf_tokens`. it is interpolated into the queries as is and must be trusted
+	Table string
+	// TTL of issued token, default to [DefaultTTL]
+	TTL time.Duration
+	// CleanupInterval is the interval of expired rows removal, zero disable the cleanup
+	CleanupInterval time.Duration
+	// Generator of new token, default to [UUIDTokenGenerator]
+	Generator GenerateTokenFunc
+	// Clock default to [SystemClock]
+	Clock Clock
+	// GracePeriod keep accepting token for this long after it expired, see [Config.GracePeriod]
+	GracePeriod time.Duration
+	// DollarPlaceholder use `$1` placeholder (postgres) instead of `?` (mysql, sqlite)
+	DollarPlaceholder bool
+}
+
+// SQLTokenProvider store tokens in relational database through [database/sql].
+// the table can be created with [SQLTokenProvider.CreateTable]
+type SQLTokenProvider struct {
+	db          *sql.DB
+	table       string
+	placeholder func(int) string
+	token_ttl   time.Duration
+	grace       time.Duration
+	generate    GenerateTokenFunc
+
+	insert_query    string
+	check_query     string
+	consume_query   string
+	issued_at_query string
+	cleanup_query   string
+	revoke_query    string
+	revoke_id_query string
+	list_query      string
+
+	stop_gc context.CancelFunc
+	gc_done chan struct{}
+	clock   Clock
+}
+
+var (
+	_ TokenProvider    = (*SQLTokenProvider)(nil)
+	_ Consumer         = (*SQLTokenProvider)(nil)
+	_ IssuedAtProvider = (*SQLTokenProvider)(nil)
+	_ TTLProvider      = (*SQLTokenProvider)(nil)
+)
+
+func NewSQLTokenProvider(ctx context.Context, db *sql.DB, cfg SQLConfig) *SQLTokenProvider {
+	if cfg.Table == "" {
+		cfg.Table = "csrf_tokens"
+	}
+
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+
+	if cfg.Generator == nil {
+		cfg.Generator = UUIDTokenGenerator
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock{}
+	}
+
+	p := func(i int) string { return "?" }
+	if cfg.DollarPlaceholder {
+		p = func(i int) string { return fmt.Sprintf("$%d", i) }
+	}
+
+	stp := &SQLTokenProvider{
+		db:          db,
+		gc_done:     make(chan struct{}),
+		clock:       cfg.Clock,
+		table:       cfg.Table,
+		placeholder: p,
+		token_ttl:   cfg.TTL,
+		grace:       cfg.GracePeriod,
+		generate:    cfg.Generator,
+
+		insert_query:    fmt.Sprintf("INSERT INTO %s (token, id, subject, expire_at, issued_at) VALUES (%s, %s, %s, %s, %s)", cfg.Table, p(1), p(2), p(3), p(4), p(5)),
+		check_query:     fmt.Sprintf("SELECT 1 FROM %s WHERE token = %s AND subject = %s AND expire_at > %s", cfg.Table, p(1), p(2), p(3)),
+		consume_query:   fmt.Sprintf("DELETE FROM %s WHERE token = %s AND subject = %s AND expire_at > %s", cfg.Table, p(1), p(2), p(3)),
+		issued_at_query: fmt.Sprintf("SELECT issued_at FROM %s WHERE token = %s", cfg.Table, p(1)),
+		cleanup_query:   fmt.Sprintf("DELETE FROM %s WHERE expire_at <= %s", cfg.Table, p(1)),
+		revoke_query: fmt.Sprintf("DELETE FROM %s WHERE subject = %s OR subject LIKE %s ESCAPE '!' OR subject LIKE %s ESCAPE '!'",
+			cfg.Table, p(1), p(2), p(3)),
+		revoke_id_query: fmt.Sprintf("DELETE FROM %s WHERE id = %s", cfg.Table, p(1)),
+		list_query: fmt.Sprintf("SELECT id, subject, expire_at, issued_at FROM %s WHERE id > %s AND expire_at > %s ORDER BY id LIMIT %s",
+			cfg.Table, p(1), p(2), p(3)),
+	}
+
+	ctx, stp.stop_gc = context.WithCancel(ctx)
+	if cfg.CleanupInterval > 0 {
+		go stp.gc(ctx, cfg.CleanupInterval)
+	} else {
+		close(stp.gc_done)
+	}
+
+	return stp
+}
+
+// CreateTable create the token table if not exists, `id` is the [TokenID] of the token for [SQLTokenProvider.List]
+func (stp *SQLTokenProvider) CreateTable(ctx context.Context) error {
+	_, err := stp.db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (token VARCHAR(255) PRIMARY KEY, id CHAR(64) NOT NULL UNIQUE, subject TEXT NOT NULL, expire_at BIGINT NOT NULL, issued_at BIGINT NOT NULL)",
+		stp.table,
+	))
+	return err
+}
+
+func (stp *SQLTokenProvider) gc(ctx context.Context, interval time.Duration) {
+	defer close(stp.gc_done)
+
+	timer := stp.clock.NewTimer(interval)
+	defer timer.Stop()
+
+	ctx_done := ctx.Done()
+	for {
+		select {
+		case <-ctx_done:
+			return
+		case <-timer.C():
+		}
+
+		// error is ignored, the rows will be removed on the next run
+		_, _ = stp.db.ExecContext(ctx, stp.cleanup_query, stp.valid_after())
+		timer.Reset(interval)
+	}
+}
+
+// sql_subject escape the NUL and SOH separators of the subject, see [MatchSubject],
+// with printable `~` sequences since databases like postgres reject NUL in text columns.
+// the encoding keep the prefix of every bound or scoped subject so [SQLTokenProvider.RevokeAll] can match them with LIKE
+var (
+	sql_subject   = strings.NewReplacer("~", "~~", "\x00", "~0", "\x01", "~1")
+	sql_unsubject = strings.NewReplacer("~~", "~", "~0", "\x00", "~1", "\x01")
+)
+
+// valid_after return the unix time expire_at must be after for the token to be valid, see [SQLConfig.GracePeriod]
+func (stp *SQLTokenProvider) valid_after() int64 {
+	return stp.clock.Now().Add(-stp.grace).Unix()
+}
+
+// Get issue token bound to the session id from context, see [WithSessionID]
+func (stp *SQLTokenProvider) Get(ctx context.Context) (string, error) {
+	token, err := GenerateToken(stp.generate)
+	if err != nil {
+		return "", err
+	}
+
+	now := stp.clock.Now()
+	_, err = stp.db.ExecContext(ctx, stp.insert_query, token, TokenID(token), sql_subject.Replace(SessionIDFromContext(ctx)), now.Add(TTLFromContext(ctx, stp.token_ttl)).Unix(), now.UnixNano())
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Check return [ErrTokenNotFound] if there is no unexpired row bound to the session id from context
+func (stp *SQLTokenProvider) Check(ctx context.Context, token string) error {
+	var found int
+	err := stp.db.QueryRowContext(ctx, stp.check_query, token, sql_subject.Replace(SessionIDFromContext(ctx)), stp.valid_after()).Scan(&found)
+	if err == sql.ErrNoRows {
+		return ErrTokenNotFound
+	}
+
+	return err
+}
+
+// CheckAndConsume delete the token and return [ErrTokenNotFound] if no unexpired row bound to the session id from context was deleted
+func (stp *SQLTokenProvider) CheckAndConsume(ctx context.Context, token string) error {
+	result, err := stp.db.ExecContext(ctx, stp.consume_query, token, sql_subject.Replace(SessionIDFromContext(ctx)), stp.valid_after())
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n != 1 {
+		return ErrTokenNotFound
+	}
+
+	return nil
+}
+
+func (stp *SQLTokenProvider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	var issued_at int64
+	err := stp.db.QueryRowContext(ctx, stp.issued_at_query, token).Scan(&issued_at)
+	if err == sql.ErrNoRows {
+		return time.Time{}, ErrTokenNotFound
+	}
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(0, issued_at), nil
+}
+
+func (stp *SQLTokenProvider) TTL() time.Duration { return stp.token_ttl }
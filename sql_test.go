@@ -0,0 +1,126 @@
+package csrf_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+
+	_ "modernc.org/sqlite"
+)
+
+func new_sql_provider(t *testing.T) (*csrf.SQLTokenProvider, *sql.DB) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every connection of :memory: is separate database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	stp := csrf.NewSQLTokenProvider(context.Background(), db, csrf.SQLConfig{})
+	t.Cleanup(func() { stp.Close() })
+
+	if err := stp.CreateTable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return stp, db
+}
+
+func TestSQLTokenProvider(t *testing.T) {
+	stp, db := new_sql_provider(t)
+
+	subjects := []string{"alice", "alice\x00127.0.0.1", "alice\x00127.0.0.1\x01/delete", "bob~0%_"}
+	for _, subject := range subjects {
+		t.Run(subject, func(t *testing.T) {
+			ctx := csrf.WithSessionID(context.Background(), subject)
+			token := must_get(t, stp, ctx)
+
+			if err := stp.Check(ctx, token); err != nil {
+				t.Fatalf("Check = %v", err)
+			}
+
+			if errs := stp.CheckBatch(ctx, []string{token}); errs[0] != nil {
+				t.Fatalf("CheckBatch = %v", errs[0])
+			}
+
+			other := csrf.WithSessionID(context.Background(), subject+"\x00::1")
+			if err := stp.Check(other, token); !errors.Is(err, csrf.ErrTokenNotFound) {
+				t.Fatalf("Check of other binding = %v, want %v", err, csrf.ErrTokenNotFound)
+			}
+
+			tokens, _, err := stp.List(ctx, "", 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, info := range tokens {
+				if info.ID == csrf.TokenID(token) && info.Subject != subject {
+					t.Errorf("List subject = %q, want %q", info.Subject, subject)
+				}
+			}
+
+			if err := stp.CheckAndConsume(ctx, token); err != nil {
+				t.Fatalf("CheckAndConsume = %v", err)
+			}
+
+			if err := stp.CheckAndConsume(ctx, token); !errors.Is(err, csrf.ErrTokenNotFound) {
+				t.Fatalf("CheckAndConsume of consumed token = %v, want %v", err, csrf.ErrTokenNotFound)
+			}
+		})
+	}
+
+	// postgres reject NUL in text columns
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM csrf_tokens WHERE instr(subject, char(0)) > 0").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Errorf("%d subjects stored with NUL", n)
+	}
+}
+
+func TestSQLTokenProviderRevokeAll(t *testing.T) {
+	stp, _ := new_sql_provider(t)
+
+	tests := []struct {
+		subject string
+		revoked bool
+	}{
+		{"alice", true},
+		{"alice\x00127.0.0.1", true},
+		{"alice\x01/delete", true},
+		{"alice\x00127.0.0.1\x01/delete", true},
+		{"alice~0", false},
+		{"alicex", false},
+		{"alic_", false},
+		{"bob", false},
+	}
+
+	tokens := make([]string, len(tests))
+	for i, tt := range tests {
+		tokens[i] = must_get(t, stp, csrf.WithSessionID(context.Background(), tt.subject))
+	}
+
+	if err := stp.RevokeAll(context.Background(), "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tt := range tests {
+		t.Run(strings.ReplaceAll(tt.subject, "\x00", "/"), func(t *testing.T) {
+			var want error
+			if tt.revoked {
+				want = csrf.ErrTokenNotFound
+			}
+
+			if err := stp.Check(csrf.WithSessionID(context.Background(), tt.subject), tokens[i]); !errors.Is(err, want) {
+				t.Errorf("Check = %v, want %v", err, want)
+			}
+		})
+	}
+}
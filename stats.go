@@ -0,0 +1,81 @@
+package csrf
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is runtime statistics of [TokenProvider], the counters are cumulative since creation
+type Stats struct {
+	// Tokens is the number of currently stored tokens, including expired tokens not yet collected
+	Tokens    int
+	Issued    uint64
+	Validated uint64
+	Rejected  uint64
+	Expired   uint64
+	Evicted   uint64
+	// Grace is the number of tokens accepted within [Config.GracePeriod] after they expired
+	Grace uint64
+	// LastGCDuration is the duration of the last gc run
+	LastGCDuration time.Duration
+}
+
+// StatsProvider is optional interface for [TokenProvider] which expose its runtime statistics
+type StatsProvider interface {
+	Stats() Stats
+}
+
+var (
+	_ StatsProvider = (*DefaultTokenProvider)(nil)
+	_ StatsProvider = (*HashedTokenProvider)(nil)
+	_ StatsProvider = (*ShardedTokenProvider)(nil)
+)
+
+type provider_stats struct {
+	issued    atomic.Uint64
+	validated atomic.Uint64
+	rejected  atomic.Uint64
+	expired   atomic.Uint64
+	evicted   atomic.Uint64
+	grace     atomic.Uint64
+	last_gc   atomic.Int64
+}
+
+func (dtp *DefaultTokenProvider) Stats() Stats {
+	dtp.mu.RLock()
+	tokens := len(dtp.tokens)
+	dtp.mu.RUnlock()
+
+	return Stats{
+		Tokens:         tokens,
+		Issued:         dtp.stats.issued.Load(),
+		Validated:      dtp.stats.validated.Load(),
+		Rejected:       dtp.stats.rejected.Load(),
+		Expired:        dtp.stats.expired.Load(),
+		Evicted:        dtp.stats.evicted.Load(),
+		Grace:          dtp.stats.grace.Load(),
+		LastGCDuration: time.Duration(dtp.stats.last_gc.Load()),
+	}
+}
+
+func (htp *HashedTokenProvider) Stats() Stats {
+	return htp.dtp.Stats()
+}
+
+// Stats return the sum of every shard statistics, LastGCDuration is the longest of the shards
+func (stp *ShardedTokenProvider) Stats() Stats {
+	var total Stats
+	for _, shard := range stp.shards {
+		stats := shard.Stats()
+		total.Tokens += stats.Tokens
+		total.Issued += stats.Issued
+		total.Validated += stats.Validated
+		total.Rejected += stats.Rejected
+		total.Expired += stats.Expired
+		total.Evicted += stats.Evicted
+		total.Grace += stats.Grace
+		total.LastGCDuration = max(total.LastGCDuration, stats.LastGCDuration)
+	}
+
+	return total
+}
@@ -0,0 +1,30 @@
+package csrf
+
+import (
+	"context"
+	"net/http"
+)
+
+// WithSubject bind tokens to the session or user identifier returned by `subject`,
+// the identifier is passed to the [TokenProvider] through the context (see [WithSessionID])
+// for tokens issued by the middlewares and for every validation, so token can not be replayed across accounts
+func WithSubject(subject func(*http.Request) string) Option {
+	return func(c *CSRF) {
+		c.subject = subject
+	}
+}
+
+// GetTokenFor is [CSRF.GetToken] with token bound to `subject`
+func (c *CSRF) GetTokenFor(ctx context.Context, subject string) (string, error) {
+	return c.GetToken(WithSessionID(ctx, subject))
+}
+
+// GetFor issue token bound to `subject`
+func (dtp *DefaultTokenProvider) GetFor(ctx context.Context, subject string) (string, error) {
+	return dtp.Get(WithSessionID(ctx, subject))
+}
+
+// CheckFor check token bound to `subject`
+func (dtp *DefaultTokenProvider) CheckFor(ctx context.Context, subject, token string) error {
+	return dtp.Check(WithSessionID(ctx, subject), token)
+}
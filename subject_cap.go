@@ -0,0 +1,60 @@
+package csrf
+
+// index_locked record `token` bound to `session_id` in the index of its subject (see [MatchSubject]) and evict
+// the oldest tokens of the subject above [Config.MaxTokensPerSubject], it must be called with dtp.mu held.
+// tokens without subject are not indexed
+func (dtp *DefaultTokenProvider) index_locked(token, session_id string) {
+	subject := SubjectOf(session_id)
+	if subject == "" {
+		return
+	}
+
+	if dtp.by_subject == nil {
+		dtp.by_subject = make(map[string]map[string]struct{})
+	}
+
+	tokens := dtp.by_subject[subject]
+	if tokens == nil {
+		tokens = make(map[string]struct{})
+		dtp.by_subject[subject] = tokens
+	}
+	tokens[token] = struct{}{}
+
+	// consumed and expired tokens are removed from the index by delete_locked, so every token counts
+	for dtp.max_per_subject > 0 && len(tokens) > dtp.max_per_subject {
+		dtp.delete_locked(dtp.oldest_locked(tokens, token))
+		dtp.stats.evicted.Add(1)
+		dtp.logger.Debug("csrf: token evicted", "max_tokens_per_subject", dtp.max_per_subject)
+	}
+}
+
+// oldest_locked return the earliest issued of `tokens` other than `except`, it must be called with dtp.mu held
+func (dtp *DefaultTokenProvider) oldest_locked(tokens map[string]struct{}, except string) string {
+	var oldest string
+	var issued_at int64
+	for token := range tokens {
+		if entry := dtp.tokens[token]; token != except && (oldest == "" || entry.issued_at < issued_at) {
+			oldest, issued_at = token, entry.issued_at
+		}
+	}
+
+	return oldest
+}
+
+// delete_locked delete `token` and remove it from the index of its subject, it must be called with dtp.mu held
+func (dtp *DefaultTokenProvider) delete_locked(token string) {
+	entry, found := dtp.tokens[token]
+	if !found {
+		return
+	}
+
+	delete(dtp.tokens, token)
+
+	subject := SubjectOf(entry.subject)
+	if tokens, found := dtp.by_subject[subject]; found {
+		delete(tokens, token)
+		if len(tokens) == 0 {
+			delete(dtp.by_subject, subject)
+		}
+	}
+}
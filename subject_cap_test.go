@@ -0,0 +1,115 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestMaxTokensPerSubjectRemoved(t *testing.T) {
+	alice := csrf.WithSessionID(context.Background(), "alice")
+
+	tests := []struct {
+		name   string
+		remove func(t *testing.T, tp *csrf.DefaultTokenProvider, clock *csrftest.Clock, token string)
+	}{
+		{"consumed", func(t *testing.T, tp *csrf.DefaultTokenProvider, _ *csrftest.Clock, token string) {
+			if err := tp.CheckAndConsume(alice, token); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"exchanged", func(t *testing.T, tp *csrf.DefaultTokenProvider, _ *csrftest.Clock, token string) {
+			replacement, err := tp.Exchange(alice, token)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tp.CheckAndConsume(alice, replacement); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"revoked", func(t *testing.T, tp *csrf.DefaultTokenProvider, _ *csrftest.Clock, _ string) {
+			if err := tp.RevokeAll(alice, "alice"); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+			tp := new_provider(t, csrf.Config{MaxTokensPerSubject: 2, Clock: clock})
+
+			tt.remove(t, tp, clock, must_get(t, tp, alice))
+
+			// the removed token no longer count against the limit
+			first, second := must_get(t, tp, alice), must_get(t, tp, alice)
+			for _, token := range []string{first, second} {
+				if err := tp.Check(alice, token); err != nil {
+					t.Errorf("Check = %v", err)
+				}
+			}
+
+			if evicted := tp.Stats().Evicted; evicted != 0 {
+				t.Errorf("Evicted = %d, want 0", evicted)
+			}
+		})
+	}
+}
+
+func TestMaxTokensPerSubjectExpired(t *testing.T) {
+	alice := csrf.WithSessionID(context.Background(), "alice")
+	clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+	resets := make(chan time.Duration, 1)
+	tp := new_provider(t, csrf.Config{TTL: time.Minute, GCInterval: time.Second, MaxTokensPerSubject: 1, Clock: reset_clock{clock, resets}})
+	<-resets
+
+	expired := must_get(t, tp, alice)
+	clock.Advance(time.Minute)
+	<-resets
+
+	if err := tp.Check(alice, expired); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Fatalf("Check after gc = %v, want %v", err, csrf.ErrTokenNotFound)
+	}
+
+	must_get(t, tp, alice)
+	if evicted := tp.Stats().Evicted; evicted != 0 {
+		t.Errorf("Evicted = %d, want 0", evicted)
+	}
+}
+
+func TestMaxTokensPerSubjectBindings(t *testing.T) {
+	clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+	tp := new_provider(t, csrf.Config{MaxTokensPerSubject: 2, Clock: clock})
+	ctx := context.Background()
+
+	// the same subject from different ip and scope
+	first := must_get(t, tp, csrf.WithSessionID(ctx, "alice\x00192.0.2.1"))
+	clock.Advance(time.Second)
+	second := must_get(t, tp, csrf.WithSessionID(ctx, "alice\x00192.0.2.2\x01/delete"))
+	clock.Advance(time.Second)
+	third := must_get(t, tp, csrf.WithSessionID(ctx, "alice"))
+	other := must_get(t, tp, csrf.WithSessionID(ctx, "bob\x00192.0.2.1"))
+
+	tests := []struct {
+		name       string
+		session_id string
+		token      string
+		err        error
+	}{
+		{"oldest", "alice\x00192.0.2.1", first, csrf.ErrTokenNotFound},
+		{"scoped", "alice\x00192.0.2.2\x01/delete", second, nil},
+		{"newest", "alice", third, nil},
+		{"other subject", "bob\x00192.0.2.1", other, nil},
+	}
+
+	for _, tt := range tests {
+		if err := tp.Check(csrf.WithSessionID(ctx, tt.session_id), tt.token); !errors.Is(err, tt.err) {
+			t.Errorf("Check of %s = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package csrf
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+)
+
+var errNoContextToken = errors.New("no token in context, see CSRF.TokenMiddleware")
+
+// RequestToken return the token stored in `v` context by [CSRF.TokenMiddleware],
+// `v` is either [*http.Request] or [context.Context]
+func RequestToken(v any) (string, error) {
+	var token string
+	switch v := v.(type) {
+	case *http.Request:
+		token = TokenFromContext(v.Context())
+	case context.Context:
+		token = TokenFromContext(v)
+	}
+
+	if token == "" {
+		return "", errNoContextToken
+	}
+
+	return token, nil
+}
+
+// TemplateFuncs return [template.FuncMap] with
+//
+//   - `csrfToken` return the request token, e.g. `{{ csrfToken .Request }}`
+//   - `csrfField` return hidden input named by [WithFormField], e.g. `{{ csrfField .Request }}`
+//
+// both accept [*http.Request] or [context.Context] and fail if the request did not pass through [CSRF.TokenMiddleware]
+func TemplateFuncs(c *CSRF) template.FuncMap {
+	return template.FuncMap{
+		"csrfToken": RequestToken,
+		"csrfField": func(v any) (template.HTML, error) {
+			token, err := RequestToken(v)
+			if err != nil {
+				return "", err
+			}
+
+			return hidden_field(c.form_field, token), nil
+		},
+	}
+}
+
+func hidden_field(name, token string) template.HTML {
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(name) +
+		`" value="` + template.HTMLEscapeString(token) + `">`)
+}
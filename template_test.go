@@ -0,0 +1,45 @@
+package csrf_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithFormField("_token"))
+	tmpl := template.Must(template.New("").Funcs(csrf.TemplateFuncs(c)).Parse(
+		`{{ csrfToken . }}|{{ csrfField . }}|{{ csrfToken .Context }}`,
+	))
+
+	var out strings.Builder
+	h := c.TokenMiddleware(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := tmpl.Execute(&out, r); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	parts := strings.Split(out.String(), "|")
+	if len(parts) != 3 || parts[0] == "" {
+		t.Fatalf("rendered %q", out.String())
+	}
+
+	token := parts[0]
+	if want := `<input type="hidden" name="_token" value="` + token + `">`; parts[1] != want {
+		t.Errorf("csrfField = %s, want %s", parts[1], want)
+	}
+
+	if parts[2] != token {
+		t.Errorf("csrfToken of context = %s, want %s", parts[2], token)
+	}
+
+	// without TokenMiddleware
+	if err := tmpl.Execute(&out, httptest.NewRequest(http.MethodGet, "/", nil)); err == nil {
+		t.Error("Execute without token in context passed")
+	}
+}
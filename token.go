@@ -0,0 +1,44 @@
+package csrf
+
+import (
+	"context"
+	"time"
+)
+
+// Token is issued token with its expiry, see [CSRF.Issue]
+type Token struct {
+	Value    string
+	IssuedAt time.Time
+	// ExpireAt is zero if the [TokenProvider] does not implement [TTLProvider]
+	ExpireAt time.Time
+	// Meta is optional metadata attached to the token
+	Meta map[string]string
+}
+
+// Expired report whether the token is expired at `now`, token without expiry never expire
+func (t Token) Expired(now time.Time) bool {
+	return !t.ExpireAt.IsZero() && !now.Before(t.ExpireAt)
+}
+
+// Issue is [CSRF.GetToken] returning [Token] instead of the bare token,
+// the time is taken from the clock set by [WithClock] and the ttl override of [WithTokenTTL] is honored
+func (c *CSRF) Issue(ctx context.Context) (Token, error) {
+	// pooled token was issued before the call, see [PooledProvider]
+	issued_at := c.clock.Now()
+	value, err := c.GetToken(with_issued_at(ctx, &issued_at))
+	if err != nil {
+		return Token{}, err
+	}
+
+	return c.new_token(ctx, value, issued_at), nil
+}
+
+// new_token return [Token] of `value` issued at `now` with the expiry the [TokenProvider] give it
+func (c *CSRF) new_token(ctx context.Context, value string, now time.Time) Token {
+	token := Token{Value: value, IssuedAt: now}
+	if ttl := c.TTL(); ttl > 0 {
+		token.ExpireAt = now.Add(TTLFromContext(ctx, ttl))
+	}
+
+	return token
+}
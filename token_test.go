@@ -0,0 +1,85 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestIssue(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		ttl  time.Duration
+	}{
+		{"provider ttl", context.Background(), time.Hour},
+		{"ttl override", csrf.WithTokenTTL(context.Background(), time.Minute), time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+			tp := new_provider(t, csrf.Config{TTL: time.Hour, Clock: clock})
+			c := csrf.New(tp, csrf.WithClock(clock))
+
+			token, err := c.Issue(tt.ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if token.Value == "" {
+				t.Fatal("empty Value")
+			}
+
+			if !token.IssuedAt.Equal(clock.Now()) {
+				t.Errorf("IssuedAt = %v, want %v", token.IssuedAt, clock.Now())
+			}
+
+			if got := token.ExpireAt.Sub(token.IssuedAt); got != tt.ttl {
+				t.Errorf("ExpireAt - IssuedAt = %v, want %v", got, tt.ttl)
+			}
+
+			if token.Meta != nil {
+				t.Errorf("Meta = %v, want nil", token.Meta)
+			}
+
+			clock.Advance(token.ExpireAt.Sub(clock.Now()) - time.Second)
+			if err := tp.Check(context.Background(), token.Value); err != nil {
+				t.Errorf("Check before ExpireAt = %v", err)
+			}
+
+			clock.Advance(time.Second)
+			if err := tp.Check(context.Background(), token.Value); !errors.Is(err, csrf.ErrInvalidToken) {
+				t.Errorf("Check at ExpireAt = %v, want %v", err, csrf.ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestTokenExpired(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+
+	tests := []struct {
+		name   string
+		token  csrf.Token
+		at     time.Time
+		expect bool
+	}{
+		{"before", csrf.Token{ExpireAt: now}, now.Add(-time.Nanosecond), false},
+		{"at expiry", csrf.Token{ExpireAt: now}, now, true},
+		{"after", csrf.Token{ExpireAt: now}, now.Add(time.Second), true},
+		{"no expiry", csrf.Token{}, now.Add(100 * 365 * 24 * time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.token.Expired(tt.at); got != tt.expect {
+				t.Errorf("Expired = %v, want %v", got, tt.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package csrf
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracer record span for [CSRF.GetToken], [CSRF.Validate] and the [TokenProvider] calls
+// with the outcome, failure reason and the source which supplied the token as attributes
+func WithTracer(tracer trace.Tracer) Option {
+	return func(c *CSRF) {
+		c.tracer = tracer
+	}
+}
+
+func end_span(span trace.Span, err error) {
+	if err != nil {
+		span.SetAttributes(
+			attribute.String("csrf.outcome", "failure"),
+			attribute.String("csrf.reason", FailureReason(err)),
+		)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(attribute.String("csrf.outcome", "success"))
+	}
+
+	span.End()
+}
+
+// provider_span run [TokenProvider] call `op` in its own child span
+func provider_span[T any](ctx context.Context, c *CSRF, op string, call func(context.Context) (T, error)) (result T, err error) {
+	ctx, span := c.tracer.Start(ctx, "csrf.provider."+op, trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { end_span(span, err) }()
+
+	if span.IsRecording() {
+		span.SetAttributes(attribute.String("csrf.provider", fmt.Sprintf("%T", c.TokenProvider)))
+	}
+	return call(ctx)
+}
+
+// source_name return the function name of the source, e.g. `csrf.FormTokenSource`
+func source_name(source TokenSourceFunc) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(source).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := fn.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+
+	// closure returned by source constructor
+	if i := strings.Index(name, ".func"); i >= 0 {
+		name = name[:i]
+	}
+
+	return name
+}
+
+func record_source(ctx context.Context, source TokenSourceFunc) {
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(attribute.String("csrf.source", source_name(source)))
+	}
+}
@@ -0,0 +1,84 @@
+package csrf_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func span_attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]string {
+	attrs := make(map[attribute.Key]string)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+
+	return attrs
+}
+
+func TestWithTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithTracer(tracer))
+
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = c.Validate(post("/", token), csrf.HeaderTokenSource)
+	_ = c.Validate(post("/", token), csrf.HeaderTokenSource)
+
+	tests := []struct {
+		name   string
+		status codes.Code
+		attrs  map[attribute.Key]string
+	}{
+		{"csrf.GetToken", codes.Unset, map[attribute.Key]string{"csrf.outcome": "success"}},
+		{"csrf.Validate", codes.Unset, map[attribute.Key]string{"csrf.outcome": "success", "csrf.source": "csrf.HeaderTokenSource"}},
+		{"csrf.Validate", codes.Error, map[attribute.Key]string{"csrf.outcome": "failure", "csrf.reason": "token_not_found"}},
+	}
+
+	var spans []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		// provider calls are child spans
+		if span.Parent().IsValid() {
+			continue
+		}
+		spans = append(spans, span)
+	}
+
+	if len(spans) != len(tests) {
+		t.Fatalf("got %d root spans, want %d", len(spans), len(tests))
+	}
+
+	for i, tt := range tests {
+		span := spans[i]
+		if span.Name() != tt.name || span.Status().Code != tt.status {
+			t.Errorf("span #%d = %s %v, want %s %v", i, span.Name(), span.Status().Code, tt.name, tt.status)
+		}
+
+		attrs := span_attributes(span)
+		for k, v := range tt.attrs {
+			if attrs[k] != v {
+				t.Errorf("span #%d %s = %q, want %q", i, k, attrs[k], v)
+			}
+		}
+	}
+}
+
+func TestWithoutTracer(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}))
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Validate(post("/", token), csrf.HeaderTokenSource); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package csrf
+
+import (
+	"context"
+	"time"
+)
+
+// TTLProvider is optional interface for [TokenProvider] which know the lifetime of its tokens
+type TTLProvider interface {
+	TTL() time.Duration
+}
+
+func (dtp *DefaultTokenProvider) TTL() time.Duration { return dtp.token_ttl }
+
+func (htp *HashedTokenProvider) TTL() time.Duration { return htp.dtp.token_ttl }
+
+// ttl_provider forward every optional interface to the wrapped provider, those it does not implement
+// return the not supported error of the interface, except Consumer and BatchChecker which fall back to Check
+type ttl_provider struct {
+	TokenProvider
+	ttl time.Duration
+}
+
+var (
+	_ Consumer              = ttl_provider{}
+	_ Exchanger             = ttl_provider{}
+	_ BatchChecker          = ttl_provider{}
+	_ Revoker               = ttl_provider{}
+	_ Lister                = ttl_provider{}
+	_ TokenProviderWithMeta = ttl_provider{}
+	_ MetaConsumer          = ttl_provider{}
+	_ KeyRotator            = ttl_provider{}
+)
+
+func (tp ttl_provider) TTL() time.Duration { return tp.ttl }
+
+// with_ttl return `ctx` asking the wrapped provider for token of the reported ttl, unless it is already overridden
+func (tp ttl_provider) with_ttl(ctx context.Context) context.Context {
+	return WithTokenTTL(ctx, TTLFromContext(ctx, tp.ttl))
+}
+
+func (tp ttl_provider) Get(ctx context.Context) (string, error) {
+	return tp.TokenProvider.Get(tp.with_ttl(ctx))
+}
+
+func (tp ttl_provider) CheckAndConsume(ctx context.Context, token string) error {
+	return consume(ctx, tp.TokenProvider, token)
+}
+
+// Exchange consume `token` and issue new one, it is atomic only if the wrapped provider implements [Exchanger]
+func (tp ttl_provider) Exchange(ctx context.Context, token string) (string, error) {
+	if e, ok := tp.TokenProvider.(Exchanger); ok {
+		return e.Exchange(tp.with_ttl(ctx), token)
+	}
+
+	if err := consume(ctx, tp.TokenProvider, token); err != nil {
+		return "", err
+	}
+
+	return tp.Get(ctx)
+}
+
+func (tp ttl_provider) CheckBatch(ctx context.Context, tokens []string) []error {
+	if bc, ok := tp.TokenProvider.(BatchChecker); ok {
+		return bc.CheckBatch(ctx, tokens)
+	}
+
+	errs := make([]error, len(tokens))
+	for i, token := range tokens {
+		errs[i] = tp.TokenProvider.Check(ctx, token)
+	}
+
+	return errs
+}
+
+func (tp ttl_provider) RevokeAll(ctx context.Context, subject string) error {
+	revoker, ok := tp.TokenProvider.(Revoker)
+	if !ok {
+		return ErrRevokeNotSupported
+	}
+
+	return revoker.RevokeAll(ctx, subject)
+}
+
+func (tp ttl_provider) List(ctx context.Context, cursor string, limit int) ([]TokenInfo, string, error) {
+	lister, ok := tp.TokenProvider.(Lister)
+	if !ok {
+		return nil, "", ErrListNotSupported
+	}
+
+	return lister.List(ctx, cursor, limit)
+}
+
+func (tp ttl_provider) GetWithMeta(ctx context.Context, meta map[string]string) (string, error) {
+	tpm, ok := tp.TokenProvider.(TokenProviderWithMeta)
+	if !ok {
+		return "", ErrMetaNotSupported
+	}
+
+	return tpm.GetWithMeta(tp.with_ttl(ctx), meta)
+}
+
+func (tp ttl_provider) CheckWithMeta(ctx context.Context, token string) (map[string]string, error) {
+	tpm, ok := tp.TokenProvider.(TokenProviderWithMeta)
+	if !ok {
+		return nil, ErrMetaNotSupported
+	}
+
+	return tpm.CheckWithMeta(ctx, token)
+}
+
+func (tp ttl_provider) CheckAndConsumeWithMeta(ctx context.Context, token string) (map[string]string, error) {
+	mc, ok := tp.TokenProvider.(MetaConsumer)
+	if !ok {
+		return nil, ErrMetaNotSupported
+	}
+
+	return mc.CheckAndConsumeWithMeta(ctx, token)
+}
+
+func (tp ttl_provider) RotateKey(key []byte) error {
+	kr, ok := tp.TokenProvider.(KeyRotator)
+	if !ok {
+		return ErrKeyRotationNotSupported
+	}
+
+	return kr.RotateKey(key)
+}
+
+func (tp ttl_provider) IssuedAt(ctx context.Context, token string) (time.Time, error) {
+	iap, ok := tp.TokenProvider.(IssuedAtProvider)
+	if !ok {
+		return time.Time{}, errIssuedAtNotTracked
+	}
+
+	return iap.IssuedAt(ctx, token)
+}
+
+// ProviderWithTTL decorate `tp` which does not manage ttl by itself (e.g. raw key value store)
+// so it implements [TTLProvider] reporting `ttl`, the optional interfaces of `tp` are forwarded.
+// token is issued with `ttl` through [WithTokenTTL], `tp` must honor it for the reported ttl to be true
+func ProviderWithTTL(tp TokenProvider, ttl time.Duration) TokenProvider {
+	return ttl_provider{TokenProvider: tp, ttl: ttl}
+}
+
+type csrf_ttl_context_key int
+
+// WithTokenTTL override the ttl of token issued with the returned context, e.g. long lived multi step form.
+// it is honored by the providers of this package except [HMACTokenProvider] which can not carry the ttl in the token,
+// sliding expiration (see [Config.SlidingExpiration]) refresh with the default ttl
+func WithTokenTTL(ctx context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, csrf_ttl_context_key(0), ttl)
+}
+
+// TTLFromContext return the ttl set by [WithTokenTTL] or `fallback`, for [TokenProvider] implementations
+func TTLFromContext(ctx context.Context, fallback time.Duration) time.Duration {
+	if ttl, ok := ctx.Value(csrf_ttl_context_key(0)).(time.Duration); ok && ttl > 0 {
+		return ttl
+	}
+
+	return fallback
+}
+
+// GetTokenTTL is [CSRF.GetToken] with token valid for `ttl` instead of the provider default, see [WithTokenTTL]
+func (c *CSRF) GetTokenTTL(ctx context.Context, ttl time.Duration) (string, error) {
+	return c.GetToken(WithTokenTTL(ctx, ttl))
+}
+
+// TTL return the token lifetime reported by the [TokenProvider] or zero if it does not implement [TTLProvider],
+// use [ProviderWithTTL] to wrap such provider
+func (c *CSRF) TTL() time.Duration {
+	if tp, ok := c.TokenProvider.(TTLProvider); ok {
+		return tp.TTL()
+	}
+
+	return 0
+}
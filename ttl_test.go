@@ -0,0 +1,167 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bokunodev/csrf"
+	"github.com/bokunodev/csrf/csrftest"
+)
+
+func TestProviderWithTTLSingleUse(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider csrf.TokenProvider
+	}{
+		{"consumer", new_provider(t, csrf.Config{})},
+		{"fake", csrftest.NewProvider()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := csrf.New(csrf.ProviderWithTTL(tt.provider, time.Hour))
+			token, err := c.GetToken(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := c.Validate(post("/", token), csrf.HeaderTokenSource); err != nil {
+				t.Fatalf("first Validate = %v", err)
+			}
+
+			if err := c.Validate(post("/", token), csrf.HeaderTokenSource); !errors.Is(err, csrf.ErrTokenNotFound) {
+				t.Fatalf("replayed Validate = %v, want %v", err, csrf.ErrTokenNotFound)
+			}
+		})
+	}
+}
+
+func TestProviderWithTTLForward(t *testing.T) {
+	c := csrf.New(csrf.ProviderWithTTL(new_provider(t, csrf.Config{}), time.Hour))
+	ctx := csrf.WithSessionID(context.Background(), "alice")
+	token, err := c.GetToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tokens, _, err := c.List(ctx, "", 10); err != nil || len(tokens) != 1 {
+		t.Fatalf("List = %v, %v", tokens, err)
+	}
+
+	if err := c.RevokeAll(ctx, "alice"); err != nil {
+		t.Fatalf("RevokeAll = %v", err)
+	}
+
+	if err := c.TokenProvider.Check(ctx, token); !errors.Is(err, csrf.ErrTokenNotFound) {
+		t.Fatalf("Check after RevokeAll = %v", err)
+	}
+
+	fake := csrf.New(csrf.ProviderWithTTL(csrftest.NewProvider(), time.Hour))
+	if _, _, err := fake.List(ctx, "", 10); !errors.Is(err, csrf.ErrListNotSupported) {
+		t.Errorf("List of provider without Lister = %v, want %v", err, csrf.ErrListNotSupported)
+	}
+
+	if err := fake.RotateKey([]byte("key")); !errors.Is(err, csrf.ErrKeyRotationNotSupported) {
+		t.Errorf("RotateKey = %v, want %v", err, csrf.ErrKeyRotationNotSupported)
+	}
+}
+
+func TestProviderWithTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider csrf.TokenProvider
+		want     time.Duration
+	}{
+		{"wrapped", csrf.ProviderWithTTL(csrftest.NewProvider(), 10*time.Minute), 10 * time.Minute},
+		{"unwrapped", csrftest.NewProvider(), 0},
+		{"provider ttl", new_provider(t, csrf.Config{TTL: time.Hour}), time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := csrf.New(tt.provider)
+			if ttl := c.TTL(); ttl != tt.want {
+				t.Errorf("TTL = %v, want %v", ttl, tt.want)
+			}
+
+			token, err := c.Issue(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.want == 0 && !token.ExpireAt.IsZero() {
+				t.Errorf("ExpireAt = %v, want zero", token.ExpireAt)
+			}
+
+			if tt.want > 0 && token.ExpireAt.Sub(token.IssuedAt) != tt.want {
+				t.Errorf("ExpireAt - IssuedAt = %v, want %v", token.ExpireAt.Sub(token.IssuedAt), tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderWithTTLIssue(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		ttl  time.Duration
+	}{
+		{"longer than inner", context.Background(), time.Hour},
+		{"override", csrf.WithTokenTTL(context.Background(), 2*time.Hour), 2 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+			c := csrf.New(csrf.ProviderWithTTL(new_provider(t, csrf.Config{TTL: 15 * time.Minute, Clock: clock}), time.Hour),
+				csrf.WithTokenMode(csrf.MultiUse), csrf.WithClock(clock))
+
+			token, err := c.Issue(tt.ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := token.ExpireAt.Sub(token.IssuedAt); got != tt.ttl {
+				t.Errorf("ExpireAt - IssuedAt = %v, want %v", got, tt.ttl)
+			}
+
+			// past the ttl of the wrapped provider
+			clock.Advance(tt.ttl - time.Second)
+			if err := c.Validate(post("/", token.Value), csrf.HeaderTokenSource); err != nil {
+				t.Errorf("Validate before ExpireAt = %v", err)
+			}
+
+			clock.Advance(time.Second)
+			if err := c.Validate(post("/", token.Value), csrf.HeaderTokenSource); !errors.Is(err, csrf.ErrInvalidToken) {
+				t.Errorf("Validate at ExpireAt = %v, want %v", err, csrf.ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestWithTokenTTL(t *testing.T) {
+	clock := csrftest.NewClock(time.Unix(1_000_000, 0))
+	tp := new_provider(t, csrf.Config{TTL: time.Minute, Clock: clock})
+	c := csrf.New(tp, csrf.WithTokenMode(csrf.MultiUse))
+
+	short, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	long, err := c.GetTokenTTL(context.Background(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if err := c.Validate(post("/", short), csrf.HeaderTokenSource); !errors.Is(err, csrf.ErrTokenExpired) {
+		t.Errorf("Validate of default ttl token = %v, want %v", err, csrf.ErrTokenExpired)
+	}
+
+	if err := c.Validate(post("/", long), csrf.HeaderTokenSource); err != nil {
+		t.Errorf("Validate of long ttl token = %v", err)
+	}
+}
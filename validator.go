@@ -0,0 +1,52 @@
+package csrf
+
+import "net/http"
+
+// Validator validate request against the sources given to [CSRF.MustValidate]
+type Validator struct {
+	c       *CSRF
+	sources []TokenSourceFunc
+}
+
+// MustValidate return [Validator] bound to the given sources.
+// at least one source is required by the signature and it panics if any of the sources is nil,
+// so misconfiguration is caught when wiring up the handlers instead of on the first request
+func (c *CSRF) MustValidate(source TokenSourceFunc, sources ...TokenSourceFunc) *Validator {
+	sources = append([]TokenSourceFunc{source}, sources...)
+	for _, s := range sources {
+		if s == nil {
+			panic("`sources` must not contain nil")
+		}
+	}
+
+	return &Validator{c: c, sources: sources}
+}
+
+// Validate is [CSRF.Validate] with the bound sources
+func (v *Validator) Validate(r *http.Request) error {
+	return v.c.Validate(r, v.sources...)
+}
+
+// Func return [Validator.Validate] as [ValidatorFunc]
+func (v *Validator) Func() ValidatorFunc {
+	return v.Validate
+}
+
+// Middleware is [CSRF.Protect] with the bound sources
+func (v *Validator) Middleware(handle_failure FailureHandlerFunc) func(next http.Handler) http.Handler {
+	return v.c.Protect(handle_failure, v.sources...)
+}
+
+// Middleware call `next` if `validate` pass, otherwise respond with `handle_err`
+func (validate ValidatorFunc) Middleware(handle_err ErrorHandlerFunc) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := validate(r); err != nil {
+				handle_err(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
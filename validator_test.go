@@ -0,0 +1,150 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestMustValidate(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithTokenMode(csrf.MultiUse))
+	v := c.MustValidate(csrf.HeaderTokenSource, csrf.QueryTokenSource("csrf"))
+
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		header string
+		want   error
+	}{
+		{"both", "/?csrf=" + token, token, nil},
+		// every source must supply the token once one did
+		{"header only", "/", token, csrf.ErrInconsistentTokenBetweenSources},
+		{"mismatch", "/?csrf=other", token, csrf.ErrInconsistentTokenBetweenSources},
+		{"missing", "/", "", csrf.ErrTokenMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := v.Validate(post(tt.target, tt.header)); !errors.Is(err, tt.want) {
+				t.Errorf("Validate = %v, want %v", err, tt.want)
+			}
+
+			if err := v.Func()(post(tt.target, tt.header)); !errors.Is(err, tt.want) {
+				t.Errorf("Func = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	reached := false
+	mw := v.Middleware(func(w http.ResponseWriter, _ *http.Request, err error) bool {
+		w.WriteHeader(http.StatusForbidden)
+		return false
+	})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { reached = true }))
+
+	mw.ServeHTTP(httptest.NewRecorder(), post("/", ""))
+	if reached {
+		t.Error("Middleware passed request without token")
+	}
+
+	mw.ServeHTTP(httptest.NewRecorder(), post("/?csrf="+token, token))
+	if !reached {
+		t.Error("Middleware rejected valid request")
+	}
+}
+
+func TestMustValidateNilSource(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}))
+
+	tests := []struct {
+		name    string
+		sources []csrf.TokenSourceFunc
+	}{
+		{"first", []csrf.TokenSourceFunc{nil}},
+		{"rest", []csrf.TokenSourceFunc{csrf.HeaderTokenSource, nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("MustValidate did not panic")
+				}
+			}()
+
+			c.MustValidate(tt.sources[0], tt.sources[1:]...)
+		})
+	}
+}
+
+func TestValidateNoSources(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}))
+	if err := c.Validate(post("/", "token")); !errors.Is(err, csrf.ErrNoSources) {
+		t.Errorf("Validate = %v, want %v", err, csrf.ErrNoSources)
+	}
+}
+
+func TestValidatorsSafeMethods(t *testing.T) {
+	origin, err := csrf.NewOriginCheck("https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validators := []struct {
+		name     string
+		validate csrf.ValidatorFunc
+		err      error
+	}{
+		{"content type", csrf.ContentTypeCheck("application/json"), csrf.ErrContentTypeNotAllowed},
+		{"custom header", csrf.RequestedWithCheck(), csrf.ErrCustomHeaderMissing},
+		{"origin", origin.Validate, csrf.ErrOriginNotAllowed},
+		{"fetch metadata", csrf.FetchMetadataCheck{}.Validate, csrf.ErrCrossSiteRequest},
+	}
+
+	tests := []struct {
+		name   string
+		opts   []csrf.Option
+		method string
+		// whether the validator should run
+		unsafe bool
+	}{
+		{"default safe", nil, http.MethodOptions, false},
+		{"default unsafe", nil, http.MethodPost, true},
+		{"custom safe", []csrf.Option{csrf.WithSafeMethods(http.MethodGet)}, http.MethodGet, false},
+		{"custom unsafe", []csrf.Option{csrf.WithSafeMethods(http.MethodGet)}, http.MethodOptions, true},
+		{"no safe method", []csrf.Option{csrf.WithSafeMethods()}, http.MethodGet, true},
+	}
+
+	for _, v := range validators {
+		for _, tt := range tests {
+			t.Run(v.name+"/"+tt.name, func(t *testing.T) {
+				c := csrf.New(new_provider(t, csrf.Config{}), append(tt.opts, csrf.WithValidators(v.validate))...)
+				r := httptest.NewRequest(tt.method, "/", nil)
+				r.Header.Set("Origin", "https://evil.example")
+				r.Header.Set("Sec-Fetch-Site", "cross-site")
+
+				want := csrf.ErrTokenMissing
+				if tt.unsafe {
+					want = v.err
+				}
+
+				if err := c.Validate(r, csrf.HeaderTokenSource); !errors.Is(err, want) {
+					t.Errorf("Validate = %v, want %v", err, want)
+				}
+			})
+		}
+
+		// on its own the validator use DefaultSafeMethods
+		if err := v.validate(httptest.NewRequest(http.MethodOptions, "/", nil)); err != nil {
+			t.Errorf("%s of OPTIONS = %v", v.name, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package csrf
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DefaultWebSocketProtocolPrefix is the prefix of the `Sec-WebSocket-Protocol` entry carrying the token,
+// e.g. `new WebSocket(url, ["csrf.` + token + `", "chat"])`
+const DefaultWebSocketProtocolPrefix = "csrf."
+
+// WebSocketProtocolSource return token from `Sec-WebSocket-Protocol` entry starting with `prefix`,
+// browsers can not set custom header on websocket handshake
+func WebSocketProtocolSource(prefix string) TokenSourceFunc {
+	return func(r *http.Request) string {
+		for _, protocol := range websocket_protocols(r) {
+			if token, ok := strings.CutPrefix(protocol, prefix); ok {
+				return token
+			}
+		}
+
+		return ""
+	}
+}
+
+// WebSocketProtocols return the requested subprotocols without the entry carrying the token,
+// the server must not select the token entry as the negotiated subprotocol
+func WebSocketProtocols(r *http.Request, prefix string) []string {
+	protocols := websocket_protocols(r)
+	n := 0
+	for _, protocol := range protocols {
+		if !strings.HasPrefix(protocol, prefix) {
+			protocols[n] = protocol
+			n++
+		}
+	}
+
+	return protocols[:n]
+}
+
+func websocket_protocols(r *http.Request) []string {
+	var protocols []string
+	for _, value := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(value, ",") {
+			if protocol = strings.TrimSpace(protocol); protocol != "" {
+				protocols = append(protocols, protocol)
+			}
+		}
+	}
+
+	return protocols
+}
+
+// ValidateUpgrade is [CSRF.Validate] for websocket handshake, which is GET request and thus skipped by the middlewares
+// while it can be initiated cross origin. it must be called before the connection is upgraded,
+// the token is read from [WebSocketProtocolSource] with [DefaultWebSocketProtocolPrefix] if no source is given,
+// use [QueryTokenSource] for token in the url
+func (c *CSRF) ValidateUpgrade(r *http.Request, sources ...TokenSourceFunc) error {
+	if len(sources) == 0 {
+		sources = []TokenSourceFunc{WebSocketProtocolSource(DefaultWebSocketProtocolPrefix)}
+	}
+
+	return c.Validate(r, sources...)
+}
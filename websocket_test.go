@@ -0,0 +1,57 @@
+package csrf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/bokunodev/csrf"
+)
+
+func TestValidateUpgrade(t *testing.T) {
+	c := csrf.New(new_provider(t, csrf.Config{}), csrf.WithTokenMode(csrf.MultiUse))
+	token, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		protocols []string
+		query     string
+		sources   []csrf.TokenSourceFunc
+		want      error
+	}{
+		{"protocol", []string{"chat, csrf." + token}, "", nil, nil},
+		{"protocol header per entry", []string{"chat", "csrf." + token}, "", nil, nil},
+		{"no protocol", []string{"chat"}, "", nil, csrf.ErrTokenMissing},
+		{"unknown", []string{"csrf.unknown"}, "", nil, csrf.ErrTokenNotFound},
+		{"query", nil, "?csrf=" + token, []csrf.TokenSourceFunc{csrf.QueryTokenSource("csrf")}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/ws"+tt.query, nil)
+			for _, protocol := range tt.protocols {
+				r.Header.Add("Sec-WebSocket-Protocol", protocol)
+			}
+
+			if err := c.ValidateUpgrade(r, tt.sources...); !errors.Is(err, tt.want) {
+				t.Errorf("ValidateUpgrade = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebSocketProtocols(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	r.Header.Add("Sec-WebSocket-Protocol", "chat, csrf.token")
+	r.Header.Add("Sec-WebSocket-Protocol", "v2")
+
+	if got, want := csrf.WebSocketProtocols(r, csrf.DefaultWebSocketProtocolPrefix), []string{"chat", "v2"}; !slices.Equal(got, want) {
+		t.Errorf("WebSocketProtocols = %v, want %v", got, want)
+	}
+}